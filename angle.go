@@ -0,0 +1,58 @@
+package arithm
+
+import "math"
+
+// === Angles ================================================================
+
+// Rad is an angle in radians. Functions of this module expect angles in
+// radians unless stated otherwise; using Rad and Deg at API boundaries
+// makes the unit of an angle explicit.
+type Rad float64
+
+// Deg is an angle in degrees, as used by MetaFont and MetaPost.
+type Deg float64
+
+// Deg converts an angle from radians to degrees.
+func (a Rad) Deg() Deg {
+	return Deg(float64(a) * 180 / math.Pi)
+}
+
+// Rad converts an angle from degrees to radians.
+func (a Deg) Rad() Rad {
+	return Rad(float64(a) * math.Pi / 180)
+}
+
+// Normalized returns an angle normalized to (-π,π].
+func (a Rad) Normalized() Rad {
+	return Rad(NormalizePi(float64(a)))
+}
+
+// Normalized returns an angle normalized to (-180,180].
+func (a Deg) Normalized() Deg {
+	d := math.Remainder(float64(a), 360)
+	if d <= -180 {
+		d += 360
+	}
+	return Deg(d)
+}
+
+// NormalizePi normalizes an angle in radians to (-π,π].
+func NormalizePi(a float64) float64 {
+	a = math.Remainder(a, 2*math.Pi)
+	if a <= -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+// Normalize2Pi normalizes an angle in radians to [0,2π).
+func Normalize2Pi(a float64) float64 {
+	a = math.Mod(a, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	if a >= 2*math.Pi { // a was a tiny negative number
+		a = 0
+	}
+	return a
+}
@@ -0,0 +1,46 @@
+package arithm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestAngleNormalization(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	for _, x := range []struct{ a, pi, twopi float64 }{
+		{0, 0, 0},
+		{math.Pi, math.Pi, math.Pi},
+		{-math.Pi, math.Pi, math.Pi},
+		{3 * math.Pi / 2, -math.Pi / 2, 3 * math.Pi / 2},
+		{-math.Pi / 2, -math.Pi / 2, 3 * math.Pi / 2},
+		{7 * math.Pi, math.Pi, math.Pi},
+		{-1e-20, -1e-20, 0},
+	} {
+		if a := NormalizePi(x.a); math.Abs(a-x.pi) > 1e-12 {
+			t.Errorf("Expected NormalizePi(%g) = %g, is %g", x.a, x.pi, a)
+		}
+		if a := Normalize2Pi(x.a); math.Abs(a-x.twopi) > 1e-12 || a < 0 || a >= 2*math.Pi {
+			t.Errorf("Expected Normalize2Pi(%g) = %g, is %g", x.a, x.twopi, a)
+		}
+	}
+}
+
+func TestAngleUnits(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if r := Deg(90).Rad(); r != math.Pi/2 {
+		t.Errorf("Expected 90° = π/2, is %g", r)
+	}
+	if d := Rad(math.Pi).Deg(); d != 180 {
+		t.Errorf("Expected π = 180°, is %g", d)
+	}
+	if d := Deg(-540).Normalized(); d != 180 {
+		t.Errorf("Expected -540° to normalize to 180°, is %g", d)
+	}
+	if r := Rad(-3 * math.Pi / 2).Normalized(); math.Abs(float64(r)-math.Pi/2) > 1e-12 {
+		t.Errorf("Expected -3π/2 to normalize to π/2, is %g", r)
+	}
+}
@@ -38,6 +38,7 @@ OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.  */
 package arithm
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/cmplx"
@@ -61,25 +62,53 @@ var Epsilon float64 = 0.0000001
 
 // Is0 is a predicate: is n = 0 ?
 func Is0(n float64) bool {
-	return math.Abs(n) <= Epsilon
+	return Tolerance(Epsilon).Is0(n)
 }
 
 // Is1 is a predicate: is n = 1.0 ?
 func Is1(n float64) bool {
-	return math.Abs(1-n) <= Epsilon
+	return Tolerance(Epsilon).Is1(n)
 }
 
 // Zap makes n = 0 if n "means" to be zero
 func Zap(n float64) float64 {
-	if Is0(n) {
+	return Tolerance(Epsilon).Zap(n)
+}
+
+// Round to ε.
+func Round(n float64) float64 {
+	return Tolerance(Epsilon).Round(n)
+}
+
+// Tolerance is a scoped alternative to the package-level Epsilon: numbers
+// below the tolerance are considered 0. Clients needing a tolerance
+// different from Epsilon should use a Tolerance value instead of changing
+// Epsilon, which would affect every user of this package and is not safe for
+// concurrent use. The package-level functions Is0, Is1, Zap and Round use a
+// tolerance of Epsilon.
+type Tolerance float64
+
+// Is0 is a predicate: is n = 0 ?
+func (tol Tolerance) Is0(n float64) bool {
+	return math.Abs(n) <= float64(tol)
+}
+
+// Is1 is a predicate: is n = 1.0 ?
+func (tol Tolerance) Is1(n float64) bool {
+	return math.Abs(1-n) <= float64(tol)
+}
+
+// Zap makes n = 0 if n "means" to be zero.
+func (tol Tolerance) Zap(n float64) float64 {
+	if tol.Is0(n) {
 		n = 0
 	}
 	return n
 }
 
-// Round to ε.
-func Round(n float64) float64 {
-	return math.Round(n/Epsilon) * Epsilon
+// Round rounds n to a multiple of the tolerance.
+func (tol Tolerance) Round(n float64) float64 {
+	return math.Round(n/float64(tol)) * float64(tol)
 }
 
 // === Pair Data Type ========================================================
@@ -100,13 +129,62 @@ func (p Pair) C() complex128 {
 	return complex128(p)
 }
 
-// C2P returns a Pair from a complex number.
+// NaNPolicy controls how complex numbers which are NaN or infinite are
+// converted to pairs. Like Tolerance, a policy is a value passed to where it
+// applies, instead of a package-level setting: pipelines which have to fail
+// fast on invalid geometry use NaNPanic.C2P or NaNError.C2PE, without
+// affecting other users of this package. The package-level functions C2P and
+// C2PE use policy NaNZero.
+type NaNPolicy int
+
+// Policies for invalid complex numbers.
+const (
+	NaNZero  NaNPolicy = iota // convert to the origin and report an error
+	NaNError                  // keep the invalid value and report an error
+	NaNPanic                  // panic with ErrInvalidPair
+)
+
+// ErrInvalidPair is reported when creating a pair from a complex number
+// which is NaN or infinite.
+var ErrInvalidPair = errors.New("pair created from NaN or infinite complex number")
+
+// C2P returns a Pair from a complex number. NaN and infinite numbers are
+// converted to the origin, tracing an error.
 func C2P(c complex128) Pair {
+	return NaNZero.C2P(c)
+}
+
+// C2PE returns a Pair from a complex number. For a NaN or infinite number,
+// it returns ErrInvalidPair together with the origin.
+func C2PE(c complex128) (Pair, error) {
+	return NaNZero.C2PE(c)
+}
+
+// C2P returns a Pair from a complex number, treating NaN and infinite
+// numbers according to the policy. As C2P cannot return ErrInvalidPair, the
+// error is traced for policies NaNZero and NaNError.
+func (pol NaNPolicy) C2P(c complex128) Pair {
+	p, err := pol.C2PE(c)
+	if err != nil {
+		T().Errorf("C2P(%v): %v", c, err)
+	}
+	return p
+}
+
+// C2PE returns a Pair from a complex number. For a NaN or infinite number,
+// it returns ErrInvalidPair together with the origin (policy NaNZero) or
+// with the invalid pair (policy NaNError), or panics (policy NaNPanic).
+func (pol NaNPolicy) C2PE(c complex128) (Pair, error) {
 	if cmplx.IsNaN(c) || cmplx.IsInf(c) {
-		T().Errorf("created pair for complex.NaN")
-		return P(0, 0)
+		switch pol {
+		case NaNPanic:
+			panic(ErrInvalidPair)
+		case NaNError:
+			return Pair(c), ErrInvalidPair
+		}
+		return P(0, 0), ErrInvalidPair
 	}
-	return P(real(c), imag(c))
+	return P(real(c), imag(c)), nil
 }
 
 // P is a quick notation for contructing a pair from floats.
@@ -169,17 +247,152 @@ func (p Pair) Shifted(v Pair) Pair {
 	return T.Transform(p).Zap()
 }
 
+// Add returns the vector sum p+q. Contrary to Shifted, the result is not
+// zapped.
+func (p Pair) Add(q Pair) Pair {
+	return P(p.X()+q.X(), p.Y()+q.Y())
+}
+
+// Sub returns the vector difference p-q.
+func (p Pair) Sub(q Pair) Pair {
+	return P(p.X()-q.X(), p.Y()-q.Y())
+}
+
+// Mul returns p multiplied by scalar s. Multiplying two pairs with operator
+// '*' performs complex multiplication instead, which is rarely intended.
+// Contrary to Scaled, the result is not zapped.
+func (p Pair) Mul(s float64) Pair {
+	return P(p.X()*s, p.Y()*s)
+}
+
+// Div returns p divided by scalar s.
+func (p Pair) Div(s float64) Pair {
+	return P(p.X()/s, p.Y()/s)
+}
+
 // Rotated returns a new pair rotated around origin by theta (counterclockwise).
 func (p Pair) Rotated(theta float64) Pair {
 	T := Rotation(theta)
 	return T.Transform(p).Zap()
 }
 
+// Rotated90 returns a new pair rotated counter-clockwise around origin by 90°.
+// Contrary to Rotated, the result is exact.
+func (p Pair) Rotated90() Pair {
+	return P(-p.Y(), p.X())
+}
+
+// Rotated180 returns a new pair rotated around origin by 180°, exactly.
+func (p Pair) Rotated180() Pair {
+	return P(-p.X(), -p.Y())
+}
+
+// Rotated270 returns a new pair rotated counter-clockwise around origin by
+// 270°, i.e. clockwise by 90°, exactly.
+func (p Pair) Rotated270() Pair {
+	return P(p.Y(), -p.X())
+}
+
 // Rotatedaround returns a new pair rotated around v by theta (counterclockwise).
 func (p Pair) Rotatedaround(v Pair, theta float64) Pair {
 	return p.Shifted(-v).Rotated(theta).Shifted(v).Zap()
 }
 
+// Dot returns the dot product (scalar product) of two pairs.
+func (p Pair) Dot(q Pair) float64 {
+	return p.X()*q.X() + p.Y()*q.Y()
+}
+
+// Cross returns the 2D cross product of two pairs, i.e. the z-component of
+// the 3D cross product. It is positive if q points counter-clockwise of p.
+func (p Pair) Cross(q Pair) float64 {
+	return p.X()*q.Y() - p.Y()*q.X()
+}
+
+// Length returns the length of a pair, i.e. its distance from the origin
+// (MetaFont: "abs p").
+func (p Pair) Length() float64 {
+	return math.Hypot(p.X(), p.Y())
+}
+
+// LengthSquared returns the squared length of a pair. It is cheaper than
+// Length and sufficient for comparing distances.
+func (p Pair) LengthSquared() float64 {
+	return p.X()*p.X() + p.Y()*p.Y()
+}
+
+// Unit returns a pair of length 1 pointing in the direction of p
+// (MetaFont: "unitvector p"). The unit vector of the origin is the origin.
+func (p Pair) Unit() Pair {
+	l := p.Length()
+	if l == 0 {
+		return Origin
+	}
+	return P(p.X()/l, p.Y()/l)
+}
+
+// Angle returns the direction of a pair in radians, counter-clockwise from
+// the positive x-axis, normalized to (-π,π] (MetaPost: "angle p", but in
+// radians). The angle of the origin is 0.
+func (p Pair) Angle() float64 {
+	return NormalizePi(math.Atan2(p.Y(), p.X()))
+}
+
+// AngleDeg returns the direction of a pair in degrees, normalized to
+// (-180,180] (MetaPost: "angle p").
+func (p Pair) AngleDeg() float64 {
+	return p.Angle() * 180 / math.Pi
+}
+
+// AngleBetween returns the angle to turn from the direction of p to the
+// direction of q, in radians and normalized to (-π,π]. Positive angles
+// turn counter-clockwise.
+func AngleBetween(p, q Pair) float64 {
+	return NormalizePi(math.Atan2(p.Cross(q), p.Dot(q)))
+}
+
+// Lerp returns the point at fraction t of the way from a to b, i.e.
+// a + t(b-a) (MetaFont mediation: "t[a,b]"). t is not restricted to [0,1].
+func Lerp(t float64, a, b Pair) Pair {
+	return P(Mediate(t, a.X(), b.X()), Mediate(t, a.Y(), b.Y()))
+}
+
+// Mediate returns the number at fraction t of the way from x to y, i.e.
+// x + t(y-x) (MetaFont: "t[x,y]").
+func Mediate(t, x, y float64) float64 {
+	return x + t*(y-x)
+}
+
+// Quantized returns p with its coordinates rounded to the nearest multiple
+// of step, i.e. snapped to a grid of width step. For step ≤ 0, p is
+// returned unchanged.
+func (p Pair) Quantized(step float64) Pair {
+	if step <= 0 {
+		return p
+	}
+	return P(math.Round(p.X()/step)*step, math.Round(p.Y()/step)*step)
+}
+
+// GridKey identifies a cell of a grid. It is comparable and may be used as
+// a map key, where Pairs would suffer from rounding differences.
+type GridKey struct {
+	X, Y int64
+}
+
+// Key returns the grid cell of p for a grid of width step > 0, i.e. the
+// position of p.Quantized(step) in units of step. Pairs differing by less
+// than step/2 in both coordinates usually share a key, thus nearly identical
+// points may be de-duplicated with a map[GridKey]…
+//
+// For step ≤ 0, p is not quantized (see Quantized), and only identical
+// pairs share a key.
+func (p Pair) Key(step float64) GridKey {
+	if step <= 0 { // keys from the bits of the coordinates, with -0 = +0
+		return GridKey{int64(math.Float64bits(p.X() + 0)), int64(math.Float64bits(p.Y() + 0))}
+	}
+	return GridKey{int64(math.Round(p.X() / step)), int64(math.Round(p.Y() / step))}
+}
+
 // === Affine Transformations ================================================
 
 // AT is an affine transform, a matrix type used for transforming vectors.
@@ -243,6 +456,51 @@ func Rotation(theta float64) AT {
 	return m
 }
 
+// RotationAround transform. Rotate a point counter-clockwise around center
+// (MetaFont: "rotatedaround(center, theta)", but in radians).
+func RotationAround(center Pair, theta float64) AT {
+	return Compose(Translation(-center), Rotation(theta), Translation(center))
+}
+
+// Scaling transform. Scale a point by sx in x-direction and by sy in
+// y-direction, relative to the origin (MetaFont: "xscaled sx yscaled sy").
+func Scaling(sx, sy float64) AT {
+	m := newAT()
+	m.set(0, 0, sx)
+	m.set(1, 1, sy)
+	m.set(2, 2, 1.0)
+	return m
+}
+
+// ScalingAround transform. Scale a point by sx in x-direction and by sy in
+// y-direction, relative to center, which stays fixed. Used e.g. for zooming
+// about a focus point.
+func ScalingAround(center Pair, sx, sy float64) AT {
+	return Compose(Translation(-center), Scaling(sx, sy), Translation(center))
+}
+
+// UniformScaling transform. Scale a point by s in both directions, relative
+// to the origin (MetaFont: "scaled s").
+func UniformScaling(s float64) AT {
+	return Scaling(s, s)
+}
+
+// Slanted transform. Slant a point by s, i.e. shift it horizontally by s
+// times its y-coordinate (MetaFont: "slanted s"). Slanting is used to derive
+// oblique typefaces from upright ones.
+func Slanted(s float64) AT {
+	return Shear(s, 0)
+}
+
+// Shear transform. Shift a point horizontally by sx times its y-coordinate
+// and vertically by sy times its x-coordinate.
+func Shear(sx, sy float64) AT {
+	m := Identity()
+	m.set(0, 1, sx)
+	m.set(1, 0, sy)
+	return m
+}
+
 // Debug Stringer for an affine transform.
 func (m AT) String() string {
 	s := fmt.Sprintf("[%g,%g,%g|%g,%g,%g|%g,%g,%g]",
@@ -250,6 +508,46 @@ func (m AT) String() string {
 	return s
 }
 
+// Equal compares two affine transforms component-wise. Components differing
+// by no more than eps are considered equal.
+func (m AT) Equal(n AT, eps float64) bool {
+	if len(m) != len(n) {
+		return false
+	}
+	for i := range m {
+		if !(math.Abs(m[i]-n[i]) <= eps) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsIdentity is a predicate: does m map every point onto itself?
+// Components are compared with tolerance Epsilon.
+func (m AT) IsIdentity() bool {
+	return m.IsTranslation() && Is0(m.get(0, 2)) && Is0(m.get(1, 2))
+}
+
+// IsTranslation is a predicate: does m shift every point by the same
+// vector? The identity is a translation by (0,0).
+func (m AT) IsTranslation() bool {
+	return Is1(m.get(0, 0)) && Is0(m.get(0, 1)) && Is0(m.get(1, 0)) && Is1(m.get(1, 1))
+}
+
+// IsRigid is a predicate: does m preserve distances between points, i.e. is
+// m a combination of rotations, translations and reflections?
+func (m AT) IsRigid() bool {
+	a, b, c, d := m.get(0, 0), m.get(0, 1), m.get(1, 0), m.get(1, 1)
+	return Is1(a*a+c*c) && Is1(b*b+d*d) && Is0(a*b+c*d)
+}
+
+// PreservesOrientation is a predicate: does m keep the orientation of
+// figures, i.e. is m free of reflections? Singular transforms do not
+// preserve orientation.
+func (m AT) PreservesOrientation() bool {
+	return m.get(0, 0)*m.get(1, 1)-m.get(0, 1)*m.get(1, 0) > 0
+}
+
 // v1 × v2, v.n = [a,b,c]
 func dotProd(vec1, vec2 []float64) float64 {
 	p1 := vec1[0] * vec2[0]
@@ -270,20 +568,215 @@ func (m AT) Combine(n AT) AT {
 	return o
 }
 
-func (m *AT) multiplyVector(v []float64) []float64 {
-	c := make([]float64, 3)
-	c[0] = dotProd(m.row(0), v)
-	c[1] = dotProd(m.row(1), v)
-	c[2] = dotProd(m.row(2), v)
-	return c
+// Compose combines a sequence of affine transforms into one. The transforms
+// are applied in the order given, i.e. Compose(t1, t2, t3) transforms a
+// point by t1 first and by t3 last. Compose() is the identity.
+func Compose(ts ...AT) AT {
+	m := Identity()
+	for _, t := range ts {
+		m = m.Combine(t)
+	}
+	return m
+}
+
+// Translate returns a transform which applies m and then translates by v
+// (MetaFont: "shifted v"). Together with Rotate and Scale it allows to build
+// transforms step by step, e.g. Identity().Rotate(θ).Translate(v).
+func (m AT) Translate(v Pair) AT {
+	return m.Combine(Translation(v))
+}
+
+// Rotate returns a transform which applies m and then rotates
+// counter-clockwise around the origin by theta, given in radians
+// (MetaFont: "rotated", but in radians).
+func (m AT) Rotate(theta float64) AT {
+	return m.Combine(Rotation(theta))
+}
+
+// Scale returns a transform which applies m and then scales by s relative to
+// the origin (MetaFont: "scaled s").
+func (m AT) Scale(s float64) AT {
+	return m.Combine(UniformScaling(s))
 }
 
 // Transform a 2D-point. The argument is unchanged and a new pair is returned.
 func (m AT) Transform(p Pair) Pair {
-	c := make([]float64, 3)
-	c[0] = p.X()
-	c[1] = p.Y()
-	c[2] = 1.0
-	c = m.multiplyVector(c)
-	return P(c[0], c[1])
+	x, y := p.X(), p.Y()
+	return P(m[0]*x+m[1]*y+m[2], m[3]*x+m[4]*y+m[5])
+}
+
+// Transformable is implemented by geometric objects which may be
+// transformed by an affine transform, e.g. paths and polygons. It allows to
+// apply a transform uniformly to geometry of different types. Transformed
+// returns a transformed copy of the object, of the same dynamic type.
+type Transformable interface {
+	Transformed(AT) Transformable
+}
+
+// TransformInto transforms all points of src and stores them in dst, which
+// is re-used if its capacity suffices. It returns the resulting slice of
+// length len(src). dst and src may be the same slice, transforming points
+// in place. Render loops may use it to transform large paths without
+// allocating.
+func (m AT) TransformInto(dst, src []Pair) []Pair {
+	if cap(dst) < len(src) {
+		dst = make([]Pair, len(src))
+	}
+	dst = dst[:len(src)]
+	for i, p := range src {
+		dst[i] = m.Transform(p)
+	}
+	return dst
+}
+
+// ErrSingular is returned when inverting a transform which cannot be
+// inverted, e.g. a transform which scales by 0.
+var ErrSingular = errors.New("affine transform is singular")
+
+// Inverse returns the inverse of an affine transform, i.e. the transform
+// which maps transformed points back onto the original ones (MetaPost:
+// "inverse t"). m is expected to have a last row of (0,0,1).
+// If m is singular, Inverse returns ErrSingular.
+func (m AT) Inverse() (AT, error) {
+	a, b, c, d := m.get(0, 0), m.get(0, 1), m.get(1, 0), m.get(1, 1)
+	det := a*d - b*c
+	scale := math.Max(math.Max(math.Abs(a), math.Abs(b)), math.Max(math.Abs(c), math.Abs(d)))
+	if math.IsNaN(det) || math.Abs(det) <= Epsilon*scale*scale {
+		return nil, ErrSingular
+	}
+	tx, ty := m.get(0, 2), m.get(1, 2)
+	inv := newAT()
+	inv.set(0, 0, d/det)
+	inv.set(0, 1, -b/det)
+	inv.set(1, 0, -c/det)
+	inv.set(1, 1, a/det)
+	inv.set(0, 2, (b*ty-d*tx)/det)
+	inv.set(1, 2, (c*tx-a*ty)/det)
+	inv.set(2, 2, 1.0)
+	return inv, nil
+}
+
+// Decomposition holds the components of an affine transform, as found by
+// AT.Decompose.
+type Decomposition struct {
+	Translation Pair    // translation (dx,dy)
+	Rotation    float64 // counter-clockwise rotation, in radians
+	Shear       float64 // horizontal shear factor, i.e. tangent of skew angle
+	Scale       Pair    // scale factors (sx,sy); sy < 0 for reflections
+	Reflected   bool    // does the transform mirror points?
+}
+
+// Decompose splits an affine transform into components, such that
+// applying
+//
+//	Scaling(d.Scale.X(), d.Scale.Y())
+//	Shear(d.Shear, 0)
+//	Rotation(d.Rotation)
+//	Translation(d.Translation)
+//
+// in this order is equivalent to m. This corresponds to the SVG attribute
+// transform="translate(…) rotate(…) skewX(…) scale(…)", with angles converted
+// to degrees. m is expected to have a last row of (0,0,1). For singular
+// transforms, components which cannot be determined are 0.
+func (m AT) Decompose() Decomposition {
+	a, b, c, d := m.get(0, 0), m.get(0, 1), m.get(1, 0), m.get(1, 1)
+	dec := Decomposition{Translation: P(m.get(0, 2), m.get(1, 2))}
+	sx := math.Hypot(a, c)
+	if !Is0(sx) {
+		dec.Rotation = math.Atan2(c, a)
+	}
+	cos, sin := math.Cos(dec.Rotation), math.Sin(dec.Rotation)
+	sy := d*cos - b*sin
+	if !Is0(sy) {
+		dec.Shear = (b*cos + d*sin) / sy
+	}
+	dec.Scale = P(sx, sy)
+	dec.Reflected = a*d-b*c < 0
+	return dec
+}
+
+// --- 2x3 Matrix Forms ------------------------------------------------------
+
+// RowMajor returns the first two rows of m, i.e. (a,b,c,d,e,f) for
+//
+//	x' = a⋅x + b⋅y + c
+//	y' = d⋅x + e⋅y + f
+//
+// This is the element order of golang.org/x/image/math/f64.Aff3, which may
+// be created by conversion: f64.Aff3(m.RowMajor()). It is also the order of
+// the arguments of gio's f32.NewAffine2D and the results of Affine2D.Elems.
+func (m AT) RowMajor() [6]float64 {
+	return [6]float64{m[0], m[1], m[2], m[3], m[4], m[5]}
+}
+
+// FromRowMajor creates an affine transform from the first two rows of its
+// matrix, as returned by RowMajor.
+func FromRowMajor(r [6]float64) AT {
+	m := Identity()
+	copy(m, r[:])
+	return m
+}
+
+// ColumnMajor returns the first two rows of m in column order, i.e.
+// (a,b,c,d,e,f) for
+//
+//	x' = a⋅x + c⋅y + e
+//	y' = b⋅x + d⋅y + f
+//
+// This is the order of the fields of gg.Matrix (XX, YX, XY, YY, X0, Y0), of
+// SVG's matrix(a,b,c,d,e,f) and of PDF's transformation matrices.
+func (m AT) ColumnMajor() [6]float64 {
+	return [6]float64{m[0], m[3], m[1], m[4], m[2], m[5]}
+}
+
+// FromColumnMajor creates an affine transform from the elements of its
+// matrix in column order, as returned by ColumnMajor.
+func FromColumnMajor(c [6]float64) AT {
+	return FromRowMajor([6]float64{c[0], c[2], c[4], c[1], c[3], c[5]})
+}
+
+// --- MetaPost Transform Parts ----------------------------------------------
+
+// FromParts creates an affine transform from its six parts, as in MetaPost's
+// "(tx,ty,txx,txy,tyx,tyy)". The transform maps (x,y) to
+//
+//	(tx + txx⋅x + txy⋅y, ty + tyx⋅x + tyy⋅y)
+func FromParts(tx, ty, txx, txy, tyx, tyy float64) AT {
+	return FromRowMajor([6]float64{txx, txy, tx, tyx, tyy, ty})
+}
+
+// XPart returns the x-part of a transform, i.e. its horizontal translation
+// (MetaPost: "xpart t").
+func (m AT) XPart() float64 {
+	return m.get(0, 2)
+}
+
+// YPart returns the y-part of a transform, i.e. its vertical translation
+// (MetaPost: "ypart t").
+func (m AT) YPart() float64 {
+	return m.get(1, 2)
+}
+
+// XXPart returns the factor of x in the transformed x-coordinate
+// (MetaPost: "xxpart t").
+func (m AT) XXPart() float64 {
+	return m.get(0, 0)
+}
+
+// XYPart returns the factor of y in the transformed x-coordinate
+// (MetaPost: "xypart t").
+func (m AT) XYPart() float64 {
+	return m.get(0, 1)
+}
+
+// YXPart returns the factor of x in the transformed y-coordinate
+// (MetaPost: "yxpart t").
+func (m AT) YXPart() float64 {
+	return m.get(1, 0)
+}
+
+// YYPart returns the factor of y in the transformed y-coordinate
+// (MetaPost: "yypart t").
+func (m AT) YYPart() float64 {
+	return m.get(1, 1)
 }
@@ -1,6 +1,8 @@
 package arithm
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 
 	"github.com/npillmayer/schuko/tracing/gotestingadapter"
@@ -41,3 +43,360 @@ func TestTranslation(t *testing.T) {
 		t.Errorf("Expected result to be origin, is not")
 	}
 }
+
+func TestInverse(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Rotation(30 * Deg2Rad).Combine(Translation(P(3, -2)))
+	inv, err := m.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := P(1.5, 4)
+	if q := inv.Transform(m.Transform(p)); !q.Equal(p) {
+		t.Errorf("Expected inverse to map %v back onto itself, is %v", p, q)
+	}
+	if _, err := (AT{1, 2, 0, 2, 4, 0, 0, 0, 1}).Inverse(); err != ErrSingular {
+		t.Errorf("Expected singular transform to be reported, is %v", err)
+	}
+}
+
+func TestScaling(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if p := Scaling(2, -3).Transform(P(1, 1)); !p.Equal(P(2, -3)) {
+		t.Errorf("Expected (1,1) scaled by (2,-3) to be (2,-3), is %v", p)
+	}
+	if p := UniformScaling(0.5).Transform(P(4, 2)); !p.Equal(P(2, 1)) {
+		t.Errorf("Expected (4,2) scaled by 0.5 to be (2,1), is %v", p)
+	}
+}
+
+func TestShear(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if p := Slanted(0.25).Transform(P(1, 4)); !p.Equal(P(2, 4)) {
+		t.Errorf("Expected (1,4) slanted by 1/4 to be (2,4), is %v", p)
+	}
+	if p := Shear(1, 2).Transform(P(1, 1)); !p.Equal(P(2, 3)) {
+		t.Errorf("Expected (1,1) sheared by (1,2) to be (2,3), is %v", p)
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Scaling(2, -0.5).Combine(Shear(0.3, 0)).Combine(Rotation(40 * Deg2Rad)).Combine(Translation(P(1, 2)))
+	dec := m.Decompose()
+	if !Is0(dec.Rotation-40*Deg2Rad) || !Is0(dec.Shear-0.3) || !dec.Scale.Equal(P(2, -0.5)) ||
+		!dec.Translation.Equal(P(1, 2)) || !dec.Reflected {
+		t.Errorf("Unexpected decomposition %+v", dec)
+	}
+	n := Scaling(dec.Scale.X(), dec.Scale.Y()).Combine(Shear(dec.Shear, 0)).
+		Combine(Rotation(dec.Rotation)).Combine(Translation(dec.Translation))
+	p := P(3, -1)
+	if !m.Transform(p).Equal(n.Transform(p)) {
+		t.Errorf("Expected components to re-compose to the transform")
+	}
+	if dec := Rotation(90 * Deg2Rad).Decompose(); dec.Reflected || !dec.Scale.Equal(P(1, 1)) {
+		t.Errorf("Expected pure rotation, have %+v", dec)
+	}
+}
+
+func TestATEqual(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Rotation(90 * Deg2Rad)
+	if !m.Equal(AT{0, -1, 0, 1, 0, 0, 0, 0, 1}, 1e-9) {
+		t.Errorf("Expected rotation by 90° to equal exact matrix, is %v", m)
+	}
+	if m.Equal(Identity(), 1e-9) {
+		t.Errorf("Expected rotation to differ from identity")
+	}
+	if !Identity().Equal(Identity(), 0) {
+		t.Errorf("Expected identity to equal itself")
+	}
+}
+
+func TestDotCross(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p, q := P(2, 1), P(-1, 3)
+	if d := p.Dot(q); d != 1 {
+		t.Errorf("Expected dot product to be 1, is %g", d)
+	}
+	if c := p.Cross(q); c != 7 {
+		t.Errorf("Expected cross product to be 7, is %g", c)
+	}
+	if c := q.Cross(p); c != -7 {
+		t.Errorf("Expected cross product to be anti-commutative, is %g", c)
+	}
+}
+
+func TestLength(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p := P(3, -4)
+	if p.Length() != 5 || p.LengthSquared() != 25 {
+		t.Errorf("Expected length 5 and squared length 25, are %g and %g", p.Length(), p.LengthSquared())
+	}
+	if u := p.Unit(); !u.Equal(P(0.6, -0.8)) {
+		t.Errorf("Expected unit vector (0.6,-0.8), is %v", u)
+	}
+	if u := Origin.Unit(); !u.IsOrigin() {
+		t.Errorf("Expected unit vector of origin to be origin, is %v", u)
+	}
+}
+
+func TestAngle(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if a := P(1, 1).AngleDeg(); !Is0(a - 45) {
+		t.Errorf("Expected angle of (1,1) to be 45°, is %g", a)
+	}
+	if a := P(-1, math.Copysign(0, -1)).AngleDeg(); a != 180 {
+		t.Errorf("Expected angle of (-1,-0) to be normalized to 180°, is %g", a)
+	}
+	if a := Origin.Angle(); a != 0 {
+		t.Errorf("Expected angle of origin to be 0, is %g", a)
+	}
+	if a := AngleBetween(P(0, 1), P(1, 0)); !Is0(a + math.Pi/2) {
+		t.Errorf("Expected clockwise turn of 90°, is %g", a)
+	}
+}
+
+func TestMediation(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if m := Mediate(0.25, 2, 6); m != 3 {
+		t.Errorf("Expected 1/4[2,6] to be 3, is %g", m)
+	}
+	if p := Lerp(0.5, P(0, 0), P(4, 2)); !p.Equal(P(2, 1)) {
+		t.Errorf("Expected 1/2[(0,0),(4,2)] to be (2,1), is %v", p)
+	}
+	if p := Lerp(-1, P(1, 1), P(2, 3)); !p.Equal(P(0, -1)) {
+		t.Errorf("Expected extrapolation -1[(1,1),(2,3)] to be (0,-1), is %v", p)
+	}
+}
+
+func TestRotated90(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p := P(3, 1e-9)
+	if q := p.Rotated90(); q != P(-1e-9, 3) {
+		t.Errorf("Expected exact rotation by 90°, is %v", q)
+	}
+	if q := p.Rotated180(); q != P(-3, -1e-9) {
+		t.Errorf("Expected exact rotation by 180°, is %v", q)
+	}
+	if q := p.Rotated270(); q != P(1e-9, -3) {
+		t.Errorf("Expected exact rotation by 270°, is %v", q)
+	}
+	if !p.Rotated90().Equal(p.Rotated(90 * Deg2Rad)) {
+		t.Errorf("Expected exact rotation to agree with Rotated")
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	tol := Tolerance(0.01)
+	if !tol.Is0(0.005) || Is0(0.005) {
+		t.Errorf("Expected 0.005 to be 0 for tolerance 0.01 only")
+	}
+	if !tol.Is1(1.009) || tol.Zap(-0.002) != 0 || tol.Zap(0.5) != 0.5 {
+		t.Errorf("Unexpected comparisons for tolerance 0.01")
+	}
+	if r := tol.Round(1.23456); math.Abs(r-1.23) > 1e-12 {
+		t.Errorf("Expected 1.23456 rounded to 0.01 to be 1.23, is %g", r)
+	}
+	if Tolerance(Epsilon).Is0(Epsilon*2) != Is0(Epsilon*2) {
+		t.Errorf("Expected package-level functions to use Epsilon")
+	}
+}
+
+func TestCompose(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Compose(UniformScaling(2), Rotation(math.Pi/2), Translation(P(1, 0)))
+	if p := m.Transform(P(1, 0)); !p.Equal(P(1, 2)) {
+		t.Errorf("Expected (1,0) to transform to (1,2), is %v", p)
+	}
+	n := Identity().Scale(2).Rotate(math.Pi / 2).Translate(P(1, 0))
+	if !m.Equal(n, 1e-12) {
+		t.Errorf("Expected builder to agree with Compose, is %v", n)
+	}
+	if !Compose().Equal(Identity(), 0) {
+		t.Errorf("Expected empty composition to be the identity")
+	}
+}
+
+func TestMatrixForms(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Compose(Shear(0.5, 0), Rotation(0.3), Translation(P(7, -2)))
+	r, c := m.RowMajor(), m.ColumnMajor()
+	if p := m.Transform(P(2, 3)); !p.Equal(P(r[0]*2+r[1]*3+r[2], r[3]*2+r[4]*3+r[5])) {
+		t.Errorf("Expected row-major elements to transform like m, m=%v, r=%v", m, r)
+	}
+	if p := m.Transform(P(2, 3)); !p.Equal(P(c[0]*2+c[2]*3+c[4], c[1]*2+c[3]*3+c[5])) {
+		t.Errorf("Expected column-major elements to transform like m, m=%v, c=%v", m, c)
+	}
+	if !FromRowMajor(r).Equal(m, 0) || !FromColumnMajor(c).Equal(m, 0) {
+		t.Errorf("Expected round trip of 2x3 forms to reproduce m")
+	}
+}
+
+func TestRotationAround(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	c := P(2, 1)
+	m := RotationAround(c, math.Pi/2)
+	if p := m.Transform(P(3, 1)); !p.Equal(P(2, 2)) {
+		t.Errorf("Expected (3,1) to rotate to (2,2), is %v", p)
+	}
+	if p := m.Transform(c); !p.Equal(c) {
+		t.Errorf("Expected center to be fixed, is %v", p)
+	}
+	if p, q := m.Transform(P(-1, 4)), P(-1, 4).Rotatedaround(c, math.Pi/2); !p.Equal(q) {
+		t.Errorf("Expected transform to agree with Pair.Rotatedaround, %v ≠ %v", p, q)
+	}
+}
+
+func TestScalingAround(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	c := P(2, 1)
+	m := ScalingAround(c, 3, -1)
+	if p := m.Transform(P(3, 2)); !p.Equal(P(5, 0)) {
+		t.Errorf("Expected (3,2) to scale to (5,0), is %v", p)
+	}
+	if p := m.Transform(c); !p.Equal(c) {
+		t.Errorf("Expected center to be fixed, is %v", p)
+	}
+}
+
+func TestQuantized(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if q := P(1.26, -0.74).Quantized(0.5); q != P(1.5, -0.5) {
+		t.Errorf("Expected (1.5,-0.5), is %v", q)
+	}
+	if q := P(1.26, -0.74).Quantized(0); q != P(1.26, -0.74) {
+		t.Errorf("Expected step 0 to leave pair unchanged, is %v", q)
+	}
+	seen := make(map[GridKey]bool)
+	for _, p := range []Pair{P(0.1+0.2, 1), P(0.3, 1), P(0.3, 1.0000001), P(0.31, 1)} {
+		seen[p.Key(1e-3)] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected 2 distinct keys, have %d", len(seen))
+	}
+	x := 0.1
+	if P(0.3, 1).Key(0) == P(x+0.2, 1).Key(0) || P(0.3, 0).Key(0) != P(0.3, math.Copysign(0, -1)).Key(-1) {
+		t.Errorf("Expected step 0 to key identical pairs only")
+	}
+}
+
+func TestTransformParts(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := FromParts(1, 2, 3, 4, 5, 6)
+	if p := m.Transform(P(1, 1)); p != P(8, 13) {
+		t.Errorf("Expected (1,1) to transform to (8,13), is %v", p)
+	}
+	parts := []float64{m.XPart(), m.YPart(), m.XXPart(), m.XYPart(), m.YXPart(), m.YYPart()}
+	for i, x := range parts {
+		if x != float64(i+1) {
+			t.Errorf("Expected part #%d to be %d, is %g", i, i+1, x)
+		}
+	}
+}
+
+func TestTransformPredicates(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	for _, x := range []struct {
+		name                           string
+		m                              AT
+		identity, shift, rigid, orient bool
+	}{
+		{"identity", Identity(), true, true, true, true},
+		{"translation", Translation(P(1, 2)), false, true, true, true},
+		{"rotation", RotationAround(P(1, 2), 1), false, false, true, true},
+		{"reflection", Scaling(1, -1), false, false, true, false},
+		{"scaling", UniformScaling(2), false, false, false, true},
+		{"singular", Scaling(1, 0), false, false, false, false},
+	} {
+		if x.m.IsIdentity() != x.identity || x.m.IsTranslation() != x.shift ||
+			x.m.IsRigid() != x.rigid || x.m.PreservesOrientation() != x.orient {
+			t.Errorf("Unexpected classification of %s transform %v", x.name, x.m)
+		}
+	}
+}
+
+func TestC2PPolicy(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	nan := cmplx.NaN()
+	if p, err := C2PE(complex(1, 2)); err != nil || p != P(1, 2) {
+		t.Errorf("Expected valid pair (1,2), is %v (%v)", p, err)
+	}
+	if p, err := C2PE(nan); err != ErrInvalidPair || p != Origin {
+		t.Errorf("Expected NaN to be converted to origin with error, is %v (%v)", p, err)
+	}
+	if p := C2P(nan); p != Origin {
+		t.Errorf("Expected NaN to be converted to origin by default, is %v", p)
+	}
+	if p, err := NaNError.C2PE(nan); err != ErrInvalidPair || !cmplx.IsNaN(p.C()) {
+		t.Errorf("Expected NaN to be passed through with error, is %v (%v)", p, err)
+	}
+	if p := NaNError.C2P(nan); !cmplx.IsNaN(p.C()) {
+		t.Errorf("Expected NaN to be passed through, is %v", p)
+	}
+	defer func() {
+		if r := recover(); r != ErrInvalidPair {
+			t.Errorf("Expected panic with ErrInvalidPair, have %v", r)
+		}
+	}()
+	NaNPanic.C2P(cmplx.Inf())
+}
+
+func TestPairArithmetic(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p, q := P(3, 1), P(1, 2)
+	if r := p.Add(q); r != P(4, 3) {
+		t.Errorf("Expected (4,3), is %v", r)
+	}
+	if r := p.Sub(q); r != P(2, -1) {
+		t.Errorf("Expected (2,-1), is %v", r)
+	}
+	if r := p.Mul(2); r != P(6, 2) {
+		t.Errorf("Expected (6,2), is %v", r)
+	}
+	if r := p.Div(2); r != P(1.5, 0.5) {
+		t.Errorf("Expected (1.5,0.5), is %v", r)
+	}
+	if r := P(1e-9, 1).Mul(1); r.X() == 0 {
+		t.Errorf("Expected Mul not to zap coordinates")
+	}
+}
+
+func TestTransformInto(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m := Translation(P(1, 2))
+	src := []Pair{P(0, 0), P(1, 1)}
+	buf := make([]Pair, 0, 4)
+	dst := m.TransformInto(buf, src)
+	if len(dst) != 2 || &dst[0] != &buf[:1][0] || dst[1] != P(2, 3) {
+		t.Errorf("Expected transformed points in re-used buffer, is %v", dst)
+	}
+	if m.TransformInto(src, src); src[0] != P(1, 2) {
+		t.Errorf("Expected points to be transformed in place, is %v", src)
+	}
+	if n := testing.AllocsPerRun(10, func() { m.TransformInto(buf, src) }); n != 0 {
+		t.Errorf("Expected no allocations, have %g", n)
+	}
+}
@@ -0,0 +1,232 @@
+package arithm
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// === Arbitrary Precision Numbers ===========================================
+
+// Number is the common interface of numeric types with different precision.
+// Float wraps a float64, BigNumber wraps a big.Float of arbitrary precision.
+// Calculations which are written in terms of Number may be run at high
+// precision, e.g. to produce reference values for checking the rounding
+// behaviour of float64 calculations.
+//
+// Operations combining a Float with a BigNumber yield a BigNumber, i.e. the
+// result always has the higher precision of the operands. Other
+// implementations of Number are converted to float64 by Float operations.
+type Number interface {
+	Add(Number) Number
+	Sub(Number) Number
+	Mul(Number) Number
+	Quo(Number) Number
+	Neg() Number
+	Sign() int
+	Float64() float64
+	String() string
+}
+
+// Float is a Number of float64 precision.
+type Float float64
+
+var _ Number = Float(0)
+
+// Add returns x+y.
+func (x Float) Add(y Number) Number {
+	if b, ok := y.(BigNumber); ok {
+		return x.big(b.Prec()).Add(b)
+	}
+	return x + Float(y.Float64())
+}
+
+// Sub returns x-y.
+func (x Float) Sub(y Number) Number {
+	if b, ok := y.(BigNumber); ok {
+		return x.big(b.Prec()).Sub(b)
+	}
+	return x - Float(y.Float64())
+}
+
+// Mul returns x⋅y.
+func (x Float) Mul(y Number) Number {
+	if b, ok := y.(BigNumber); ok {
+		return x.big(b.Prec()).Mul(b)
+	}
+	return x * Float(y.Float64())
+}
+
+// Quo returns x/y.
+func (x Float) Quo(y Number) Number {
+	if b, ok := y.(BigNumber); ok {
+		return x.big(b.Prec()).Quo(b)
+	}
+	return x / Float(y.Float64())
+}
+
+// Neg returns -x.
+func (x Float) Neg() Number {
+	return -x
+}
+
+// Sign returns -1, 0 or 1, depending on the sign of x.
+func (x Float) Sign() int {
+	if x < 0 {
+		return -1
+	} else if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns x as a float64.
+func (x Float) Float64() float64 {
+	return float64(x)
+}
+
+func (x Float) String() string {
+	return fmt.Sprintf("%g", float64(x))
+}
+
+func (x Float) big(prec uint) BigNumber {
+	return NewBigNumber(float64(x), prec)
+}
+
+// BigNumber is a Number of arbitrary precision, given in bits of mantissa.
+// BigNumbers are immutable; every operation allocates a new big.Float.
+// The zero value is a 0 of precision 0, which adopts the precision of the
+// other operand in operations (or 53 bits, if both have precision 0).
+type BigNumber struct {
+	f *big.Float
+}
+
+var _ Number = BigNumber{}
+
+// NewBigNumber creates a BigNumber of precision prec (bits of mantissa) from
+// a float64. The conversion is exact for prec ≥ 53.
+func NewBigNumber(x float64, prec uint) BigNumber {
+	return BigNumber{new(big.Float).SetPrec(prec).SetFloat64(x)}
+}
+
+// Prec returns the precision of x in bits of mantissa.
+func (x BigNumber) Prec() uint {
+	return x.Big().Prec()
+}
+
+// Big returns the underlying big.Float of x. It must not be modified.
+func (x BigNumber) Big() *big.Float {
+	if x.f == nil { // zero value
+		return new(big.Float)
+	}
+	return x.f
+}
+
+// operand converts y to a big.Float with the precision of x.
+func (x BigNumber) operand(y Number) (*big.Float, *big.Float) {
+	prec := x.Prec()
+	b, ok := y.(BigNumber)
+	if !ok {
+		b = NewBigNumber(y.Float64(), prec)
+	} else if b.Prec() > prec {
+		prec = b.Prec()
+	}
+	if prec == 0 {
+		prec = 53
+	}
+	return new(big.Float).SetPrec(prec), b.Big()
+}
+
+// Add returns x+y.
+func (x BigNumber) Add(y Number) Number {
+	z, b := x.operand(y)
+	return BigNumber{z.Add(x.Big(), b)}
+}
+
+// Sub returns x-y.
+func (x BigNumber) Sub(y Number) Number {
+	z, b := x.operand(y)
+	return BigNumber{z.Sub(x.Big(), b)}
+}
+
+// Mul returns x⋅y.
+func (x BigNumber) Mul(y Number) Number {
+	z, b := x.operand(y)
+	return BigNumber{z.Mul(x.Big(), b)}
+}
+
+// Quo returns x/y. Division by zero panics, as for big.Float.
+func (x BigNumber) Quo(y Number) Number {
+	z, b := x.operand(y)
+	return BigNumber{z.Quo(x.Big(), b)}
+}
+
+// Neg returns -x.
+func (x BigNumber) Neg() Number {
+	return BigNumber{new(big.Float).SetPrec(x.Prec()).Neg(x.Big())}
+}
+
+// Sign returns -1, 0 or 1, depending on the sign of x.
+func (x BigNumber) Sign() int {
+	return x.Big().Sign()
+}
+
+// Float64 returns the float64 nearest to x.
+func (x BigNumber) Float64() float64 {
+	f, _ := x.Big().Float64()
+	return f
+}
+
+func (x BigNumber) String() string {
+	return x.Big().Text('g', int(float64(x.Prec())*0.30103))
+}
+
+// --- Pairs of Numbers ------------------------------------------------------
+
+// NPair is a pair of Numbers, the counterpart of Pair for calculations of
+// arbitrary precision.
+type NPair struct {
+	X, Y Number
+}
+
+// NP converts a Pair to an NPair. For prec > 0, the coordinates will be
+// BigNumbers of precision prec, otherwise they will be Floats.
+func NP(p Pair, prec uint) NPair {
+	if prec == 0 {
+		return NPair{Float(p.X()), Float(p.Y())}
+	}
+	return NPair{NewBigNumber(p.X(), prec), NewBigNumber(p.Y(), prec)}
+}
+
+// Pair rounds an NPair to a Pair.
+func (p NPair) Pair() Pair {
+	return P(p.X.Float64(), p.Y.Float64())
+}
+
+func (p NPair) String() string {
+	return fmt.Sprintf("(%s,%s)", p.X, p.Y)
+}
+
+// Add returns p+q.
+func (p NPair) Add(q NPair) NPair {
+	return NPair{p.X.Add(q.X), p.Y.Add(q.Y)}
+}
+
+// Sub returns p-q.
+func (p NPair) Sub(q NPair) NPair {
+	return NPair{p.X.Sub(q.X), p.Y.Sub(q.Y)}
+}
+
+// Scaled returns p scaled by factor a.
+func (p NPair) Scaled(a Number) NPair {
+	return NPair{p.X.Mul(a), p.Y.Mul(a)}
+}
+
+// Dot returns the dot product of p and q.
+func (p NPair) Dot(q NPair) Number {
+	return p.X.Mul(q.X).Add(p.Y.Mul(q.Y))
+}
+
+// Cross returns the 2D cross product of p and q.
+func (p NPair) Cross(q NPair) Number {
+	return p.X.Mul(q.Y).Sub(p.Y.Mul(q.X))
+}
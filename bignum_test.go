@@ -0,0 +1,82 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestBigNumber(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	// 1 + 1e-20 - 1 vanishes at float64 precision, but not at 128 bits
+	one, tiny := Float(1), Float(1e-20)
+	if r := one.Add(tiny).Sub(one); r.Sign() != 0 {
+		t.Errorf("Expected float64 calculation to lose 1e-20, is %v", r)
+	}
+	big1 := NewBigNumber(1, 128)
+	r := big1.Add(tiny).Sub(one)
+	if _, ok := r.(BigNumber); !ok || r.Float64() != 1e-20 {
+		t.Errorf("Expected big calculation to keep 1e-20, is %v", r)
+	}
+	if r := tiny.Mul(big1); r.(BigNumber).Prec() != 128 {
+		t.Errorf("Expected mixed operation to have higher precision")
+	}
+	if q := NewBigNumber(1, 200).Quo(Float(3)).Mul(Float(3)).Sub(Float(1)); q.Float64() > 1e-59 {
+		t.Errorf("Expected 1/3⋅3 = 1 at 200 bits, have difference %v", q)
+	}
+}
+
+func TestNPair(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p, q := NP(P(2, 1), 100), NP(P(-1, 3), 0)
+	if d := p.Dot(q).Float64(); d != 1 {
+		t.Errorf("Expected dot product 1, is %g", d)
+	}
+	if c := p.Cross(q).Float64(); c != 7 {
+		t.Errorf("Expected cross product 7, is %g", c)
+	}
+	if s := p.Add(q).Scaled(Float(2)).Pair(); s != P(2, 8) {
+		t.Errorf("Expected (2,8), is %v", s)
+	}
+	if s := p.Sub(q).Pair(); s != P(3, -2) {
+		t.Errorf("Expected (3,-2), is %v", s)
+	}
+}
+
+// fixed is a Number of a foreign type, to check mixed operations.
+type fixed struct{ Float }
+
+func TestFloatMixed(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	x, y := Float(6), fixed{Float(2)}
+	for _, r := range []Number{x.Add(y), x.Sub(y), x.Mul(y), x.Quo(y)} {
+		if _, ok := r.(Float); !ok {
+			t.Errorf("Expected operation with foreign Number to yield a Float, is %T", r)
+		}
+	}
+	if r := x.Add(y).Mul(y).Sub(y).Quo(y).Float64(); r != 7 {
+		t.Errorf("Expected ((6+2)⋅2-2)/2 = 7, is %g", r)
+	}
+}
+
+func TestBigNumberZeroValue(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	var zero BigNumber
+	if zero.Sign() != 0 || zero.Float64() != 0 || zero.Prec() != 0 {
+		t.Errorf("Expected zero value to be 0 of precision 0, is %v", zero)
+	}
+	r := zero.Add(NewBigNumber(1, 100)).Sub(Float(1e-20))
+	if b, ok := r.(BigNumber); !ok || b.Prec() != 100 || r.Float64() != 1-1e-20 {
+		t.Errorf("Expected zero value to adopt precision of operand, is %v", r)
+	}
+	if r := Float(2).Mul(zero).Add(zero.Neg()); r.Sign() != 0 {
+		t.Errorf("Expected 2⋅0 - 0 = 0, is %v", r)
+	}
+	if zero.String() != "0" {
+		t.Errorf("Expected zero value to print as 0, is %q", zero.String())
+	}
+}
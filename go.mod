@@ -7,4 +7,5 @@ require (
 	github.com/emirpasic/gods v1.12.0
 	github.com/npillmayer/schuko v0.1.0
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/image v0.18.0
 )
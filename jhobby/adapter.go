@@ -0,0 +1,135 @@
+package jhobby
+
+import (
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Adapting Client Path Types --------------------------------------------
+
+// Knots is a minimal interface for clients' own path data structures, e.g.
+// the internal path type of a DSL interpreter. It is a subset of interface
+// HobbyPath. Contrary to HobbyPath, Z(i) is called for 0 ≤ i < N only.
+//
+// See AdaptKnots.
+type Knots interface {
+	IsCycle() bool     // is this a cyclic path?
+	N() int            // number of knots in the path
+	Z(int) arithm.Pair // knot #i, 0 ≤ i < N
+}
+
+// AdaptKnots puts interface HobbyPath over a client's path data structure,
+// without copying it into a Path. The adapter takes care of
+// modulo-subscripting of knots for cyclic paths.
+//
+// If the client type implements any of the parameter methods of HobbyPath
+// (PreDir, PostDir, PreCurl, PostCurl, PreTension, PostTension), the adapter
+// will delegate to them. Otherwise default values are used, i.e. no explicit
+// directions, and curls and tensions of 1. The same holds for interface
+// ExplicitControls, RoughKnots and DataKnots.
+//
+// Example:
+//
+//	controls := FindHobbyControls(AdaptKnots(myPath), nil)
+func AdaptKnots(knots Knots) HobbyPath {
+	return knotsAdapter{knots}
+}
+
+type knotsAdapter struct {
+	knots Knots
+}
+
+var _ HobbyPath = knotsAdapter{}
+var _ ExplicitControls = knotsAdapter{}
+var _ RoughKnots = knotsAdapter{}
+var _ DataKnots = knotsAdapter{}
+
+func (ka knotsAdapter) IsCycle() bool {
+	return ka.knots.IsCycle()
+}
+
+func (ka knotsAdapter) N() int {
+	return ka.knots.N()
+}
+
+func (ka knotsAdapter) Z(i int) arithm.Pair {
+	return ka.knots.Z(ka.mod(i))
+}
+
+func (ka knotsAdapter) mod(i int) int {
+	if n := ka.knots.N(); n > 0 && (i < 0 || i >= n) {
+		i = (i%n + n) % n
+	}
+	return i
+}
+
+func (ka knotsAdapter) PreDir(i int) arithm.Pair {
+	if p, ok := ka.knots.(interface{ PreDir(int) arithm.Pair }); ok {
+		return p.PreDir(ka.mod(i))
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (ka knotsAdapter) PostDir(i int) arithm.Pair {
+	if p, ok := ka.knots.(interface{ PostDir(int) arithm.Pair }); ok {
+		return p.PostDir(ka.mod(i))
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (ka knotsAdapter) PreCurl(i int) float64 {
+	if p, ok := ka.knots.(interface{ PreCurl(int) float64 }); ok {
+		return p.PreCurl(ka.mod(i))
+	}
+	return 1.0
+}
+
+func (ka knotsAdapter) PostCurl(i int) float64 {
+	if p, ok := ka.knots.(interface{ PostCurl(int) float64 }); ok {
+		return p.PostCurl(ka.mod(i))
+	}
+	return 1.0
+}
+
+func (ka knotsAdapter) PreTension(i int) float64 {
+	if p, ok := ka.knots.(interface{ PreTension(int) float64 }); ok {
+		return p.PreTension(ka.mod(i))
+	}
+	return 1.0
+}
+
+func (ka knotsAdapter) PostTension(i int) float64 {
+	if p, ok := ka.knots.(interface{ PostTension(int) float64 }); ok {
+		return p.PostTension(ka.mod(i))
+	}
+	return 1.0
+}
+
+func (ka knotsAdapter) ExplicitPreControl(i int) arithm.Pair {
+	if ex, ok := ka.knots.(interface{ ExplicitPreControl(int) arithm.Pair }); ok {
+		return ex.ExplicitPreControl(ka.mod(i))
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (ka knotsAdapter) ExplicitPostControl(i int) arithm.Pair {
+	if ex, ok := ka.knots.(interface{ ExplicitPostControl(int) arithm.Pair }); ok {
+		return ex.ExplicitPostControl(ka.mod(i))
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (ka knotsAdapter) IsRough(i int) bool {
+	if r, ok := ka.knots.(RoughKnots); ok {
+		return r.IsRough(ka.mod(i))
+	}
+	return false
+}
+
+func (ka knotsAdapter) KnotData(i int) interface{} {
+	if d, ok := ka.knots.(DataKnots); ok {
+		return d.KnotData(ka.mod(i))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Elliptical Arcs -------------------------------------------------------
+
+// svgArc holds the parameters of an elliptical arc join, as in SVG path data.
+type svgArc struct {
+	rx, ry   float64 // radii
+	rotation float64 // rotation of the x-axis of the ellipse, in radians
+	largeArc bool    // select the arc spanning more than 180°
+	sweep    bool    // arc proceeds in the direction of increasing angles
+}
+
+// ArcJoin connects two knots with an elliptical arc, as does the "A" command
+// of SVG path data. rx and ry are the radii of the ellipse, which is rotated
+// by rotation (in radians, contrary to SVG). Of the four possible arcs
+// between the knots, largeArc selects one spanning more than 180°, and sweep
+// selects one proceeding in the direction of increasing angles, i.e.
+// counter-clockwise in a coordinate system with the y-axis pointing upwards
+// (or clockwise, as displayed by SVG). As with SVG, radii too small to
+// connect the knots are scaled up, and an arc with a zero radius is a
+// straight line.
+//
+// The arc is converted to cubic Bézier segments with explicit control points
+// as soon as the knot ending it is added. Arcs spanning more than 90° are
+// split, inserting knots on the arc.
+// Part of builder functionality.
+func (path *Path) ArcJoin(rx, ry, rotation float64, largeArc, sweep bool) KnotAdder {
+	if path.N() == 0 {
+		path.fail("cannot add arc to empty path")
+		return path
+	}
+	if !path.checkNumber(rx, "radius") || !path.checkNumber(ry, "radius") ||
+		!path.checkNumber(rotation, "rotation") {
+		return path
+	}
+	path.arc = &svgArc{math.Abs(rx), math.Abs(ry), rotation, largeArc, sweep}
+	path.noteJoin()
+	return path
+}
+
+// appendKnot appends a knot to a path, resolving a pending arc join.
+func (path *Path) appendKnot(p arithm.Pair) {
+	path.resolveArc(p)
+	path.noteKnot()
+	path.points = append(path.points, p)
+}
+
+// resolveArc converts a pending arc join ending at knot end into explicit
+// curves. Knots between the pieces of the arc are appended to the path.
+func (path *Path) resolveArc(end arithm.Pair) {
+	if path.arc == nil {
+		return
+	}
+	arc := *path.arc
+	path.arc = nil
+	segs := arc.segments(path.Z(path.N()-1), end, path.epsilon())
+	for i, seg := range segs {
+		if i > 0 {
+			path.points = append(path.points, seg.P0)
+		}
+		path.SetExplicitControls(path.N()-1, seg.C1, seg.C2)
+	}
+}
+
+// segments converts an arc from p1 to p2 into cubic Bézier segments, each
+// spanning at most 90°. The implementation follows the SVG specification,
+// appendix F.6 (conversion from endpoint to center parameterization).
+// Distances and radii below eps are considered to be zero.
+func (arc svgArc) segments(p1, p2 arithm.Pair, eps float64) []CubicSegment {
+	if cmplx.Abs((p2 - p1).C()) < eps {
+		return []CubicSegment{{p1, p1, p2, p2}}
+	}
+	if arc.rx < eps || arc.ry < eps {
+		return []CubicSegment{straightSegment(p1, p2)}
+	}
+	rx, ry := arc.rx, arc.ry
+	cosr, sinr := math.Cos(arc.rotation), math.Sin(arc.rotation)
+	dx, dy := (p1.X()-p2.X())/2, (p1.Y()-p2.Y())/2
+	x1, y1 := cosr*dx+sinr*dy, -sinr*dx+cosr*dy
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 { // radii too small
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if arc.largeArc == arc.sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	center := arithm.P(cosr*cx1-sinr*cy1+(p1.X()+p2.X())/2, sinr*cx1+cosr*cy1+(p1.Y()+p2.Y())/2)
+	eta1 := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	eta2 := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx)
+	delta := eta2 - eta1
+	if arc.sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !arc.sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	point := func(eta float64) arithm.Pair {
+		x, y := rx*math.Cos(eta), ry*math.Sin(eta)
+		return center + arithm.P(cosr*x-sinr*y, sinr*x+cosr*y)
+	}
+	tangent := func(eta float64) arithm.Pair {
+		x, y := -rx*math.Sin(eta), ry*math.Cos(eta)
+		return arithm.P(cosr*x-sinr*y, sinr*x+cosr*y)
+	}
+	pieces := int(math.Ceil(math.Abs(delta)/(math.Pi/2) - 1e-9))
+	if pieces < 1 {
+		pieces = 1
+	}
+	step := delta / float64(pieces)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+	segs := make([]CubicSegment, pieces)
+	for i := range segs {
+		a, b := eta1+float64(i)*step, eta1+float64(i+1)*step
+		segs[i] = CubicSegment{point(a), point(a) + tangent(a).Scaled(k), point(b) - tangent(b).Scaled(k), point(b)}
+	}
+	segs[0].P0, segs[pieces-1].P3 = p1, p2
+	return segs
+}
@@ -0,0 +1,170 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Arc Length ------------------------------------------------------------
+
+// arcSubdivisions is the number of sub-intervals per segment for which
+// cumulative arc lengths are tabulated.
+const arcSubdivisions = 16
+
+// Abscissae and weights for 5-point Gauss-Legendre quadrature on [-1…1].
+var gaussX = [5]float64{0, -0.5384693101056831, 0.5384693101056831, -0.9061798459386640, 0.9061798459386640}
+var gaussW = [5]float64{0.5688888888888889, 0.4786286704993665, 0.4786286704993665, 0.2369268850561891, 0.2369268850561891}
+
+// ArcLengthTable supports fast conversions between arc length and time on a
+// solved path, e.g. for placing dashes or markers along a path. Time is
+// measured as in MetaPost: time t = i + f, 0 ≤ f ≤ 1, denotes time f on
+// segment #i, i.e. on the segment starting at knot #i.
+//
+// The table is built once from a path and its control points. Queries take
+// O(log n) for a path of n segments. If the control points are held in a
+// container of this package (e.g. Path.Controls or a container allocated by
+// FindHobbyControls), the table is rebuilt automatically when the control
+// points change. For other implementations of SplineControls, clients have
+// to call Invalidate after changing control points.
+type ArcLengthTable struct {
+	path     HobbyPath
+	controls SplineControls
+	version  uint64         // version of controls the table has been built for
+	segs     []CubicSegment // segments of the path
+	lengths  []float64      // cumulative arc length at the end of sub-interval #k
+	eps      float64        // geometric tolerance of the path
+	valid    bool
+}
+
+// NewArcLengthTable creates an arc length table for a path with calculated
+// control points.
+func NewArcLengthTable(path HobbyPath, controls SplineControls) *ArcLengthTable {
+	tab := &ArcLengthTable{path: path, controls: controls}
+	tab.build()
+	return tab
+}
+
+// Invalidate forces the table to be rebuilt with the next query.
+func (tab *ArcLengthTable) Invalidate() {
+	tab.valid = false
+}
+
+func (tab *ArcLengthTable) check() {
+	if c, ok := tab.controls.(*splcntrls); ok && c.version != tab.version {
+		tab.valid = false
+	}
+	if !tab.valid {
+		tab.build()
+	}
+}
+
+func (tab *ArcLengthTable) build() {
+	if c, ok := tab.controls.(*splcntrls); ok {
+		tab.version = c.version
+	}
+	tab.segs = Segments(tab.path, tab.controls)
+	tab.eps = epsilonOf(tab.path)
+	tab.lengths = make([]float64, len(tab.segs)*arcSubdivisions)
+	s := 0.0
+	for i, seg := range tab.segs {
+		for k := 0; k < arcSubdivisions; k++ {
+			t0 := float64(k) / arcSubdivisions
+			s += seg.arcLength(t0, t0+1.0/arcSubdivisions)
+			tab.lengths[i*arcSubdivisions+k] = s
+		}
+	}
+	tab.valid = true
+}
+
+// Length returns the total arc length of the path.
+func (tab *ArcLengthTable) Length() float64 {
+	tab.check()
+	if len(tab.lengths) == 0 {
+		return 0
+	}
+	return tab.lengths[len(tab.lengths)-1]
+}
+
+// LengthAtTime returns the arc length of the path from its start to time t.
+// t is clamped to the time range of the path.
+func (tab *ArcLengthTable) LengthAtTime(t float64) float64 {
+	tab.check()
+	if len(tab.segs) == 0 || t <= 0 {
+		return 0
+	}
+	if t >= float64(len(tab.segs)) {
+		return tab.Length()
+	}
+	k := int(t * arcSubdivisions) // sub-interval containing t
+	if k >= len(tab.lengths) {
+		k = len(tab.lengths) - 1
+	}
+	return tab.lengthBefore(k) + tab.partialLength(k, t)
+}
+
+// TimeAtLength returns the time at which the arc length of the path,
+// measured from its start, equals s. s is clamped to the length of the path.
+func (tab *ArcLengthTable) TimeAtLength(s float64) float64 {
+	tab.check()
+	if len(tab.segs) == 0 || s <= 0 {
+		return 0
+	}
+	if s >= tab.Length() {
+		return float64(len(tab.segs))
+	}
+	k := sort.SearchFloat64s(tab.lengths, s) // sub-interval containing s
+	t0 := float64(k) / arcSubdivisions
+	t1 := float64(k+1) / arcSubdivisions
+	s0, s1 := tab.lengthBefore(k), tab.lengths[k]
+	if s1-s0 <= 0 {
+		return t0
+	}
+	t := t0 + (s-s0)/(s1-s0)*(t1-t0)
+	for it := 0; it < 8; it++ { // Newton iteration, safeguarded to [t0…t1]
+		i := k / arcSubdivisions
+		speed := cmplx.Abs(tab.segs[i].derivative(t - float64(i)).C())
+		if speed < tab.eps {
+			break
+		}
+		dt := (s0 + tab.partialLength(k, t) - s) / speed
+		t = math.Max(t0, math.Min(t1, t-dt))
+		if math.Abs(dt) < 1e-12 {
+			break
+		}
+	}
+	return t
+}
+
+func (tab *ArcLengthTable) lengthBefore(k int) float64 {
+	if k == 0 {
+		return 0
+	}
+	return tab.lengths[k-1]
+}
+
+// partialLength returns the arc length from the start of sub-interval #k to
+// time t.
+func (tab *ArcLengthTable) partialLength(k int, t float64) float64 {
+	i := k / arcSubdivisions
+	f0 := float64(k%arcSubdivisions) / arcSubdivisions
+	return tab.segs[i].arcLength(f0, t-float64(i))
+}
+
+// derivative returns the first derivative of the segment at time t.
+func (seg CubicSegment) derivative(t float64) arithm.Pair {
+	s := 1 - t
+	return (seg.C1 - seg.P0).Scaled(3*s*s) + (seg.C2 - seg.C1).Scaled(6*s*t) + (seg.P3 - seg.C2).Scaled(3*t*t)
+}
+
+// arcLength integrates the speed of a segment from time t0 to t1.
+func (seg CubicSegment) arcLength(t0, t1 float64) float64 {
+	half, mid := (t1-t0)/2, (t0+t1)/2
+	l := 0.0
+	for i, x := range gaussX {
+		l += gaussW[i] * cmplx.Abs(seg.derivative(mid+half*x).C())
+	}
+	return l * half
+}
@@ -0,0 +1,92 @@
+package jhobby
+
+import (
+	"math"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Arrow Heads -----------------------------------------------------------
+
+// ArrowStyle is the shape of an arrow head.
+type ArrowStyle int
+
+// Arrow styles. The opening angle of arrow heads is 45° (MetaPost: ahangle).
+const (
+	CurvedArrow   ArrowStyle = iota // sides follow the curve of the path, as with MetaPost
+	TriangleArrow                   // sides are straight lines
+)
+
+// arrowAngle is the opening angle of arrow heads.
+const arrowAngle = math.Pi / 4
+
+// WithArrowHead creates an arrow head at the end of a solved path, similar
+// to MetaPost's drawarrow. Parameter size is the length of the sides of
+// the arrow head (MetaPost: ahlength). It returns the path cut at the base
+// of the arrow head (MetaPost: cutafter), so that the stroked path does not
+// poke through the tip, and the outline of the arrow head as a closed path,
+// to be filled.
+//
+// Both paths have explicit control points, which are calculated. If the
+// arrow head is longer than the path, the returned shaft is nil.
+func WithArrowHead(path HobbyPath, controls SplineControls, style ArrowStyle,
+	size float64) (*Path, *Path) {
+	//
+	segs := Segments(path, controls)
+	if len(segs) == 0 || size <= 0 {
+		return nil, nil
+	}
+	tab := NewArcLengthTable(path, controls)
+	end := float64(len(segs))
+	tip := PointAt(path, controls, end)
+	var head *Path
+	if style == TriangleArrow {
+		dir := DirectionAt(path, controls, end)
+		left := tip - dir.Rotated(-arrowAngle/2).Scaled(size)
+		right := tip - dir.Rotated(arrowAngle/2).Scaled(size)
+		head = pathFromSegments([]CubicSegment{
+			straightSegment(tip, left), straightSegment(left, right), straightSegment(right, tip),
+		}, true)
+	} else {
+		side := subpathSegments(segs, tab.TimeAtLength(tab.Length()-size), end)
+		var outline []CubicSegment
+		for _, seg := range side { // from base to tip, rotated clockwise
+			outline = append(outline, seg.rotatedAround(tip, -arrowAngle/2))
+		}
+		for i := len(side) - 1; i >= 0; i-- { // from tip to base, rotated counter-clockwise
+			outline = append(outline, side[i].Reversed().rotatedAround(tip, arrowAngle/2))
+		}
+		outline = append(outline, straightSegment(outline[len(outline)-1].P3, outline[0].P0))
+		head = pathFromSegments(outline, true)
+	}
+	base := size * math.Cos(arrowAngle/2)
+	if tab.Length() <= base {
+		return nil, head
+	}
+	shaft := pathFromSegments(subpathSegments(segs, 0, tab.TimeAtLength(tab.Length()-base)), false)
+	return shaft, head
+}
+
+// subpathSegments returns the segments of a path between times t0 and t1,
+// with t0 < t1.
+func subpathSegments(segs []CubicSegment, t0, t1 float64) []CubicSegment {
+	var sub []CubicSegment
+	for i, seg := range segs {
+		from, to := math.Max(t0-float64(i), 0), math.Min(t1-float64(i), 1)
+		if from < to {
+			sub = append(sub, seg.piece(from, to))
+		}
+	}
+	return sub
+}
+
+// straightSegment returns a segment representing a straight line from a to b.
+func straightSegment(a, b arithm.Pair) CubicSegment {
+	return CubicSegment{a, a + (b - a).Scaled(1.0/3), a + (b - a).Scaled(2.0/3), b}
+}
+
+// rotatedAround rotates a segment around a point by theta (radians).
+func (seg CubicSegment) rotatedAround(center arithm.Pair, theta float64) CubicSegment {
+	at := arithm.Translation(-center).Combine(arithm.Rotation(theta)).Combine(arithm.Translation(center))
+	return CubicSegment{at.Transform(seg.P0), at.Transform(seg.C1), at.Transform(seg.C2), at.Transform(seg.P3)}
+}
@@ -0,0 +1,75 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Bounding Boxes --------------------------------------------------------
+
+// ControlHullBox returns the lower left and upper right corner of the box
+// enclosing all knots and control points of a solved path. As a Bézier
+// segment lies within the convex hull of its control points, the box
+// encloses the curve, but it may be larger than necessary. It is cheap to
+// compute and intended for culling. Control points which have not been
+// calculated are ignored. Returns NaN pairs for an empty path.
+func ControlHullBox(path HobbyPath, controls SplineControls) (arithm.Pair, arithm.Pair) {
+	box := newBox()
+	for i := 0; i < path.N(); i++ {
+		box.extend(path.Z(i))
+		if controls != nil {
+			box.extend(controls.PreControl(i))
+			box.extend(controls.PostControl(i))
+		}
+	}
+	return box.corners()
+}
+
+// BoundingBox returns the lower left and upper right corner of the tight
+// bounding box of a solved path, i.e. of the curve itself. Clients will use
+// it for precise layout measurements. It is more expensive to compute than
+// ControlHullBox, as it finds the extrema of every segment. Control points
+// which have not been calculated are treated as in Segments. Returns NaN
+// pairs for an empty path.
+func BoundingBox(path HobbyPath, controls SplineControls) (arithm.Pair, arithm.Pair) {
+	box := newBox()
+	for i := 0; i < path.N(); i++ {
+		box.extend(path.Z(i))
+	}
+	for _, seg := range Segments(path, controls) {
+		for _, coord := range []func(arithm.Pair) float64{arithm.Pair.X, arithm.Pair.Y} {
+			a, b, c, _ := seg.coeffs(coord)
+			for _, t := range clampRoots(quadraticRoots(3*a, 2*b, c)) { // extrema
+				box.extend(seg.At(t))
+			}
+		}
+	}
+	return box.corners()
+}
+
+// box is an axis-aligned box, extended point by point.
+type box struct {
+	minx, miny, maxx, maxy float64
+}
+
+func newBox() *box {
+	return &box{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func (b *box) extend(p arithm.Pair) {
+	if cmplx.IsNaN(p.C()) {
+		return
+	}
+	b.minx, b.maxx = math.Min(b.minx, p.X()), math.Max(b.maxx, p.X())
+	b.miny, b.maxy = math.Min(b.miny, p.Y()), math.Max(b.maxy, p.Y())
+}
+
+func (b *box) corners() (arithm.Pair, arithm.Pair) {
+	if b.minx > b.maxx {
+		nan := arithm.Pair(cmplx.NaN())
+		return nan, nan
+	}
+	return arithm.P(b.minx, b.miny), arithm.P(b.maxx, b.maxy)
+}
@@ -0,0 +1,252 @@
+package jhobby
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Cubic Bézier Segments -------------------------------------------------
+
+// CubicSegment is a single cubic Bézier segment of a solved path, given by its
+// start knot, two control points and its end knot.
+type CubicSegment struct {
+	P0, C1, C2, P3 arithm.Pair
+}
+
+// Segments returns the cubic Bézier segments of a path with solved controls,
+// so clients may range over them without having to deal with the indexing
+// conventions of SplineControls. An open path of N knots has N-1 segments,
+// a cyclic path has N segments; segment #i starts at knot #i.
+//
+// Control points which are unknown (i.e., have not been calculated) are
+// replaced by the adjacent knots, resulting in a straight line.
+func Segments(path HobbyPath, controls SplineControls) []CubicSegment {
+	n := path.N()
+	if n < 2 {
+		return nil
+	}
+	cnt := n - 1
+	if path.IsCycle() {
+		cnt = n
+	}
+	segs := make([]CubicSegment, cnt)
+	for i := 0; i < cnt; i++ {
+		segs[i] = segmentAt(path, controls, i)
+	}
+	return segs
+}
+
+// segmentAt returns segment #i of a path, with unknown control points
+// replaced by the adjacent knots.
+func segmentAt(path HobbyPath, controls SplineControls, i int) CubicSegment {
+	n := path.N()
+	seg := CubicSegment{P0: path.Z(i), P3: path.Z(i + 1)}
+	seg.C1, seg.C2 = seg.P0, seg.P3
+	if controls != nil {
+		if c := controls.PostControl(i); !cmplx.IsNaN(c.C()) {
+			seg.C1 = c
+		}
+		if c := controls.PreControl((i + 1) % n); !cmplx.IsNaN(c.C()) {
+			seg.C2 = c
+		}
+	}
+	return seg
+}
+
+// pathFromSegments creates a path with explicit control points from a
+// sequence of connected segments. For cycles, the last segment has to end at
+// the start of the first one. The control points of the path are calculated.
+func pathFromSegments(segs []CubicSegment, cycle bool) *Path {
+	p := Nullpath()
+	for i, seg := range segs {
+		p.Knot(seg.P0).ExplicitCurve(seg.C1, seg.C2)
+		if !cycle && i == len(segs)-1 {
+			p.Knot(seg.P3).End()
+		}
+	}
+	if cycle {
+		p.Cycle()
+	}
+	FindHobbyControls(p, p.Controls)
+	return p
+}
+
+// BezierSegments is implemented by the control point containers of this
+// package, i.e., by Path.Controls and by containers allocated by
+// FindHobbyControls. Clients holding a SplineControls may use a type assertion
+// to access the solved segments of a path.
+type BezierSegments interface {
+	NumSegments() int
+	Segment(i int) (p0, c1, c2, p3 arithm.Pair)
+}
+
+var _ BezierSegments = &splcntrls{}
+
+// NumSegments returns the number of Bézier segments of the path the control
+// points have been calculated for: N-1 for an open path of N knots, N for a
+// cycle. Returns 0 if the controls have not been calculated for a path yet.
+func (ctrls *splcntrls) NumSegments() int {
+	if ctrls.path == nil || ctrls.path.N() < 2 {
+		return 0
+	}
+	if ctrls.path.IsCycle() {
+		return ctrls.path.N()
+	}
+	return ctrls.path.N() - 1
+}
+
+// Segment returns the Bézier points of segment #i, i.e. the segment starting
+// at knot #i, of the path the control points have been calculated for.
+// Renderers may use it without knowing the indexing conventions of
+// SplineControls. Unknown control points are replaced by the adjacent knots.
+// Segment panics if i is out of range.
+func (ctrls *splcntrls) Segment(i int) (p0, c1, c2, p3 arithm.Pair) {
+	if i < 0 || i >= ctrls.NumSegments() {
+		panic(fmt.Sprintf("segment index %d out of range [0,%d)", i, ctrls.NumSegments()))
+	}
+	seg := segmentAt(ctrls.path, ctrls, i)
+	return seg.P0, seg.C1, seg.C2, seg.P3
+}
+
+// At evaluates the segment at time 0 ≤ t ≤ 1.
+func (seg CubicSegment) At(t float64) arithm.Pair {
+	s := 1 - t
+	b0, b1, b2, b3 := s*s*s, 3*s*s*t, 3*s*t*t, t*t*t
+	x := b0*seg.P0.X() + b1*seg.C1.X() + b2*seg.C2.X() + b3*seg.P3.X()
+	y := b0*seg.P0.Y() + b1*seg.C1.Y() + b2*seg.C2.Y() + b3*seg.P3.Y()
+	return arithm.P(x, y)
+}
+
+// Split splits the segment at time 0 ≤ t ≤ 1 into two segments, using de
+// Casteljau's algorithm.
+func (seg CubicSegment) Split(t float64) (CubicSegment, CubicSegment) {
+	lerp := func(a, b arithm.Pair) arithm.Pair { return a + (b - a).Scaled(t) }
+	p01, p12, p23 := lerp(seg.P0, seg.C1), lerp(seg.C1, seg.C2), lerp(seg.C2, seg.P3)
+	p012, p123 := lerp(p01, p12), lerp(p12, p23)
+	mid := lerp(p012, p123)
+	return CubicSegment{seg.P0, p01, p012, mid}, CubicSegment{mid, p123, p23, seg.P3}
+}
+
+// Reversed returns the segment with its direction reversed.
+func (seg CubicSegment) Reversed() CubicSegment {
+	return CubicSegment{seg.P3, seg.C2, seg.C1, seg.P0}
+}
+
+// coeffs returns the coefficients (a,b,c,d) of the power basis form
+// a⋅t³ + b⋅t² + c⋅t + d of one coordinate of a segment. Parameter coord
+// selects the coordinate to use.
+func (seg CubicSegment) coeffs(coord func(arithm.Pair) float64) (float64, float64, float64, float64) {
+	p0, p1, p2, p3 := coord(seg.P0), coord(seg.C1), coord(seg.C2), coord(seg.P3)
+	a := -p0 + 3*p1 - 3*p2 + p3
+	b := 3*p0 - 6*p1 + 3*p2
+	c := -3*p0 + 3*p1
+	return a, b, c, p0
+}
+
+// --- Root Finding ----------------------------------------------------------
+
+// _rootEpsilon is the tolerance of root finding. Root finding is independent
+// of the units of a path, thus coefficients are compared relative to their
+// magnitude, and roots are times on a segment, i.e. within [0…1].
+const _rootEpsilon = 1e-7
+
+// cubicRoots finds the real roots of a⋅t³ + b⋅t² + c⋅t + d = 0 within
+// the interval [0…1]. Degenerate cases (quadratic, linear) are handled.
+// Roots are returned in ascending order.
+func cubicRoots(a, b, c, d float64) []float64 {
+	var roots []float64
+	scale := math.Max(math.Max(math.Abs(a), math.Abs(b)), math.Max(math.Abs(c), math.Abs(d)))
+	if scale == 0 {
+		return nil // 0 = 0 has no isolated roots
+	}
+	if math.Abs(a) <= _rootEpsilon*scale {
+		roots = quadraticRoots(b, c, d)
+	} else {
+		// normalize to t³ + A t² + B t + C and substitute t = x - A/3
+		A, B, C := b/a, c/a, d/a
+		p := B - A*A/3
+		q := 2*A*A*A/27 - A*B/3 + C
+		shift := -A / 3
+		disc := q*q/4 + p*p*p/27
+		discScale := q*q/4 + math.Abs(p*p*p)/27 // magnitude of the terms of disc
+		switch {
+		case math.Abs(disc) <= _rootEpsilon*discScale:
+			u := math.Cbrt(-q / 2)
+			roots = []float64{2*u + shift, -u + shift}
+		case disc > 0:
+			sq := math.Sqrt(disc)
+			roots = []float64{math.Cbrt(-q/2+sq) + math.Cbrt(-q/2-sq) + shift}
+		default:
+			r := math.Sqrt(-p / 3)
+			phi := math.Acos(math.Max(-1, math.Min(1, -q/(2*r*r*r))))
+			for k := 0.0; k < 3; k++ {
+				roots = append(roots, 2*r*math.Cos((phi-2*math.Pi*k)/3)+shift)
+			}
+		}
+		f := func(t float64) float64 { return ((a*t+b)*t+c)*t + d }
+		for i, t := range roots { // polish roots with a Newton step
+			df := (3*a*t+2*b)*t + c // vanishes at double roots
+			if df != 0 {
+				if polished := t - f(t)/df; math.Abs(f(polished)) < math.Abs(f(t)) {
+					roots[i] = polished
+				}
+			}
+		}
+	}
+	return clampRoots(roots)
+}
+
+// quadraticRoots finds the real roots of a⋅t² + b⋅t + c = 0.
+func quadraticRoots(a, b, c float64) []float64 {
+	scale := math.Max(math.Abs(a), math.Max(math.Abs(b), math.Abs(c)))
+	if math.Abs(a) <= _rootEpsilon*scale {
+		if math.Abs(b) <= _rootEpsilon*scale {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		if disc > -_rootEpsilon*scale*scale {
+			return []float64{-b / (2 * a)}
+		}
+		return nil
+	}
+	sq := math.Sqrt(disc)
+	return []float64{(-b + sq) / (2 * a), (-b - sq) / (2 * a)}
+}
+
+// clampRoots keeps roots within [0…1] (snapping values which are off by
+// less than ε) and returns them sorted and without duplicates.
+func clampRoots(roots []float64) []float64 {
+	var result []float64
+	for _, t := range roots {
+		if t < 0 && t > -_rootEpsilon {
+			t = 0
+		} else if t > 1 && t < 1+_rootEpsilon {
+			t = 1
+		}
+		if t < 0 || t > 1 || math.IsNaN(t) {
+			continue
+		}
+		dup := false
+		for _, r := range result {
+			if math.Abs(r-t) <= _rootEpsilon {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			result = append(result, t)
+		}
+	}
+	for i := 1; i < len(result); i++ { // insertion sort, there are at most 3 roots
+		for j := i; j > 0 && result[j] < result[j-1]; j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
+	return result
+}
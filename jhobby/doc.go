@@ -58,19 +58,20 @@ omitted for clarity and brevity):
    Nullpath().Knot(P(0,0)).Curve().Knot(P(2,3)).TensionCurve(N(1.4),N(1.4)).Knot(P(5,3))
       .Curve().DirKnot(P(3,-1),P(-1,0)).Curve().Cycle()
 
-Alternatively clients may put interface HobbyCurve over their own path
-data structure. Either way, a HobbyPath will then be subjected to a call to
-FindHobbyControls(...)
+Alternatively clients may put interface HobbyPath over their own path
+data structure, either by implementing it directly or by using AdaptKnots(...)
+for simple knot sequences. Either way, a HobbyPath will then be subjected to
+a call to FindHobbyControls(...)
 
    controls = FindHobbyControls(path, nil)
 
 which returns the necessary control point information to produce a smooth
 curve:
 
-  (0,0) .. controls (-0.5882,1.2616) and (0.4229,2.6442)
-   .. (2,3) .. controls (2.7160,3.1616) and (4.3325,3.2937)
-   .. (5,3) .. controls (6.5505,2.3177) and (6.2401,-0.4348)
-   .. (3,-1) .. controls (1.8036,-1.2085) and (0.4731,-1.0144)
+  (0,0) .. controls (-0.6503,1.2325) and (0.4218,2.6635)
+   .. (2,3) .. controls (2.7156,3.1526) and (4.3229,3.2798)
+   .. (5,3) .. controls (6.7429,2.2799) and (6.0734,-1.0000)
+   .. (3,-1) .. controls (1.8347,-1.0000) and (0.5279,-1.0006)
    .. cycle
 
 Caveats
@@ -82,9 +83,9 @@ Please do use with caution!
 probably due to different rounding. These are under investigation.
 
 
-(3) Currently it isn't possible to explicitly set control points,
-as I don't need this functionality. This may or may not change in the future.
-Please note that the goal of this project is ultimately to support graphical
+(3) Control points may be set explicitly for single joins
+(see ExplicitCurve), but there is no further support for editing control
+points. Please note that the goal of this project is ultimately to support graphical
 requirements for typesetting, not implementing a graphical system. If you
 need a full fledged engine for preparing illustrations, you should stick
 to MetaPost, which is a really great piece of software!
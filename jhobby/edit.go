@@ -0,0 +1,190 @@
+package jhobby
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Editing Paths ---------------------------------------------------------
+
+// Clone creates a deep copy of a path, including its knot and join
+// parameters and its (calculated) control points. Clients may use it
+// for speculative edits, i.e., to try a change and compare the results
+// without affecting the original path.
+func (path *Path) Clone() *Path {
+	p := &Path{
+		points:   clonePairs(path.points),
+		cycle:    path.cycle,
+		predirs:  clonePairs(path.predirs),
+		postdirs: clonePairs(path.postdirs),
+		curls:    clonePairs(path.curls),
+		tensions: clonePairs(path.tensions),
+		exprec:   clonePairs(path.exprec),
+		expostc:  clonePairs(path.expostc),
+		infos:    append([]knotInfo(nil), path.infos...),
+		eps:      path.eps,
+		g2:       path.g2,
+		build:    path.build,
+		err:      path.err,
+		Controls: &splcntrls{},
+		checked:  path.checked,
+	}
+	p.Controls.path = p
+	if path.arc != nil { // pending arc join
+		arc := *path.arc
+		p.arc = &arc
+	}
+	if path.Controls != nil {
+		p.Controls.prec = clonePairs(path.Controls.prec)
+		p.Controls.postc = clonePairs(path.Controls.postc)
+	}
+	return p
+}
+
+// InsertKnotAt inserts a smooth knot at position i, i.e. before the current
+// knot #i. For i = N the knot is appended. Parameters of the existing knots
+// are preserved; explicit control points of the join which is split by the
+// new knot are dropped.
+//
+// Control points calculated for the path are invalid after editing it and
+// have to be re-calculated with FindHobbyControls.
+func (path *Path) InsertKnotAt(i int, pt arithm.Pair) *Path {
+	if i < 0 || i > path.N() {
+		panic(fmt.Sprintf("cannot insert knot at position %d of path with %d knots", i, path.N()))
+	}
+	path.normalize()
+	nan := arithm.Pair(cmplx.NaN())
+	if i > 0 || path.cycle {
+		path.dropExplicitJoin(i - 1)
+	}
+	path.points = insertC(path.points, i, pt)
+	path.predirs = insertC(path.predirs, i, nan)
+	path.postdirs = insertC(path.postdirs, i, nan)
+	path.curls = insertC(path.curls, i, nocurl)
+	path.tensions = insertC(path.tensions, i, 1+1i)
+	path.exprec = insertC(path.exprec, i, nan)
+	path.expostc = insertC(path.expostc, i, nan)
+	path.infos = append(path.infos, knotInfo{})
+	copy(path.infos[i+1:], path.infos[i:])
+	path.infos[i] = knotInfo{}
+	path.Controls.prec = insertC(path.Controls.prec, i, nan)
+	path.Controls.postc = insertC(path.Controls.postc, i, nan)
+	path.Controls.version++
+	return path
+}
+
+// RemoveKnotAt removes knot #i from a path, together with its parameters.
+// The knots adjacent to it will be connected by a join carrying the
+// outgoing tension of knot #i-1 and the incoming tension of knot #i+1.
+// Explicit control points of the joins at knot #i are dropped.
+//
+// Control points calculated for the path are invalid after editing it and
+// have to be re-calculated with FindHobbyControls.
+func (path *Path) RemoveKnotAt(i int) *Path {
+	if i < 0 || i >= path.N() {
+		panic(fmt.Sprintf("cannot remove knot #%d of path with %d knots", i, path.N()))
+	}
+	path.normalize()
+	if i > 0 || path.cycle {
+		path.dropExplicitJoin(i - 1)
+	}
+	path.dropExplicitJoin(i)
+	path.points = removeC(path.points, i)
+	path.predirs = removeC(path.predirs, i)
+	path.postdirs = removeC(path.postdirs, i)
+	path.curls = removeC(path.curls, i)
+	path.tensions = removeC(path.tensions, i)
+	path.exprec = removeC(path.exprec, i)
+	path.expostc = removeC(path.expostc, i)
+	path.infos = append(path.infos[:i], path.infos[i+1:]...)
+	path.Controls.prec = removeC(path.Controls.prec, i)
+	path.Controls.postc = removeC(path.Controls.postc, i)
+	path.Controls.version++
+	return path
+}
+
+// ReplaceKnot moves knot #i to a new position, keeping its parameters.
+//
+// Control points calculated for the path are invalid after editing it and
+// have to be re-calculated with FindHobbyControls.
+func (path *Path) ReplaceKnot(i int, pt arithm.Pair) *Path {
+	if i < 0 || i >= path.N() {
+		panic(fmt.Sprintf("cannot replace knot #%d of path with %d knots", i, path.N()))
+	}
+	path.points[i] = pt
+	if path.Controls != nil {
+		path.Controls.version++
+	}
+	return path
+}
+
+// normalize extends all parameter slices to the length of the path, making
+// it possible to edit them in parallel.
+func (path *Path) normalize() {
+	n := path.N()
+	if n == 0 {
+		return
+	}
+	nan := arithm.Pair(cmplx.NaN())
+	path.predirs = extendC(path.predirs, n-1, nan)
+	path.postdirs = extendC(path.postdirs, n-1, nan)
+	path.curls = extendC(path.curls, n-1, nocurl)
+	path.tensions = extendC(path.tensions, n-1, 1+1i)
+	path.exprec = extendC(path.exprec, n-1, nan)
+	path.expostc = extendC(path.expostc, n-1, nan)
+	path.infos = extendInfo(path.infos, n-1)
+	path.Controls.prec = extendC(path.Controls.prec, n-1, nan)
+	path.Controls.postc = extendC(path.Controls.postc, n-1, nan)
+}
+
+// dropExplicitJoin removes explicit control points from the join between
+// knot #i and knot #i+1 (modulo N).
+func (path *Path) dropExplicitJoin(i int) {
+	n := path.N()
+	if n == 0 {
+		return
+	}
+	i = (i%n + n) % n
+	nan := arithm.Pair(cmplx.NaN())
+	if i < len(path.expostc) {
+		path.expostc[i] = nan
+	}
+	j := i + 1
+	if path.cycle {
+		j %= n
+	}
+	if j < len(path.exprec) {
+		path.exprec[j] = nan
+	}
+}
+
+/* Insert a complex number into an array/slice at position i.
+ */
+func insertC(arr []arithm.Pair, i int, c arithm.Pair) []arithm.Pair {
+	arr = append(arr, 0)
+	copy(arr[i+1:], arr[i:])
+	arr[i] = c
+	return arr
+}
+
+/* Remove the complex number at position i from an array/slice, if present.
+ */
+func removeC(arr []arithm.Pair, i int) []arithm.Pair {
+	if i >= len(arr) {
+		return arr
+	}
+	return append(arr[:i], arr[i+1:]...)
+}
+
+/* Copy an array/slice of complex numbers. nil remains nil.
+ */
+func clonePairs(arr []arithm.Pair) []arithm.Pair {
+	if arr == nil {
+		return nil
+	}
+	c := make([]arithm.Pair, len(arr), cap(arr))
+	copy(c, arr)
+	return c
+}
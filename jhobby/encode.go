@@ -0,0 +1,393 @@
+package jhobby
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Binary Encoding -------------------------------------------------------
+
+// Binary format (all integers are unsigned varints):
+//
+//	magic "JH", version byte, flags byte (bit 0 = cycle, bit 1 = G2)
+//	eps
+//	N, followed by N knots
+//	for each parameter slice: count, followed by count × (index, value)
+//	(for count > 0) dense bit: if set, indices are omitted and values are
+//	given for knots 0…count-1
+//	count, followed by count × (index, length, label, length, client data)
+//
+// Everything following the flags byte is a stream of bits, with varints and
+// labels written as groups of 8 bits. Floats are compressed as in Facebook's
+// Gorilla time series database: each float is XOR-ed with the previous float
+// of its stream (x or y coordinates of a slice), or, for control points,
+// with the coordinate of their knot. A float equal to its predecessor is
+// written as a single 0 bit, otherwise only the meaningful bits between the
+// leading and trailing zeros of the XOR are written, together with their
+// position if it differs from the previous one. Neighbouring knots, and
+// control points and their knots, share their sign, exponent and leading
+// mantissa bits; knots of glyph outlines, given in integral font units, have
+// trailing zero bits as well. Thus most floats are written in less than 64
+// bits. Encoding is lossless.
+const encodingVersion = 1
+
+var encodingMagic = []byte("JH")
+
+var _ encoding.BinaryMarshaler = &Path{}
+var _ encoding.BinaryUnmarshaler = &Path{}
+
+// paramSlices lists the parameter slices of a path in encoding order,
+// together with their default values.
+func (path *Path) paramSlices() ([]*[]arithm.Pair, []arithm.Pair) {
+	nan := arithm.Pair(cmplx.NaN())
+	slices := []*[]arithm.Pair{
+		&path.predirs, &path.postdirs, &path.curls, &path.tensions,
+		&path.exprec, &path.expostc, &path.Controls.prec, &path.Controls.postc,
+	}
+	defaults := []arithm.Pair{nan, nan, nocurl, 1 + 1i, nan, nan, nan, nan}
+	return slices, defaults
+}
+
+// controlSlices is the index of the first slice of control points in
+// paramSlices. Control points lie close to their knots, thus they are XOR-ed
+// with the coordinates of their knot instead of with the previous control
+// point.
+const controlSlices = 4
+
+// anchor sets the previous floats of XOR streams x and y to knot #i.
+func anchor(points []arithm.Pair, i int, x, y *xorState) {
+	if i >= 0 && i < len(points) {
+		x.prev, y.prev = math.Float64bits(real(points[i])), math.Float64bits(imag(points[i]))
+	}
+}
+
+// MarshalBinary encodes a path, including its parameters, (calculated)
+// control points, geometric tolerance, curvature continuity and knot labels, into a compact binary
+// form. Clients may use it to cache solved paths, e.g. glyph outlines,
+// between typesetting runs. Type Path will use this encoding for
+// encoding/gob as well.
+//
+// Client data of knots (see SetKnotData) is encoded with encoding/gob,
+// thus its types have to be registered with gob.Register. MarshalBinary
+// returns an error for client data which gob cannot encode.
+func (path *Path) MarshalBinary() ([]byte, error) {
+	if path.Controls == nil {
+		path.Controls = &splcntrls{path: path}
+	}
+	var flags byte
+	if path.cycle {
+		flags |= 1
+	}
+	if path.g2 {
+		flags |= 2
+	}
+	enc := &bitWriter{buf: append(append([]byte{}, encodingMagic...), encodingVersion, flags)}
+	enc.float(path.eps, &xorState{})
+	enc.uint(uint64(path.N()))
+	var x, y xorState
+	for _, pt := range path.points {
+		enc.pair(pt, &x, &y)
+	}
+	slices, defaults := path.paramSlices()
+	for k, arr := range slices {
+		x, y = xorState{}, xorState{}
+		var indices []int
+		for i, v := range *arr {
+			if !isDefault(v, defaults[k]) {
+				indices = append(indices, i)
+			}
+		}
+		enc.uint(uint64(len(indices)))
+		dense := len(indices) > 0 && indices[len(indices)-1] == len(indices)-1
+		if dense { // values for knots 0…count-1, without indices
+			enc.bits(1, 1)
+		} else if len(indices) > 0 {
+			enc.bits(0, 1)
+		}
+		previ := 0
+		for _, i := range indices {
+			if !dense {
+				enc.uint(uint64(i - previ))
+			}
+			if k >= controlSlices {
+				anchor(path.points, i, &x, &y)
+			}
+			enc.pair((*arr)[i], &x, &y)
+			previ = i
+		}
+	}
+	var infos []int
+	for i, info := range path.infos {
+		if !info.empty() {
+			infos = append(infos, i)
+		}
+	}
+	enc.uint(uint64(len(infos)))
+	previ := 0
+	for _, i := range infos {
+		var data []byte
+		if d := path.infos[i].data; d != nil {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(&d); err != nil {
+				return nil, fmt.Errorf("cannot encode client data of knot #%d: %v", i, err)
+			}
+			data = buf.Bytes()
+		}
+		enc.uint(uint64(i - previ))
+		enc.bytes([]byte(path.infos[i].label))
+		enc.bytes(data)
+		previ = i
+	}
+	return enc.buf, nil
+}
+
+// UnmarshalBinary decodes a path from its binary form, as created by
+// MarshalBinary. The receiver's contents are replaced.
+func (path *Path) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 || !bytes.Equal(data[:2], encodingMagic) {
+		return errors.New("not a binary encoded path")
+	}
+	if data[2] != encodingVersion {
+		return fmt.Errorf("unsupported path encoding version %d", data[2])
+	}
+	p := Nullpath()
+	p.cycle = data[3]&1 != 0
+	p.g2 = data[3]&2 != 0
+	dec := &pathDecoder{data: data[4:]}
+	p.eps = dec.float(&xorState{})
+	n := dec.uint()
+	if dec.err == nil && n > uint64(len(data)) {
+		return errors.New("corrupt binary path encoding: invalid knot count")
+	}
+	var x, y xorState
+	for i := uint64(0); i < n && dec.err == nil; i++ {
+		p.points = append(p.points, dec.pair(&x, &y))
+	}
+	slices, defaults := p.paramSlices()
+	for k, arr := range slices {
+		x, y = xorState{}, xorState{}
+		count := dec.uint()
+		dense := count > 0 && dec.bits(1) == 1
+		i := 0
+		for c := uint64(0); c < count && dec.err == nil; c++ {
+			if dense {
+				i = int(c)
+			} else {
+				i += int(dec.uint())
+			}
+			if k >= controlSlices {
+				anchor(p.points, i, &x, &y)
+			}
+			v := dec.pair(&x, &y)
+			if i < 0 || i > len(data) {
+				return errors.New("corrupt binary path encoding: invalid index")
+			}
+			*arr = extendC(*arr, i, defaults[k])
+			(*arr)[i] = v
+		}
+	}
+	count := dec.uint()
+	i := 0
+	for c := uint64(0); c < count && dec.err == nil; c++ {
+		i += int(dec.uint())
+		if i < 0 || i > len(data) {
+			return errors.New("corrupt binary path encoding: invalid label")
+		}
+		p.SetLabel(i, string(dec.bytes()))
+		if d := dec.bytes(); len(d) > 0 {
+			var v interface{}
+			if err := gob.NewDecoder(bytes.NewReader(d)).Decode(&v); err != nil {
+				return fmt.Errorf("cannot decode client data of knot #%d: %v", i, err)
+			}
+			p.SetKnotData(i, v)
+		}
+	}
+	if dec.err != nil {
+		return fmt.Errorf("corrupt binary path encoding: %v", dec.err)
+	}
+	*path = *p
+	path.Controls.path = path
+	return nil
+}
+
+func isDefault(v, deflt arithm.Pair) bool {
+	if cmplx.IsNaN(deflt.C()) { // partially given values are not default
+		return math.IsNaN(real(v)) && math.IsNaN(imag(v))
+	}
+	return v == deflt
+}
+
+// xorState is the state of a stream of XOR-compressed floats: the bit
+// pattern of the previous float and the position of the meaningful bits
+// of the previous XOR.
+type xorState struct {
+	prev        uint64
+	lead, trail int
+	valid       bool // lead and trail have been written
+}
+
+// bitWriter writes varints and compressed floats to a stream of bits.
+type bitWriter struct {
+	buf  []byte
+	free uint // unused bits in the last byte
+}
+
+// bits writes the lower n bits of v, most significant bit first.
+func (w *bitWriter) bits(v uint64, n uint) {
+	for n > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+		k := n
+		if k > w.free {
+			k = w.free
+		}
+		chunk := (v >> (n - k)) & (1<<k - 1)
+		w.buf[len(w.buf)-1] |= byte(chunk << (w.free - k))
+		w.free -= k
+		n -= k
+	}
+}
+
+func (w *bitWriter) uint(x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	for _, b := range tmp[:n] {
+		w.bits(uint64(b), 8)
+	}
+}
+
+func (w *bitWriter) bytes(b []byte) {
+	w.uint(uint64(len(b)))
+	for _, c := range b {
+		w.bits(uint64(c), 8)
+	}
+}
+
+func (w *bitWriter) float(f float64, s *xorState) {
+	b := math.Float64bits(f)
+	x := b ^ s.prev
+	s.prev = b
+	if x == 0 {
+		w.bits(0, 1)
+		return
+	}
+	lead, trail := bits.LeadingZeros64(x), bits.TrailingZeros64(x)
+	if lead > 31 {
+		lead = 31
+	}
+	if s.valid && lead >= s.lead && trail >= s.trail { // fits into previous position
+		w.bits(2, 2)
+		w.bits(x>>uint(s.trail), uint(64-s.lead-s.trail))
+		return
+	}
+	s.lead, s.trail, s.valid = lead, trail, true
+	w.bits(3, 2)
+	w.bits(uint64(lead), 5)
+	w.bits(uint64(64-lead-trail-1), 6)
+	w.bits(x>>uint(trail), uint(64-lead-trail))
+}
+
+func (w *bitWriter) pair(p arithm.Pair, x, y *xorState) {
+	w.float(real(p), x)
+	w.float(imag(p), y)
+}
+
+// pathDecoder reads varints and compressed floats from a stream of bits.
+// The first error is sticky.
+type pathDecoder struct {
+	data []byte
+	pos  uint // position in bits
+	err  error
+}
+
+var errTruncated = errors.New("unexpected end of data")
+
+func (dec *pathDecoder) bits(n uint) uint64 {
+	if dec.err != nil {
+		return 0
+	}
+	if dec.pos+n > uint(len(dec.data))*8 {
+		dec.err = errTruncated
+		return 0
+	}
+	var v uint64
+	for n > 0 {
+		free := 8 - dec.pos%8
+		k := n
+		if k > free {
+			k = free
+		}
+		b := uint64(dec.data[dec.pos/8]) >> (free - k) & (1<<k - 1)
+		v = v<<k | b
+		dec.pos += k
+		n -= k
+	}
+	return v
+}
+
+func (dec *pathDecoder) uint() uint64 {
+	var x uint64
+	for s := uint(0); dec.err == nil; s += 7 {
+		if s >= 64 {
+			dec.err = errors.New("varint overflows 64 bits")
+			return 0
+		}
+		b := dec.bits(8)
+		x |= (b & 0x7f) << s
+		if b < 0x80 {
+			return x
+		}
+	}
+	return 0
+}
+
+func (dec *pathDecoder) bytes() []byte {
+	l := dec.uint()
+	if dec.err == nil && l > uint64(len(dec.data)) {
+		dec.err = errTruncated
+	}
+	if dec.err != nil {
+		return nil
+	}
+	b := make([]byte, l)
+	for k := range b {
+		b[k] = byte(dec.bits(8))
+	}
+	return b
+}
+
+func (dec *pathDecoder) float(s *xorState) float64 {
+	var x uint64
+	switch {
+	case dec.bits(1) == 0:
+		x = 0
+	case dec.bits(1) == 0:
+		x = dec.bits(uint(64-s.lead-s.trail)) << uint(s.trail)
+	default:
+		s.lead = int(dec.bits(5))
+		l := int(dec.bits(6)) + 1
+		s.trail = 64 - s.lead - l
+		if s.trail < 0 {
+			dec.err = errors.New("invalid float")
+			return 0
+		}
+		x = dec.bits(uint(l)) << uint(s.trail)
+	}
+	s.prev ^= x
+	return math.Float64frombits(s.prev)
+}
+
+func (dec *pathDecoder) pair(x, y *xorState) arithm.Pair {
+	return arithm.Pair(complex(dec.float(x), dec.float(y)))
+}
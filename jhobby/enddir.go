@@ -0,0 +1,33 @@
+package jhobby
+
+import (
+	"errors"
+
+	"github.com/npillmayer/arithm"
+)
+
+// SolveWithEndDirections finds the control points of an open path with given
+// directions at its end points, covering the common case of a curve leaving
+// its first knot and arriving at its last knot at given angles
+// (MetaPost: "z0{dir a} .. z1 .. {dir b}z2"). Angles are given in degrees,
+// counter-clockwise from the positive x-axis.
+//
+// The path is not modified. SolveWithEndDirections returns a copy of it with
+// the end directions set, overwriting directions given before, together with
+// its control points, calculated as with FindHobbyControls. Options are
+// passed on to FindHobbyControls. An error is returned if the path is cyclic
+// or has less than 2 knots.
+func SolveWithEndDirections(path *Path, startAngle, endAngle arithm.Deg, opts ...SolveOption) (*Path, SplineControls, error) {
+	if path.IsCycle() || path.N() < 2 {
+		return nil, nil, errors.New("end directions require an open path of at least 2 knots")
+	}
+	p := path.Clone()
+	p.SetPostDir(0, dir(startAngle))
+	p.SetPreDir(p.N()-1, dir(endAngle))
+	return p, FindHobbyControls(p, p.Controls, opts...), nil
+}
+
+// dir returns the unit vector for an angle (MetaPost: "dir a").
+func dir(a arithm.Deg) arithm.Pair {
+	return arithm.P(1, 0).Rotated(float64(a.Rad()))
+}
@@ -0,0 +1,144 @@
+package jhobby
+
+import (
+	"github.com/npillmayer/arithm"
+)
+
+// --- Solver Events ---------------------------------------------------------
+
+// SolveEventKind denotes the stage of the solver a SolveEvent reports.
+type SolveEventKind int
+
+// Stages of the solver, reported for each segment of a path.
+const (
+	SegmentStarted SolveEventKind = iota // a segment is about to be solved
+	EquationsBuilt                       // the linear equations for the angles are built
+	AnglesChosen                         // the tangent angles have been chosen
+	ControlsSet                          // the control points have been set
+)
+
+func (kind SolveEventKind) String() string {
+	switch kind {
+	case SegmentStarted:
+		return "SegmentStarted"
+	case EquationsBuilt:
+		return "EquationsBuilt"
+	case AnglesChosen:
+		return "AnglesChosen"
+	case ControlsSet:
+		return "ControlsSet"
+	}
+	return "SolveEventKind(?)"
+}
+
+// SolveEvent is a structured representation of the solver's progress for a
+// segment of a path. Clients may use events to build a viewer for the
+// solver's choices, or to compare them to the log output of MetaPost.
+// Fields not applicable to an event's kind are nil. Slices are owned by the
+// receiver of the event.
+//
+// For EquationsBuilt, U, V and W hold the coefficients of the forward
+// elimination of MetaFont's tridiagonal system (MetaFont §284ff), such that
+// θ(k) = V(k) − U(k)⋅θ(k+1) (+ W(k)⋅θ(0) for cycles). For AnglesChosen, Theta
+// and Phi hold the angles for each join (see SegmentReport). For
+// ControlsSet, Pre and Post hold the control points before and after each
+// knot of the segment.
+type SolveEvent struct {
+	Kind      SolveEventKind
+	From, To  int           // first and last knot of the segment (mod N)
+	U, V, W   []float64     // EquationsBuilt: elimination coefficients
+	Theta     []float64     // AnglesChosen: outgoing angle at the start of each join
+	Phi       []float64     // AnglesChosen: incoming angle at the end of each join
+	Pre, Post []arithm.Pair // ControlsSet: control points at each knot
+}
+
+// WithEvents lets the solver report its progress as structured events to a
+// callback. Calls to f are serialized. However, if segments are solved
+// concurrently (see WithWorkers), events for different segments may
+// interleave; use WithWorkers(1) to receive them segment by segment.
+func WithEvents(f func(SolveEvent)) SolveOption {
+	return func(conf *solveConfig) {
+		conf.events = f
+	}
+}
+
+// emit sends an event for a segment to the callback, if set.
+func (conf *solveConfig) emit(path HobbyPath, ev SolveEvent) {
+	if conf.events == nil {
+		return
+	}
+	ev.From, ev.To = segmentRange(path)
+	conf.eventMx.Lock()
+	defer conf.eventMx.Unlock()
+	conf.events(ev)
+}
+
+// emitEquations reports the coefficients u, v (and w) of a segment.
+func (conf *solveConfig) emitEquations(path HobbyPath, u, v, w []arithm.Number) {
+	if conf.events == nil {
+		return
+	}
+	m := path.N() + 1
+	ev := SolveEvent{Kind: EquationsBuilt, U: copyNumbers(u, m), V: copyNumbers(v, m)}
+	if w != nil {
+		ev.W = copyNumbers(w, m)
+	}
+	conf.emit(path, ev)
+}
+
+// emitAngles reports the angles chosen for a segment.
+func (conf *solveConfig) emitAngles(path HobbyPath, theta []float64) {
+	if conf.events == nil {
+		return
+	}
+	ev := SolveEvent{Kind: AnglesChosen}
+	for k := 0; k < joinCount(path); k++ {
+		ev.Theta = append(ev.Theta, theta[k])
+		ev.Phi = append(ev.Phi, -psi(path, k+1)-theta[k+1])
+	}
+	conf.emit(path, ev)
+}
+
+// emitControls reports the control points of a segment.
+func (conf *solveConfig) emitControls(path HobbyPath, controls SplineControls) {
+	if conf.events == nil {
+		return
+	}
+	ev := SolveEvent{Kind: ControlsSet}
+	for k := 0; k < path.N(); k++ {
+		ev.Pre = append(ev.Pre, controls.PreControl(k))
+		ev.Post = append(ev.Post, controls.PostControl(k))
+	}
+	conf.emit(path, ev)
+}
+
+// segmentRange returns the first and last knot of a segment, relative to the
+// whole path.
+func segmentRange(path HobbyPath) (int, int) {
+	if pp, ok := path.(*pathPartial); ok {
+		return pp.pmap(0), pp.pmap(pp.N() - 1)
+	}
+	return 0, path.N() - 1
+}
+
+// joinCount returns the number of joins of a path.
+func joinCount(path HobbyPath) int {
+	if path.IsCycle() {
+		return path.N()
+	}
+	return path.N() - 1
+}
+
+// copyNumbers rounds the first n numbers of x to float64.
+func copyNumbers(x []arithm.Number, n int) []float64 {
+	if n > len(x) {
+		n = len(x)
+	}
+	f := make([]float64, n)
+	for i := range f {
+		if x[i] != nil {
+			f[i] = x[i].Float64()
+		}
+	}
+	return f
+}
@@ -0,0 +1,74 @@
+package jhobby
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Fingerprints ----------------------------------------------------------
+
+// fingerprintGrid is the quantum coordinates are rounded to before hashing.
+const fingerprintGrid = 1e-6
+
+// Fingerprint returns a hash value of a path, including its (calculated)
+// control points. Clients may use it as a key for memoizing expensive work
+// depending on the shape of a path, e.g. rasterization or envelopes.
+//
+// Coordinates are quantized to a grid of 1e-6 before hashing, so tiny
+// numerical noise from solving does not change the fingerprint (except for
+// coordinates very close to a grid boundary). For cyclic paths the
+// fingerprint does not depend on the starting knot, consistent with Equal.
+// Paths with equal fingerprints are not guaranteed to be equal.
+func Fingerprint(path HobbyPath, controls SplineControls) uint64 {
+	n := path.N()
+	if !path.IsCycle() {
+		return fingerprintFrom(path, controls, 0)
+	}
+	var fp uint64
+	for offset := 0; offset < n; offset++ {
+		if h := fingerprintFrom(path, controls, offset); offset == 0 || h < fp {
+			fp = h
+		}
+	}
+	return fp
+}
+
+// fingerprintFrom hashes the knots of a path, starting with knot #offset.
+func fingerprintFrom(path HobbyPath, controls SplineControls, offset int) uint64 {
+	h := fnv.New64a()
+	n := path.N()
+	var buf [binary.MaxVarintLen64]byte
+	put := func(x int64) {
+		h.Write(buf[:binary.PutVarint(buf[:], x)])
+	}
+	putPair := func(p arithm.Pair) {
+		if cmplx.IsNaN(p.C()) {
+			h.Write([]byte{0})
+			return
+		}
+		h.Write([]byte{1})
+		put(quantize(p.X()))
+		put(quantize(p.Y()))
+	}
+	if path.IsCycle() {
+		put(1)
+	} else {
+		put(0)
+	}
+	put(int64(n))
+	for i := 0; i < n; i++ {
+		j := (i + offset) % n
+		putPair(path.Z(j))
+		putPair(controlOrNaN(controls, j, true))
+		putPair(controlOrNaN(controls, j, false))
+	}
+	return h.Sum64()
+}
+
+func quantize(x float64) int64 {
+	return int64(math.Round(x / fingerprintGrid))
+}
@@ -0,0 +1,153 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Fitting Paths to Samples ----------------------------------------------
+
+// fitWindow is the number of neighbouring samples on each side of a sample
+// which are used for the least-squares smoothing of knot positions.
+const fitWindow = 2
+
+// Fit constructs a sparse path whose solved spline approximates a dense
+// sequence of sample points (e.g., from a tablet or from tracing a bitmap).
+// Every sample will be at most tolerance away from the resulting curve.
+// Parameter cycle selects wether the samples describe a closed shape.
+//
+// Knots are placed at sample positions, where the positions are smoothed by
+// a local least-squares fit to account for noise in the samples. Starting
+// with a minimal set of knots, the sample with the largest deviation from the
+// curve is repeatedly added as a new knot, until the curve lies within
+// tolerance of all samples. If a sample chosen as a knot is still too far
+// from the curve, its knot is moved back to the unsmoothed sample position.
+//
+// Fit returns the path together with its solved control points.
+func Fit(samples []arithm.Pair, cycle bool, tolerance float64) (*Path, SplineControls) {
+	n := len(samples)
+	if n == 0 {
+		return Nullpath(), &splcntrls{}
+	}
+	knots := smoothSamples(samples, cycle)
+	selected := make([]bool, n)
+	if cycle && n > 3 {
+		selected[0], selected[n/3], selected[2*n/3] = true, true, true
+	} else if cycle {
+		for i := range selected {
+			selected[i] = true
+		}
+	} else {
+		selected[0], selected[n-1] = true, true
+	}
+	for {
+		path := Nullpath()
+		for i, pt := range knots {
+			if selected[i] {
+				path.points = append(path.points, pt)
+			}
+		}
+		path.cycle = cycle
+		controls := FindHobbyControls(path, path.Controls)
+		curve := sampleCurve(path, controls)
+		worst, dmax := -1, tolerance
+		for i, pt := range samples {
+			if selected[i] && knots[i] == pt {
+				continue // knot is exactly at the sample
+			}
+			if dist := polylineDistance(pt, curve); dist >= dmax {
+				worst, dmax = i, dist
+			}
+		}
+		if worst < 0 {
+			T().Debugf("fit: %d samples fitted by %d knots", n, path.N())
+			return path, controls
+		}
+		if selected[worst] { // smoothing moved the knot too far away
+			knots[worst] = samples[worst]
+		}
+		selected[worst] = true
+	}
+}
+
+// smoothSamples computes a least-squares estimate for every sample position.
+// For each sample a quadratic curve is fitted to the sample and its
+// neighbours, parameterized by (normalized) chord length. The sample is then
+// replaced by the fitted curve's position. End points of open sample
+// sequences remain fixed.
+func smoothSamples(samples []arithm.Pair, cycle bool) []arithm.Pair {
+	n := len(samples)
+	smoothed := make([]arithm.Pair, n)
+	copy(smoothed, samples)
+	if n <= 2*fitWindow {
+		return smoothed
+	}
+	for i := range samples {
+		if !cycle && (i < fitWindow || i >= n-fitWindow) {
+			continue
+		}
+		var ts []float64
+		var pts []arithm.Pair
+		t := 0.0
+		for k := -fitWindow; k <= fitWindow; k++ {
+			j := ((i+k)%n + n) % n
+			if k > -fitWindow {
+				prev := ((j-1)%n + n) % n
+				t += cmplx.Abs((samples[j] - samples[prev]).C())
+			}
+			ts = append(ts, t)
+			pts = append(pts, samples[j])
+		}
+		ti := ts[fitWindow]
+		x, okx := quadraticFitAt(ts, pts, arithm.Pair.X, ti)
+		y, oky := quadraticFitAt(ts, pts, arithm.Pair.Y, ti)
+		if okx && oky {
+			smoothed[i] = arithm.P(x, y)
+		}
+	}
+	return smoothed
+}
+
+// quadraticFitAt fits a quadratic polynomial c0 + c1⋅t + c2⋅t² to the
+// coordinates of pts (selected by coord) in a least-squares sense and
+// evaluates it at t0. Returns false if the normal equations are singular.
+func quadraticFitAt(ts []float64, pts []arithm.Pair, coord func(arithm.Pair) float64,
+	t0 float64) (float64, bool) {
+	//
+	span := ts[len(ts)-1] - ts[0] // normalize t, as the normal equations mix powers of t
+	if span <= 0 {
+		return 0, false
+	}
+	m := [][]float64{make([]float64, 3), make([]float64, 3), make([]float64, 3)}
+	b := make([]float64, 3)
+	for k, t := range ts {
+		u := (t - t0) / span // center at t0 for stability
+		pows := [3]float64{1, u, u * u}
+		v := coord(pts[k])
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				m[r][c] += pows[r] * pows[c]
+			}
+			b[r] += pows[r] * v
+		}
+	}
+	c, ok := arithm.SolveLinear(m, b)
+	if !ok {
+		return 0, false
+	}
+	return c[0], true // as t is centered at t0, the value is c0
+}
+
+// polylineDistance is the distance of point pt from a polyline.
+func polylineDistance(pt arithm.Pair, polyline []arithm.Pair) float64 {
+	if len(polyline) == 1 {
+		return cmplx.Abs((pt - polyline[0]).C())
+	}
+	dmin := math.Inf(1)
+	for i := 0; i+1 < len(polyline); i++ {
+		dmin = math.Min(dmin, segmentDistance(pt, polyline[i], polyline[i+1]))
+	}
+	return dmin
+}
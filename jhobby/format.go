@@ -0,0 +1,216 @@
+package jhobby
+
+import (
+	"math/cmplx"
+	"strconv"
+	"strings"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Formatting Paths ------------------------------------------------------
+
+// Formatter creates a textual representation of a path, including its
+// control points, if present. Clients select a Formatter according to the
+// tool which will consume the output, e.g. MetaPost, an SVG renderer, or
+// TikZ.
+//
+// AsString remains available for debugging purposes.
+type Formatter interface {
+	Format(path HobbyPath, controls SplineControls) string
+}
+
+// FormatOption configures a built-in formatter.
+type FormatOption func(*formatConfig)
+
+type formatConfig struct {
+	precision int // number of decimal places
+}
+
+// WithPrecision sets the maximum number of decimal places for coordinates.
+// Trailing zeros are omitted. The default is 4.
+func WithPrecision(digits int) FormatOption {
+	return func(conf *formatConfig) {
+		if digits >= 0 {
+			conf.precision = digits
+		}
+	}
+}
+
+func newFormatConfig(opts []FormatOption) formatConfig {
+	conf := formatConfig{precision: 4}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return conf
+}
+
+// num formats a number with the configured precision.
+func (conf formatConfig) num(x float64) string {
+	s := strconv.FormatFloat(x, 'f', conf.precision, 64)
+	if strings.ContainsRune(s, '.') {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// pair formats a pair as "x<sep>y".
+func (conf formatConfig) pair(p arithm.Pair, sep string) string {
+	return conf.num(p.X()) + sep + conf.num(p.Y())
+}
+
+// forEachJoin calls f for every join of a path, with its start and end knot
+// and its control points. Parameter curved is false if control points have
+// not been calculated (or controls is nil).
+func forEachJoin(path HobbyPath, controls SplineControls,
+	f func(i int, z0, c1, c2, z1 arithm.Pair, curved bool)) {
+	//
+	n := path.N()
+	joins := n - 1
+	if path.IsCycle() {
+		joins = n
+	}
+	for i := 0; i < joins; i++ {
+		var c1, c2 arithm.Pair
+		curved := controls != nil
+		if curved {
+			c1, c2 = controls.PostControl(i), controls.PreControl((i+1)%n)
+			curved = !cmplx.IsNaN(c1.C()) && !cmplx.IsNaN(c2.C())
+		}
+		f(i, path.Z(i), c1, c2, path.Z(i+1), curved)
+	}
+}
+
+// MetaPostFormatter returns a formatter for MetaPost path expressions, e.g.
+//
+//	(0,0)..controls (0,1) and (1,2)..(2,2)..cycle
+//
+// Joins without control points are written as "..".
+func MetaPostFormatter(opts ...FormatOption) Formatter {
+	return metaPostFormat{newFormatConfig(opts)}
+}
+
+type metaPostFormat struct{ formatConfig }
+
+func (mf metaPostFormat) Format(path HobbyPath, controls SplineControls) string {
+	var b strings.Builder
+	if path.N() == 0 {
+		return ""
+	}
+	b.WriteString("(" + mf.pair(path.Z(0), ",") + ")")
+	forEachJoin(path, controls, func(i int, z0, c1, c2, z1 arithm.Pair, curved bool) {
+		if curved {
+			b.WriteString("..controls (" + mf.pair(c1, ",") + ") and (" + mf.pair(c2, ",") + ")")
+		}
+		if path.IsCycle() && i == path.N()-1 {
+			b.WriteString("..cycle")
+		} else {
+			b.WriteString("..(" + mf.pair(z1, ",") + ")")
+		}
+	})
+	if path.IsCycle() && path.N() == 1 {
+		b.WriteString("..cycle")
+	}
+	return b.String()
+}
+
+// SVGFormatter returns a formatter for SVG path data, e.g.
+//
+//	M0 0 C0 1 1 2 2 2 C3 2 0 -1 0 0 Z
+//
+// Joins without control points are written as straight lines. Please note
+// that SVG's y-axis points downwards; coordinates are not flipped.
+func SVGFormatter(opts ...FormatOption) Formatter {
+	return svgFormat{newFormatConfig(opts)}
+}
+
+type svgFormat struct{ formatConfig }
+
+func (sf svgFormat) Format(path HobbyPath, controls SplineControls) string {
+	if path.N() == 0 {
+		return ""
+	}
+	parts := []string{"M" + sf.pair(path.Z(0), " ")}
+	forEachJoin(path, controls, func(i int, z0, c1, c2, z1 arithm.Pair, curved bool) {
+		if curved {
+			parts = append(parts, "C"+sf.pair(c1, " ")+" "+sf.pair(c2, " ")+" "+sf.pair(z1, " "))
+		} else {
+			parts = append(parts, "L"+sf.pair(z1, " "))
+		}
+	})
+	if path.IsCycle() {
+		parts = append(parts, "Z")
+	}
+	return strings.Join(parts, " ")
+}
+
+// TikZFormatter returns a formatter for TikZ path specifications, e.g.
+//
+//	(0,0) .. controls (0,1) and (1,2) .. (2,2) -- cycle
+//
+// The output does not include a path command; clients will prepend \draw,
+// \fill, etc. Joins without control points are written as straight lines.
+func TikZFormatter(opts ...FormatOption) Formatter {
+	return tikzFormat{newFormatConfig(opts)}
+}
+
+type tikzFormat struct{ formatConfig }
+
+func (tf tikzFormat) Format(path HobbyPath, controls SplineControls) string {
+	var b strings.Builder
+	if path.N() == 0 {
+		return ""
+	}
+	b.WriteString("(" + tf.pair(path.Z(0), ",") + ")")
+	forEachJoin(path, controls, func(i int, z0, c1, c2, z1 arithm.Pair, curved bool) {
+		target := "(" + tf.pair(z1, ",") + ")"
+		if path.IsCycle() && i == path.N()-1 {
+			target = "cycle"
+		}
+		if curved {
+			b.WriteString(" .. controls (" + tf.pair(c1, ",") + ") and (" + tf.pair(c2, ",") + ") .. " + target)
+		} else {
+			b.WriteString(" -- " + target)
+		}
+	})
+	return b.String()
+}
+
+// JSONFormatter returns a formatter for a JSON representation of a path.
+// Knots are listed with their incoming and outgoing control points, which are
+// null if not calculated, e.g.
+//
+//	{"cycle":false,"knots":[{"z":[0,0],"pre":null,"post":[0,1]},
+//	  {"z":[2,2],"pre":[1,2],"post":null}]}
+func JSONFormatter(opts ...FormatOption) Formatter {
+	return jsonFormat{newFormatConfig(opts)}
+}
+
+type jsonFormat struct{ formatConfig }
+
+func (jf jsonFormat) Format(path HobbyPath, controls SplineControls) string {
+	var b strings.Builder
+	b.WriteString(`{"cycle":` + strconv.FormatBool(path.IsCycle()) + `,"knots":[`)
+	for i := 0; i < path.N(); i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		pre, post := "null", "null"
+		if controls != nil {
+			pre, post = jf.jsonPair(controls.PreControl(i)), jf.jsonPair(controls.PostControl(i))
+		}
+		b.WriteString(`{"z":` + jf.jsonPair(path.Z(i)) + `,"pre":` + pre + `,"post":` + post + `}`)
+	}
+	b.WriteString("]}")
+	return b.String()
+}
+
+func (jf jsonFormat) jsonPair(p arithm.Pair) string {
+	if cmplx.IsNaN(p.C()) || cmplx.IsInf(p.C()) {
+		return "null"
+	}
+	return "[" + jf.pair(p, ",") + "]"
+}
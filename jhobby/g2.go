@@ -0,0 +1,153 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Curvature Continuity --------------------------------------------------
+
+const (
+	g2MaxIterations = 50    // max number of Newton steps
+	g2Tolerance     = 1e-10 // max curvature difference at a knot, times the size of the segment
+	g2Step          = 1e-7  // step size for numerical derivatives
+)
+
+// refineCurvature adjusts the tangent angles theta of a segment, as found by
+// Hobby's algorithm, until the curvatures at both sides of each inner knot
+// match. It uses Newton's method with a numerical Jacobian, accepting only
+// steps which reduce the curvature differences. Curvatures scale inversely
+// with the size of a path, thus the tolerance for curvature differences is
+// relative to the size of the segment. Returns false if the refinement did
+// not converge; theta then holds the best angles found.
+func refineCurvature(path HobbyPath, theta []float64, conf *solveConfig) bool {
+	eps := conf.eps
+	unknowns := g2Unknowns(path)
+	if len(unknowns) == 0 {
+		return true
+	}
+	tolerance := g2Tolerance
+	ll, ur := ControlHullBox(path, nil)
+	if size := cmplx.Abs((ur - ll).C()); size > eps {
+		tolerance /= size
+	}
+	r := curvatureResiduals(path, theta, unknowns, eps)
+	norm := maxAbs(r)
+	for it := 0; it < g2MaxIterations && norm > tolerance; it++ {
+		jac := make([][]float64, len(r))
+		for row := range jac {
+			jac[row] = make([]float64, len(unknowns))
+		}
+		for col, k := range unknowns {
+			trial := append([]float64(nil), theta...)
+			setTheta(path, trial, k, theta[k]+g2Step)
+			rt := curvatureResiduals(path, trial, unknowns, eps)
+			for row := range rt {
+				jac[row][col] = (rt[row] - r[row]) / g2Step
+			}
+		}
+		dx, ok := arithm.SolveLinear(jac, r)
+		if !ok {
+			break
+		}
+		improved := false
+		for step := 1.0; step > 1e-6; step /= 2 { // damped Newton step
+			trial := append([]float64(nil), theta...)
+			for col, k := range unknowns {
+				setTheta(path, trial, k, theta[k]-step*dx[col])
+			}
+			rt := curvatureResiduals(path, trial, unknowns, eps)
+			if n := maxAbs(rt); n < norm {
+				copy(theta, trial)
+				r, norm, improved = rt, n, true
+				break
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	if norm > tolerance {
+		conf.debugf("curvature refinement did not converge, max difference = %g", norm)
+		return false
+	}
+	return true
+}
+
+// g2Unknowns returns the knots whose tangent angles are to be refined: all
+// knots of a cycle, and the inner knots of an open segment.
+func g2Unknowns(path HobbyPath) []int {
+	var unknowns []int
+	from, to := 1, path.N()-1
+	if path.IsCycle() {
+		from, to = 0, path.N()
+	}
+	for k := from; k < to; k++ {
+		unknowns = append(unknowns, k)
+	}
+	return unknowns
+}
+
+// setTheta sets the angle at knot k to t. For cycles, theta[N] is kept
+// identical to theta[0].
+func setTheta(path HobbyPath, theta []float64, k int, t float64) {
+	theta[k] = t
+	if path.IsCycle() && k == 0 {
+		theta[path.N()] = t
+	}
+}
+
+// curvatureResiduals calculates, for every knot k in unknowns, the difference
+// between the curvature at the end of the incoming segment and the curvature
+// at the start of the outgoing segment. Curvatures at control legs shorter
+// than eps are considered 0.
+func curvatureResiduals(path HobbyPath, theta []float64, unknowns []int, eps float64) []float64 {
+	n := path.N()
+	r := make([]float64, len(unknowns))
+	for row, k := range unknowns {
+		prev := k - 1
+		if prev < 0 {
+			prev += n
+		}
+		_, c1, c2, z := thetaCubic(path, theta, prev)
+		z0, d1, d2, _ := thetaCubic(path, theta, k)
+		r[row] = endCurvature(c1, c2, z, eps) - startCurvature(z0, d1, d2, eps)
+	}
+	return r
+}
+
+// thetaCubic returns the Bézier points of segment i (from z.i to z.[i+1]) for
+// tangent angles theta.
+func thetaCubic(path HobbyPath, theta []float64, i int) (arithm.Pair, arithm.Pair, arithm.Pair, arithm.Pair) {
+	phi := -psi(path, i+1) - theta[i+1]
+	p2, p3 := segmentControls(path, i, theta[i], phi)
+	return path.Z(i), path.Z(i) + p2, path.Z(i+1) - p3, path.Z(i + 1)
+}
+
+// startCurvature is the signed curvature of a cubic Bézier curve at t = 0.
+func startCurvature(p0, p1, p2 arithm.Pair, eps float64) float64 {
+	l := cmplx.Abs((p1 - p0).C())
+	if l < eps {
+		return 0
+	}
+	return 2.0 / 3.0 * (p1 - p0).Cross(p2-p1) / (l * l * l)
+}
+
+// endCurvature is the signed curvature of a cubic Bézier curve at t = 1.
+func endCurvature(p1, p2, p3 arithm.Pair, eps float64) float64 {
+	l := cmplx.Abs((p3 - p2).C())
+	if l < eps {
+		return 0
+	}
+	return 2.0 / 3.0 * (p2 - p1).Cross(p3-p2) / (l * l * l)
+}
+
+func maxAbs(x []float64) float64 {
+	m := 0.0
+	for _, v := range x {
+		m = math.Max(m, math.Abs(v))
+	}
+	return m
+}
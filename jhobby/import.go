@@ -0,0 +1,94 @@
+package jhobby
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Importing Cubic Bézier Paths ------------------------------------------
+
+// FromCubics reconstructs a path from a sequence of cubic Bézier segments,
+// e.g. from an imported SVG or font outline. Each segment has to start at the
+// end point of its predecessor. If the last segment ends at the start point
+// of the first one, the path will be cyclic.
+//
+// For every segment the directions at its knots and the tensions of the join
+// are derived such that Hobby's algorithm will re-create the segment. The
+// resulting path may then be edited with Hobby semantics. Segments which
+// cannot be expressed in terms of directions and tensions (tensions outside
+// of 3/4…4, degenerate control points) are represented by explicit control
+// points.
+//
+// The result is a skeleton path; clients will have to call FindHobbyControls
+// to re-calculate the control points.
+func FromCubics(segs []CubicSegment) (*Path, error) {
+	path := Nullpath()
+	if len(segs) == 0 {
+		return path, nil
+	}
+	for i := 1; i < len(segs); i++ {
+		if !segs[i].P0.Equal(segs[i-1].P3) {
+			return path, fmt.Errorf("segment %d does not start at end of segment %d: %s ≠ %s",
+				i, i-1, segs[i].P0, segs[i-1].P3)
+		}
+	}
+	cycle := len(segs) > 1 && segs[len(segs)-1].P3.Equal(segs[0].P0)
+	for _, seg := range segs {
+		path.points = append(path.points, seg.P0)
+	}
+	if !cycle {
+		path.points = append(path.points, segs[len(segs)-1].P3)
+	}
+	for i, seg := range segs {
+		dirs, t1, t2, ok := hobbyParamsFromCubic(seg)
+		if !ok {
+			T().Debugf("import: segment %d needs explicit control points", i)
+			path.SetExplicitControls(i, seg.C1, seg.C2)
+			continue
+		}
+		path.SetPostDir(i, dirs[0])
+		path.SetPreDir(i+1, dirs[1])
+		if !arithm.Is0(t1 - 1.0) {
+			path.SetPostTension(i, t1)
+		}
+		if !arithm.Is0(t2 - 1.0) {
+			path.SetPreTension(i+1, t2)
+		}
+	}
+	if cycle {
+		path.Cycle()
+	}
+	return path, nil
+}
+
+// hobbyParamsFromCubic derives the directions at both ends of a segment and
+// the tensions which make Hobby's algorithm reproduce the segment.
+// Returns false if the segment cannot be expressed this way.
+func hobbyParamsFromCubic(seg CubicSegment) ([2]arithm.Pair, float64, float64, bool) {
+	var dirs [2]arithm.Pair
+	dvec := seg.P3 - seg.P0
+	v1, v2 := seg.C1-seg.P0, seg.P3-seg.C2
+	dlen, l1, l2 := cmplx.Abs(dvec.C()), cmplx.Abs(v1.C()), cmplx.Abs(v2.C())
+	if arithm.Is0(dlen) || arithm.Is0(l1) || arithm.Is0(l2) {
+		return dirs, 0, 0, false
+	}
+	theta := arithm.NormalizePi(angle(v1) - angle(dvec))
+	phi := arithm.NormalizePi(angle(dvec) - angle(v2))
+	rho, sigma := hobbyParamsRhoSigma(hobbyParamsAlphaBeta(theta, phi))
+	t1 := rho * dlen / (3 * l1)
+	t2 := sigma * dlen / (3 * l2)
+	if !isValidTension(t1) || !isValidTension(t2) {
+		return dirs, 0, 0, false
+	}
+	dirs[0], dirs[1] = v1, v2
+	return dirs, t1, t2, true
+}
+
+// isValidTension checks if a tension lies within the range accepted by
+// the tension setters, i.e. 3/4…4.
+func isValidTension(t float64) bool {
+	return !math.IsNaN(t) && t >= 0.75 && t <= 4.0
+}
@@ -0,0 +1,51 @@
+package jhobby
+
+import (
+	"sort"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Scanline Intersections ------------------------------------------------
+
+// IntersectHorizontal returns the points where a solved path crosses the
+// horizontal line at y. Crossing points are sorted by ascending x.
+// Typesetting clients may use this to wrap text around curved shapes or
+// to find cut positions at baselines.
+//
+// Control points which have not been calculated are treated as if the
+// corresponding segment were a straight line.
+func IntersectHorizontal(path HobbyPath, controls SplineControls, y float64) []arithm.Pair {
+	pts := scanline(path, controls, y, arithm.Pair.Y)
+	sort.Slice(pts, func(i, j int) bool { return pts[i].X() < pts[j].X() })
+	return pts
+}
+
+// IntersectVertical returns the points where a solved path crosses the
+// vertical line at x. Crossing points are sorted by ascending y.
+//
+// See IntersectHorizontal.
+func IntersectVertical(path HobbyPath, controls SplineControls, x float64) []arithm.Pair {
+	pts := scanline(path, controls, x, arithm.Pair.X)
+	sort.Slice(pts, func(i, j int) bool { return pts[i].Y() < pts[j].Y() })
+	return pts
+}
+
+// scanline intersects all segments of a path with the line coord(z) = at.
+// A crossing located exactly at a knot is reported once only.
+func scanline(path HobbyPath, controls SplineControls, at float64,
+	coord func(arithm.Pair) float64) []arithm.Pair {
+	//
+	var pts []arithm.Pair
+	segs := Segments(path, controls)
+	for i, seg := range segs {
+		a, b, c, d := seg.coeffs(coord)
+		for _, t := range cubicRoots(a, b, c, d-at) {
+			if t == 1 && (path.IsCycle() || i < len(segs)-1) {
+				continue // will be found as t = 0 of the next segment
+			}
+			pts = append(pts, seg.At(t))
+		}
+	}
+	return pts
+}
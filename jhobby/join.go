@@ -0,0 +1,105 @@
+package jhobby
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Joining Paths ---------------------------------------------------------
+
+// Join concatenates two open paths, merging the last knot of p1 with the
+// first knot of p2. The knots have to be within eps of each other; eps ≤ 0
+// selects the tolerance of p1 (see Path.SetEpsilon). The merged knot is
+// positioned at the last knot of p1.
+//
+// The merged knot carries the incoming parameters (direction, curl, tension,
+// explicit control point) of p1's last knot and the outgoing parameters of
+// p2's first knot. Contrary to Concat, the junction is not made a breakpoint:
+// if no directions or curls are given at the junction, the path is smoothed
+// across it, and a direction given on one side only applies to both sides,
+// as for every inner knot. A label or client data of p1's last knot takes
+// precedence over that of p2's first knot.
+//
+// Both paths remain unchanged. Join returns a new skeleton path, for which
+// clients will have to call FindHobbyControls. If one of the paths is empty,
+// a copy of the other one is returned. An error is returned if one of the
+// paths is cyclic, or if the ends of the paths do not meet.
+func Join(p1, p2 *Path, eps float64) (*Path, error) {
+	if p1 == nil || p2 == nil {
+		return nil, fmt.Errorf("cannot join nil path")
+	}
+	if p1.IsCycle() || p2.IsCycle() {
+		return nil, fmt.Errorf("cannot join cyclic paths")
+	}
+	if p2.N() == 0 {
+		return p1.skeleton(), nil
+	} else if p1.N() == 0 {
+		return p2.skeleton(), nil
+	}
+	if eps <= 0 {
+		eps = p1.epsilon()
+	}
+	k := p1.N() - 1
+	if d := cmplx.Abs((p2.Z(0) - p1.Z(k)).C()); d > eps {
+		return nil, fmt.Errorf("cannot join paths: knots %s and %s are %g apart",
+			ptstring(p1.Z(k), false), ptstring(p2.Z(0), false), d)
+	}
+	path := p1.skeleton()
+	path.truncateAt(k)
+	nan := arithm.Pair(cmplx.NaN())
+	if dir := p2.PostDir(0); !cmplx.IsNaN(dir.C()) {
+		path.SetPostDir(k, dir)
+	}
+	if hasGivenCurl(p2, 0, true) {
+		path.SetPostCurl(k, p2.PostCurl(0))
+	}
+	if t := p2.PostTension(0); t != 1.0 {
+		path.SetPostTension(k, t)
+	}
+	if c := p2.ExplicitPostControl(0); !cmplx.IsNaN(c.C()) {
+		path.expostc = extendC(path.expostc, k, nan)
+		path.expostc[k] = c
+	}
+	if getInfo(path.infos, k).empty() {
+		if info := getInfo(p2.infos, 0); !info.empty() {
+			path.infos = extendInfo(path.infos, k)
+			path.infos[k] = info
+		}
+	}
+	path.mergePreKnotParams(path.N(), p2, 1)
+	path.appendKnots(p2, 1)
+	return path, nil
+}
+
+// skeleton returns a copy of a path without calculated control points.
+func (path *Path) skeleton() *Path {
+	p := path.Clone()
+	p.Controls.prec, p.Controls.postc = nil, nil
+	return p
+}
+
+// truncateAt drops parameters stored beyond knot #k, e.g. by a trailing join
+// of an open path, and the outgoing parameters of knot #k.
+func (path *Path) truncateAt(k int) {
+	nan := arithm.Pair(cmplx.NaN())
+	path.predirs = truncC(path.predirs, k+1)
+	path.postdirs = truncC(path.postdirs, k+1)
+	path.curls = truncC(path.curls, k+1)
+	path.tensions = truncC(path.tensions, k+1)
+	path.exprec = truncC(path.exprec, k+1)
+	path.expostc = truncC(path.expostc, k+1)
+	if k < len(path.postdirs) {
+		path.postdirs[k] = nan
+	}
+	if k < len(path.curls) {
+		path.curls[k] = arithm.P(real(path.curls[k]), nocurl.Y())
+	}
+	if k < len(path.tensions) {
+		path.tensions[k] = arithm.P(real(path.tensions[k]), 1)
+	}
+	if k < len(path.expostc) {
+		path.expostc[k] = nan
+	}
+}
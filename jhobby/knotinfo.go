@@ -0,0 +1,128 @@
+package jhobby
+
+import (
+	"github.com/npillmayer/arithm"
+)
+
+// --- Knot Labels and Data --------------------------------------------------
+
+// DataKnots is an optional interface for HobbyPaths. Paths implementing it
+// carry arbitrary client data for each knot, e.g. source locations or
+// variable names of a DSL interpreter. The data is never used for solving
+// a path.
+type DataKnots interface {
+	KnotData(int) interface{} // client data for knot #i, or nil
+}
+
+var _ DataKnots = &Path{}
+var _ DataKnots = &pathPartial{}
+
+// knotInfo holds client information attached to a knot. It is not used for
+// solving a path.
+type knotInfo struct {
+	label string      // optional name of the knot
+	data  interface{} // optional client data
+}
+
+// KnotLabeled adds a standard smooth knot to a path and attaches a label to
+// it. Clients may later refer to the knot by its label instead of by its
+// index (see KnotByLabel).
+// Part of builder functionality.
+func (path *Path) KnotLabeled(p arithm.Pair, label string) JoinAdder {
+	if !path.checkPair(p, "knot") {
+		return path
+	}
+	path.appendKnot(p)
+	path.SetLabel(path.N()-1, label)
+	return path
+}
+
+// SetLabel attaches a label to knot #i, replacing a previous label. An
+// empty label removes the label from the knot. Labels are not required to
+// be unique. Knot indices of cyclic paths wrap around; negative indices of
+// open paths are ignored.
+//
+// Labels stick to their knots when editing, transforming or concatenating
+// paths.
+func (path *Path) SetLabel(i int, label string) *Path {
+	if i, ok := path.infoIndex(i); ok {
+		path.infos = extendInfo(path.infos, i)
+		path.infos[i].label = label
+	}
+	return path
+}
+
+// Label returns the label of knot #i, or "" if the knot is not labeled.
+func (path *Path) Label(i int) string {
+	i, _ = path.infoIndex(i)
+	return getInfo(path.infos, i).label
+}
+
+// KnotByLabel returns the index of the first knot with a given label.
+// Returns false if no such knot exists.
+func (path *Path) KnotByLabel(label string) (int, bool) {
+	if label == "" {
+		return -1, false
+	}
+	for i, info := range path.infos {
+		if info.label == label {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// SetKnotData attaches client data to knot #i, replacing previous data. As
+// with labels, data sticks to its knot when editing, transforming or
+// concatenating paths. Segments of a path handed to the solver delegate to
+// the data of the whole path.
+//
+// Client data is copied shallowly by Clone. It is included in the binary
+// encoding of a path using encoding/gob (see MarshalBinary).
+func (path *Path) SetKnotData(i int, v interface{}) *Path {
+	if i, ok := path.infoIndex(i); ok {
+		path.infos = extendInfo(path.infos, i)
+		path.infos[i].data = v
+	}
+	return path
+}
+
+// KnotData returns the client data attached to knot #i, or nil.
+//
+// Interface DataKnots.
+func (path *Path) KnotData(i int) interface{} {
+	i, _ = path.infoIndex(i)
+	return getInfo(path.infos, i).data
+}
+
+// infoIndex maps knot #i to its index within the knot information, wrapping
+// negative indices of cyclic paths as well. Returns false for indices which
+// do not denote a knot.
+func (path *Path) infoIndex(i int) (int, bool) {
+	if n := path.N(); path.cycle && n > 0 {
+		i = (i%n + n) % n
+	}
+	return i, i >= 0
+}
+
+func (info knotInfo) empty() bool {
+	return info.label == "" && info.data == nil
+}
+
+/* Extend an array/slice of knot information to make room for index i.
+ */
+func extendInfo(arr []knotInfo, i int) []knotInfo {
+	if i >= len(arr) {
+		arr = append(arr, make([]knotInfo, i-len(arr)+1)...)
+	}
+	return arr
+}
+
+/* Get the knot information at index i, if present, empty information otherwise.
+ */
+func getInfo(arr []knotInfo, i int) knotInfo {
+	if i < 0 || i >= len(arr) {
+		return knotInfo{}
+	}
+	return arr[i]
+}
@@ -0,0 +1,202 @@
+package jhobby
+
+import (
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Merging Coincident Knots ----------------------------------------------
+
+// WithMergeDuplicates lets the solver merge consecutive knots which are
+// closer than eps into a single knot. Knot data from digitizers frequently
+// contains duplicate points, which otherwise result in undefined (NaN)
+// control points, as the direction of a join of length 0 is undefined.
+//
+// Incoming parameters (direction, curl, tension, explicit control point) of a
+// merged knot are taken from the first knot of a group of duplicates with the
+// parameter given, outgoing parameters from the last one. The path itself is
+// not altered: control points are reported for the original knots, and the
+// joins between duplicates get control points identical to the knot.
+func WithMergeDuplicates(eps float64) SolveOption {
+	return func(conf *solveConfig) {
+		conf.mergeEps = eps
+	}
+}
+
+// mergedPath is a view of a path with groups of coincident consecutive knots
+// merged into single knots. It implements HobbyPath and SplineControls,
+// the latter mapping control points to the original knots.
+type mergedPath struct {
+	whole    HobbyPath
+	first    []int          // first knot of group #i (original index, unwrapped)
+	last     []int          // last knot of group #i (original index, unwrapped)
+	controls SplineControls // control points of the original path
+}
+
+var _ HobbyPath = &mergedPath{}
+var _ ExplicitControls = &mergedPath{}
+var _ RoughKnots = &mergedPath{}
+var _ SplineControls = &mergedPath{}
+
+// mergeDuplicates creates a merged view of a path. Returns nil if there are
+// no knots to merge, or if the merged path would be degenerate.
+func mergeDuplicates(path HobbyPath, eps float64) *mergedPath {
+	n := path.N()
+	if n < 2 {
+		return nil
+	}
+	coincide := func(i, j int) bool {
+		return cmplx.Abs((path.Z(i%n) - path.Z(j%n)).C()) < eps
+	}
+	start := 0
+	if path.IsCycle() { // start with a knot distinct from its predecessor
+		for start < n && coincide(start+n-1, start) {
+			start++
+		}
+		if start == n {
+			return nil
+		}
+	}
+	m := &mergedPath{whole: path}
+	for k := start; k < start+n; k++ {
+		if k > start && coincide(k-1, k) {
+			m.last[len(m.last)-1] = k
+			continue
+		}
+		m.first = append(m.first, k)
+		m.last = append(m.last, k)
+	}
+	if len(m.first) == n || len(m.first) < 2 {
+		return nil
+	}
+	return m
+}
+
+// fillDuplicates sets the control points of the joins between merged knots.
+func (m *mergedPath) fillDuplicates() {
+	n := m.whole.N()
+	for i := range m.first {
+		z := m.whole.Z(m.first[i] % n)
+		for k := m.first[i]; k < m.last[i]; k++ {
+			m.controls.SetPostControl(k%n, z)
+			m.controls.SetPreControl((k+1)%n, z)
+		}
+	}
+}
+
+func (m *mergedPath) mod(i int) int {
+	n := len(m.first)
+	return (i%n + n) % n
+}
+
+// pre returns the original indices of the knots of group #i, for incoming
+// parameters.
+func (m *mergedPath) pre(i int) []int {
+	i = m.mod(i)
+	n := m.whole.N()
+	var group []int
+	for k := m.first[i]; k <= m.last[i]; k++ {
+		group = append(group, k%n)
+	}
+	return group
+}
+
+// post returns the original indices of the knots of group #i in reverse
+// order, for outgoing parameters.
+func (m *mergedPath) post(i int) []int {
+	group := m.pre(i)
+	for l, r := 0, len(group)-1; l < r; l, r = l+1, r-1 {
+		group[l], group[r] = group[r], group[l]
+	}
+	return group
+}
+
+func (m *mergedPath) IsCycle() bool {
+	return m.whole.IsCycle()
+}
+
+func (m *mergedPath) N() int {
+	return len(m.first)
+}
+
+func (m *mergedPath) Z(i int) arithm.Pair {
+	return m.whole.Z(m.first[m.mod(i)] % m.whole.N())
+}
+
+func (m *mergedPath) PreDir(i int) arithm.Pair {
+	return m.firstPair(m.pre(i), m.whole.PreDir)
+}
+
+func (m *mergedPath) PostDir(i int) arithm.Pair {
+	return m.firstPair(m.post(i), m.whole.PostDir)
+}
+
+func (m *mergedPath) PreCurl(i int) float64 {
+	return m.firstNumber(m.pre(i), m.whole.PreCurl)
+}
+
+func (m *mergedPath) PostCurl(i int) float64 {
+	return m.firstNumber(m.post(i), m.whole.PostCurl)
+}
+
+func (m *mergedPath) PreTension(i int) float64 {
+	return m.firstNumber(m.pre(i), m.whole.PreTension)
+}
+
+func (m *mergedPath) PostTension(i int) float64 {
+	return m.firstNumber(m.post(i), m.whole.PostTension)
+}
+
+func (m *mergedPath) ExplicitPreControl(i int) arithm.Pair {
+	return explicitPreControl(m.whole, m.pre(i)[0])
+}
+
+func (m *mergedPath) ExplicitPostControl(i int) arithm.Pair {
+	return explicitPostControl(m.whole, m.post(i)[0])
+}
+
+func (m *mergedPath) IsRough(i int) bool {
+	for _, k := range m.pre(i) {
+		if isGivenRough(m.whole, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstPair returns the first given (non-NaN) value of a parameter.
+func (m *mergedPath) firstPair(group []int, param func(int) arithm.Pair) arithm.Pair {
+	for _, k := range group {
+		if v := param(k); !cmplx.IsNaN(v.C()) {
+			return v
+		}
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+// firstNumber returns the first non-default (≠ 1) value of a parameter.
+func (m *mergedPath) firstNumber(group []int, param func(int) float64) float64 {
+	for _, k := range group {
+		if v := param(k); v != 1.0 {
+			return v
+		}
+	}
+	return 1.0
+}
+
+func (m *mergedPath) SetPreControl(i int, c arithm.Pair) {
+	m.controls.SetPreControl(m.pre(i)[0], c)
+}
+
+func (m *mergedPath) SetPostControl(i int, c arithm.Pair) {
+	m.controls.SetPostControl(m.post(i)[0], c)
+}
+
+func (m *mergedPath) PreControl(i int) arithm.Pair {
+	return m.controls.PreControl(m.pre(i)[0])
+}
+
+func (m *mergedPath) PostControl(i int) arithm.Pair {
+	return m.controls.PostControl(m.post(i)[0])
+}
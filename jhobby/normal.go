@@ -0,0 +1,96 @@
+package jhobby
+
+import (
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Points and Normals on Paths -------------------------------------------
+
+// PointAt returns the point of a solved path at time t. Time is measured as
+// in MetaPost (see ArcLengthTable) and clamped to the time range of the
+// path; for cycles, t is taken modulo the number of segments.
+func PointAt(path HobbyPath, controls SplineControls, t float64) arithm.Pair {
+	seg, f, ok := segmentAtTime(path, controls, t)
+	if !ok {
+		if path.N() == 0 {
+			return arithm.Pair(cmplx.NaN())
+		}
+		return path.Z(0)
+	}
+	return seg.At(f)
+}
+
+// DirectionAt returns the unit tangent vector of a solved path at time t.
+// Where the tangent is undefined, e.g. at a knot coinciding with its control
+// point, the direction of the curve near t is used instead. Returns (0,0)
+// if no direction can be found.
+func DirectionAt(path HobbyPath, controls SplineControls, t float64) arithm.Pair {
+	seg, f, ok := segmentAtTime(path, controls, t)
+	if !ok {
+		return arithm.Origin
+	}
+	return seg.direction(f, epsilonOf(path))
+}
+
+// NormalAt returns the unit normal vector of a solved path at time t. The
+// normal points to the left of the direction of travel, i.e. it is the
+// tangent rotated by 90° counter-clockwise. For counter-clockwise cycles it
+// points to the inside.
+func NormalAt(path HobbyPath, controls SplineControls, t float64) arithm.Pair {
+	return leftNormal(DirectionAt(path, controls, t))
+}
+
+// OffsetPointAt returns the point at time t, displaced perpendicular to the
+// path by distance d. Positive values of d displace the point to the left
+// of the direction of travel (see NormalAt). Clients may use it to place
+// tick marks, serifs or annotations alongside a path.
+func OffsetPointAt(path HobbyPath, controls SplineControls, t, d float64) arithm.Pair {
+	return PointAt(path, controls, t) + NormalAt(path, controls, t).Scaled(d)
+}
+
+// segmentAtTime returns the segment of a path containing time t, together
+// with the time on the segment. Returns false for paths without segments.
+func segmentAtTime(path HobbyPath, controls SplineControls, t float64) (CubicSegment, float64, bool) {
+	n := path.N()
+	cnt := n - 1
+	if path.IsCycle() {
+		cnt = n
+	}
+	if cnt < 1 {
+		return CubicSegment{}, 0, false
+	}
+	if path.IsCycle() {
+		t -= float64(cnt) * float64(int(t/float64(cnt)))
+		if t < 0 {
+			t += float64(cnt)
+		}
+	}
+	if t <= 0 {
+		return segmentAt(path, controls, 0), 0, true
+	}
+	if t >= float64(cnt) {
+		return segmentAt(path, controls, cnt-1), 1, true
+	}
+	i := int(t)
+	return segmentAt(path, controls, i), t - float64(i), true
+}
+
+// direction returns the unit tangent of a segment at time t. If the first
+// derivative vanishes, the directions towards the next distinct Bézier point
+// are tried. Vectors shorter than eps are considered to vanish.
+func (seg CubicSegment) direction(t, eps float64) arithm.Pair {
+	candidates := []arithm.Pair{seg.derivative(t)}
+	if t < 0.5 {
+		candidates = append(candidates, seg.C2-seg.P0, seg.P3-seg.P0)
+	} else {
+		candidates = append(candidates, seg.P3-seg.C1, seg.P3-seg.P0)
+	}
+	for _, d := range candidates {
+		if l := cmplx.Abs(d.C()); l > eps {
+			return d.Scaled(1 / l)
+		}
+	}
+	return arithm.Origin
+}
@@ -0,0 +1,59 @@
+package jhobby
+
+// --- Orientation -----------------------------------------------------------
+
+// IsClockwise returns true if a path runs clockwise, in a coordinate system
+// with the y-axis pointing upwards (i.e., counter-clockwise as displayed in
+// a coordinate system with the y-axis pointing downwards, as for SVG).
+// Open paths are considered to be closed by a straight line.
+//
+// The orientation is found from the signed area enclosed by the path. For
+// self-intersecting paths, the orientation of the dominant part is reported.
+// Control points which have not been calculated are replaced by the adjacent
+// knots, i.e. the orientation of the polygon of knots is reported.
+func IsClockwise(path HobbyPath, controls SplineControls) bool {
+	return signedArea(path, controls) < 0
+}
+
+// WithOrientation returns a copy of a path, which for a cycle will run
+// counter-clockwise if ccw is true and clockwise otherwise (see IsClockwise).
+// Cycles with the wrong orientation are reversed (MetaPost: "reverse p"),
+// keeping the first knot. Open paths are copied unchanged.
+//
+// Use WithOrientation to get consistently wound input for operations which
+// depend on the orientation of paths, e.g. filling with the non-zero
+// winding rule or stroke outlines.
+func (path *Path) WithOrientation(ccw bool) *Path {
+	if path.cycle && IsClockwise(path, path.Controls) == ccw {
+		return path.reversed()
+	}
+	return path.Clone()
+}
+
+// signedArea returns the area enclosed by a path, positive if the path runs
+// counter-clockwise. Open paths are closed by a straight line.
+func signedArea(path HobbyPath, controls SplineControls) float64 {
+	if path.N() < 2 {
+		return 0
+	}
+	var a float64
+	for _, seg := range Segments(path, controls) {
+		a += seg.area()
+	}
+	if !path.IsCycle() {
+		a += path.Z(path.N()-1).Cross(path.Z(0)) / 2
+	}
+	return a
+}
+
+// area returns the integral ½∫(x⋅y' - y⋅x')dt over the segment, i.e. the
+// signed area between the segment and the origin. The integrand is a
+// polynomial of degree 5, thus Gauss-Legendre quadrature is exact.
+func (seg CubicSegment) area() float64 {
+	var a float64
+	for i, x := range gaussX {
+		t := (x + 1) / 2
+		a += gaussW[i] * seg.At(t).Cross(seg.derivative(t))
+	}
+	return a / 4 // ½ for the interval [0…1], ½ for the area
+}
@@ -6,7 +6,6 @@ import (
 	"math/cmplx"
 
 	"github.com/npillmayer/arithm"
-	"github.com/npillmayer/schuko/gconf"
 	"github.com/npillmayer/schuko/gtrace"
 	"github.com/npillmayer/schuko/tracing"
 )
@@ -18,7 +17,10 @@ func T() tracing.Trace {
 
 const pi float64 = 3.14159265
 const pi2 float64 = 6.28318530
-const _epsilon = 0.0000001
+const _epsilon = 0.0000001 // default for geometric tolerances
+
+// nocurl is stored for knots without an explicitly given curl.
+var nocurl = arithm.Pair(complex(math.NaN(), math.NaN()))
 
 // --- Interfaces ------------------------------------------------------------
 
@@ -77,6 +79,28 @@ type SplineControls interface {
 	SetPostControl(int, arithm.Pair) // set control point (after calculation)
 }
 
+// ExplicitControls is an optional interface for HobbyPaths. Paths implementing
+// it may carry explicitly given spline control points for some of their
+// joins (MetaFont: "z1 .. controls c1 and c2 .. z2"). Knots adjacent to
+// such a join are breakpoints for the solver, and the explicit control
+// points are copied verbatim to the SplineControls.
+//
+// Knots without explicit control points return NaN for the respective
+// control point.
+type ExplicitControls interface {
+	ExplicitPreControl(int) arithm.Pair  // explicit control point before knot #i
+	ExplicitPostControl(int) arithm.Pair // explicit control point after knot #i
+}
+
+// RoughKnots is an optional interface for HobbyPaths. Paths implementing it
+// may mark knots as breakpoints for the solver ("rough" knots), even if the
+// knots' parameters are identical to the default ones. This is the case
+// for MetaFont's "z1 -- z2", which is short for "z1{curl 1}..{curl 1}z2",
+// or for the junction of two concatenated paths ("p & q").
+type RoughKnots interface {
+	IsRough(int) bool // is knot #i a breakpoint?
+}
+
 // AsString returns
 // a path -- optionally including spline control points -- as a (debugging)
 // string. The string contains newlines if control point information is present.
@@ -116,6 +140,63 @@ func AsString(path HobbyPath, contr SplineControls) string {
 	return s
 }
 
+// Equal compares two paths, including their control points, up to a
+// tolerance of eps for every coordinate. Cyclic paths are considered equal
+// if they differ only in their starting knot. Control points which have
+// not been calculated (NaN) are only equal to uncalculated control points.
+// nil controls are treated as if all control points were uncalculated.
+func Equal(p1 HobbyPath, c1 SplineControls, p2 HobbyPath, c2 SplineControls, eps float64) bool {
+	if p1.N() != p2.N() || p1.IsCycle() != p2.IsCycle() {
+		return false
+	}
+	n := p1.N()
+	if !p1.IsCycle() {
+		return equalFrom(p1, c1, p2, c2, 0, eps)
+	}
+	for offset := 0; offset < n; offset++ {
+		if equalFrom(p1, c1, p2, c2, offset, eps) {
+			return true
+		}
+	}
+	return n == 0
+}
+
+// equalFrom compares knot #i of p1 to knot #(i+offset) mod N of p2, for all i.
+func equalFrom(p1 HobbyPath, c1 SplineControls, p2 HobbyPath, c2 SplineControls,
+	offset int, eps float64) bool {
+	//
+	n := p1.N()
+	for i := 0; i < n; i++ {
+		j := (i + offset) % n
+		if !equalPair(p1.Z(i), p2.Z(j), eps) {
+			return false
+		}
+		if !equalPair(controlOrNaN(c1, i, true), controlOrNaN(c2, j, true), eps) ||
+			!equalPair(controlOrNaN(c1, i, false), controlOrNaN(c2, j, false), eps) {
+			return false
+		}
+	}
+	return true
+}
+
+func controlOrNaN(controls SplineControls, i int, pre bool) arithm.Pair {
+	if controls == nil {
+		return arithm.Pair(cmplx.NaN())
+	} else if pre {
+		return controls.PreControl(i)
+	}
+	return controls.PostControl(i)
+}
+
+// equalPair compares two pairs coordinate-wise up to eps. NaN pairs are equal
+// to each other.
+func equalPair(a, b arithm.Pair, eps float64) bool {
+	if cmplx.IsNaN(a.C()) || cmplx.IsNaN(b.C()) {
+		return cmplx.IsNaN(a.C()) && cmplx.IsNaN(b.C())
+	}
+	return math.Abs(a.X()-b.X()) <= eps && math.Abs(a.Y()-b.Y()) <= eps
+}
+
 // --- Implementation --------------------------------------------------------
 
 // Path is a concrete implementation of interface HobbyPath.
@@ -126,9 +207,18 @@ type Path struct {
 	cycle    bool          // is this path cyclic ?
 	predirs  []arithm.Pair // explicit pre-direction at point i
 	postdirs []arithm.Pair // explicit post-direction at point i
-	curls    []arithm.Pair // explicit l and r curl at point i
+	curls    []arithm.Pair // explicit l and r curl at point i, NaN if not given
 	tensions []arithm.Pair // explicit pre- and post-tension at point i
+	exprec   []arithm.Pair // explicit control point i-
+	expostc  []arithm.Pair // explicit control point i+
+	infos    []knotInfo    // client information for point i, e.g. labels
+	eps      float64       // geometric tolerance, 0 for default
+	g2       bool          // solve for curvature continuity
+	arc      *svgArc       // pending arc join, waiting for its end knot
+	build    buildState    // last builder call, for structural validation
+	err      error         // first builder error
 	Controls *splcntrls    // control points to be calculated
+	checked  bool          // collect builder errors instead of panicking
 }
 
 // A segment of a path; will implement interface HobbyPath
@@ -141,11 +231,14 @@ type pathPartial struct {
 
 // Sub-type for collecting the calculated spline control points
 type splcntrls struct {
-	prec  []arithm.Pair // control point i-, to be calculated
-	postc []arithm.Pair // control point i+, to be calculated
+	prec    []arithm.Pair // control point i-, to be calculated
+	postc   []arithm.Pair // control point i+, to be calculated
+	path    HobbyPath     // path the control points have been calculated for
+	version uint64        // incremented with every change
 }
 
 var _ HobbyPath = &Path{}
+var _ ExplicitControls = &Path{}
 var _ SplineControls = &splcntrls{}
 var _ SplineControls = &pathPartial{}
 
@@ -163,7 +256,7 @@ func newSkeletonPath(points []arithm.Pair) *Path {
 	for i, pt := range points {
 		path.points[i] = pt // TODO: initialize all arrays
 	}
-	path.Controls = &splcntrls{}
+	path.Controls = &splcntrls{path: path}
 	return path
 }
 
@@ -178,10 +271,15 @@ func newSkeletonPath(points []arithm.Pair) *Path {
 type KnotAdder interface {
 	Knot(arithm.Pair) JoinAdder
 	SmoothKnot(arithm.Pair) JoinAdder
+	KnotLabeled(pr arithm.Pair, label string) JoinAdder
 	CurlKnot(pr arithm.Pair, precurl, postcurl float64) JoinAdder
 	DirKnot(pr arithm.Pair, dir arithm.Pair) JoinAdder
+	PreDirKnot(pr arithm.Pair, dir arithm.Pair) JoinAdder
+	PostDirKnot(pr arithm.Pair, dir arithm.Pair) JoinAdder
+	CornerKnot(pr arithm.Pair, indir, outdir arithm.Pair) JoinAdder
 	AppendSubpath(sp *Path) JoinAdder
 	Cycle() (HobbyPath, SplineControls)
+	Err() error
 }
 
 // JoinAdder is an interface for helping control the construction of a path. It is used
@@ -193,7 +291,13 @@ type JoinAdder interface {
 	Line() KnotAdder
 	Curve() KnotAdder
 	TensionCurve(t1, t2 float64) KnotAdder
+	BoundedCurve() KnotAdder
+	TensionAtLeastCurve(t1, t2 float64) KnotAdder
+	ExplicitCurve(c1, c2 arithm.Pair) KnotAdder
+	ArcJoin(rx, ry, rotation float64, largeArc, sweep bool) KnotAdder
+	Concat(sp *Path) JoinAdder
 	End() (HobbyPath, SplineControls)
+	Err() error
 }
 
 var _ KnotAdder = &Path{}
@@ -214,6 +318,62 @@ func Nullpath() *Path {
 	return newSkeletonPath(nil)
 }
 
+// CheckedNullpath creates an empty path in error-collecting mode. Builder
+// calls which would panic for paths created by Nullpath() (e.g., adding a
+// join to an empty path) will instead record an error and otherwise be
+// ignored. Knots, directions and tensions with non-finite values are
+// reported as errors, too. This is intended for constructing paths from
+// untrusted input.
+//
+// Clients check for errors by calling Err() after End() or Cycle():
+//
+//     path := CheckedNullpath()
+//     path.Knot(P(0,0)).Curve().Knot(P(x,y)).End()
+//     if err := path.Err(); err != nil {
+//         ...
+//     }
+//
+func CheckedNullpath() *Path {
+	path := newSkeletonPath(nil)
+	path.checked = true
+	return path
+}
+
+// Err returns the first error which occurred during building a path. Errors
+// are invalid arguments to builder functions, which are recorded for paths
+// created by CheckedNullpath() only (other paths panic), and errors in the
+// structure of a path (see Validate), which are recorded for every path.
+func (path *Path) Err() error {
+	return path.err
+}
+
+// fail handles builder misuse. Panics for paths in non-checked mode,
+// otherwise records the first error.
+func (path *Path) fail(msg string) {
+	if !path.checked {
+		panic(msg)
+	}
+	path.misused(msg)
+}
+
+// checkPair checks a pair for being finite, if the path is in checked mode.
+func (path *Path) checkPair(pr arithm.Pair, what string) bool {
+	if path.checked && (cmplx.IsNaN(pr.C()) || cmplx.IsInf(pr.C())) {
+		path.fail(fmt.Sprintf("%s is not finite: %v", what, pr))
+		return false
+	}
+	return true
+}
+
+// checkNumber checks a number for being finite, if the path is in checked mode.
+func (path *Path) checkNumber(x float64, what string) bool {
+	if path.checked && (math.IsNaN(x) || math.IsInf(x, 0)) {
+		path.fail(fmt.Sprintf("%s is not finite: %g", what, x))
+		return false
+	}
+	return true
+}
+
 // End an open path. Part of builder functionality.
 func (path *Path) End() (HobbyPath, SplineControls) {
 	return path, path.Controls
@@ -221,10 +381,44 @@ func (path *Path) End() (HobbyPath, SplineControls) {
 
 // Cycle closes a cyclic path. Part of builder functionality.
 func (path *Path) Cycle() (HobbyPath, SplineControls) {
+	if path.N() > 0 {
+		path.resolveArc(path.Z(0))
+	}
+	path.noteCycle()
 	path.cycle = true
+	path.foldCycle()
 	return path, path.Controls
 }
 
+// foldCycle moves parameters of the closing join to the first knot. The
+// builder stores parameters for the join after the last knot at position N,
+// but for a cycle knot N is identical to knot 0.
+func (path *Path) foldCycle() {
+	n := path.N()
+	if n == 0 {
+		return
+	}
+	if dir := getC(path.predirs, n, arithm.Pair(cmplx.NaN())); !cmplx.IsNaN(dir.C()) {
+		path.SetPreDir(0, dir)
+	}
+	if c := getC(path.curls, n, nocurl); !math.IsNaN(real(c)) {
+		path.SetPreCurl(0, real(c))
+	}
+	if t := getC(path.tensions, n, 1+1i); real(t) != 1.0 {
+		path.SetPreTension(0, real(t))
+	}
+	if c := getC(path.exprec, n, arithm.Pair(cmplx.NaN())); !cmplx.IsNaN(c.C()) {
+		path.exprec = extendC(path.exprec, 0, arithm.Pair(cmplx.NaN()))
+		path.exprec[0] = c
+	}
+	path.predirs = truncC(path.predirs, n)
+	path.postdirs = truncC(path.postdirs, n)
+	path.curls = truncC(path.curls, n)
+	path.tensions = truncC(path.tensions, n)
+	path.exprec = truncC(path.exprec, n)
+	path.expostc = truncC(path.expostc, n)
+}
+
 // Knot adds a standard smooth knot to a path. Part of builder functionality.
 func (path *Path) Knot(pr arithm.Pair) JoinAdder {
 	return path.SmoothKnot(pr)
@@ -233,7 +427,10 @@ func (path *Path) Knot(pr arithm.Pair) JoinAdder {
 // SmoothKnot adds a standard smooth knot to a path (same as Knot(pr)).
 // Part of builder functionality.
 func (path *Path) SmoothKnot(p arithm.Pair) JoinAdder {
-	path.points = append(path.points, p)
+	if !path.checkPair(p, "knot") {
+		return path
+	}
+	path.appendKnot(p)
 	return path
 }
 
@@ -241,7 +438,11 @@ func (path *Path) SmoothKnot(p arithm.Pair) JoinAdder {
 // post-curl. A curl value of 1.0 is considered neutral.
 // Part of builder functionality.
 func (path *Path) CurlKnot(p arithm.Pair, precurl, postcurl float64) JoinAdder {
-	path.points = append(path.points, p)
+	if !path.checkPair(p, "knot") || !path.checkNumber(precurl, "curl") ||
+		!path.checkNumber(postcurl, "curl") {
+		return path
+	}
+	path.appendKnot(p)
 	path.SetPreCurl(path.N()-1, precurl)
 	path.SetPostCurl(path.N()-1, postcurl)
 	return path
@@ -250,20 +451,67 @@ func (path *Path) CurlKnot(p arithm.Pair, precurl, postcurl float64) JoinAdder {
 // DirKnot adds a knot with a given tangent direction.
 // Part of builder functionality.
 func (path *Path) DirKnot(p arithm.Pair, dir arithm.Pair) JoinAdder {
-	path.points = append(path.points, p)
+	if !path.checkPair(p, "knot") || !path.checkPair(dir, "direction") {
+		return path
+	}
+	path.appendKnot(p)
 	path.SetPreDir(path.N()-1, dir)
 	path.SetPostDir(path.N()-1, dir)
 	return path
 }
 
-// Line connects two knots with a straight line.
+// PreDirKnot adds a knot with a given incoming tangent direction.
+// As with MetaFont, the direction applies to the outgoing side as well,
+// unless the knot is an end point of the path.
+// Part of builder functionality.
+func (path *Path) PreDirKnot(p arithm.Pair, dir arithm.Pair) JoinAdder {
+	if !path.checkPair(p, "knot") || !path.checkPair(dir, "direction") {
+		return path
+	}
+	path.appendKnot(p)
+	path.SetPreDir(path.N()-1, dir)
+	return path
+}
+
+// PostDirKnot adds a knot with a given outgoing tangent direction.
+// As with MetaFont, the direction applies to the incoming side as well,
+// unless the knot is an end point of the path.
+// Part of builder functionality.
+func (path *Path) PostDirKnot(p arithm.Pair, dir arithm.Pair) JoinAdder {
+	if !path.checkPair(p, "knot") || !path.checkPair(dir, "direction") {
+		return path
+	}
+	path.appendKnot(p)
+	path.SetPostDir(path.N()-1, dir)
+	return path
+}
+
+// CornerKnot adds a knot with distinct incoming and outgoing tangent
+// directions, i.e. a corner, similar to MetaFont's {indir}z{outdir}.
+// Part of builder functionality.
+func (path *Path) CornerKnot(p arithm.Pair, indir, outdir arithm.Pair) JoinAdder {
+	if !path.checkPair(p, "knot") || !path.checkPair(indir, "direction") ||
+		!path.checkPair(outdir, "direction") {
+		return path
+	}
+	path.appendKnot(p)
+	path.SetPreDir(path.N()-1, indir)
+	path.SetPostDir(path.N()-1, outdir)
+	return path
+}
+
+// Line connects two knots with a straight line. As in MetaFont, where
+// "z1 -- z2" is short for "z1{curl 1}..{curl 1}z2", both knots get an
+// explicit curl of 1 and therefore become breakpoints for the solver.
 // Part of builder functionality.
 func (path *Path) Line() KnotAdder {
 	if path.N() == 0 {
-		panic("cannot add line to empty path")
+		path.fail("cannot add line to empty path")
+		return path
 	}
 	path.SetPostCurl(path.N()-1, 1.0)
 	path.SetPreCurl(path.N(), 1.0)
+	path.noteJoin()
 	return path
 }
 
@@ -271,7 +519,8 @@ func (path *Path) Line() KnotAdder {
 // Part of builder functionality.
 func (path *Path) Curve() KnotAdder {
 	if path.N() == 0 {
-		panic("cannot add curve to empty path")
+		path.fail("cannot add curve to empty path")
+		return path
 	}
 	path.TensionCurve(1.0, 1.0)
 	return path
@@ -282,12 +531,15 @@ func (path *Path) Curve() KnotAdder {
 //
 // Tensions are adapted to lie between 3/4 and 4 (absolute).  Negative tensions
 // are interpreted as "at least" tensions to ensure the spline stays within
-// the bounding box at its control point.
-//
-// BUG(norbert@pillmayer.com): Tension spec "at least" currently not completely implemented.
+// the bounding box at its control point. Use TensionAtLeastCurve to state
+// this explicitly.
 func (path *Path) TensionCurve(t1, t2 float64) KnotAdder {
 	if path.N() == 0 {
-		panic("cannot add curve to empty path")
+		path.fail("cannot add curve to empty path")
+		return path
+	}
+	if !path.checkNumber(t1, "tension") || !path.checkNumber(t2, "tension") {
+		return path
 	}
 	if t1 != 1.0 {
 		path.SetPostTension(path.N()-1, t1)
@@ -295,16 +547,156 @@ func (path *Path) TensionCurve(t1, t2 float64) KnotAdder {
 	if t2 != 1.0 {
 		path.SetPreTension(path.N(), t2)
 	}
+	path.noteJoin()
+	return path
+}
+
+// BoundedCurve connects two knots with a curve which stays within the
+// triangle formed by the two knots and the intersection of their tangents,
+// if possible. It corresponds to MetaPost's "..." operator, i.e., to a
+// tension of "at least 1" on both sides.
+// Part of builder functionality.
+func (path *Path) BoundedCurve() KnotAdder {
+	return path.TensionAtLeastCurve(1.0, 1.0)
+}
+
+// TensionAtLeastCurve connects two knots with a curve of tensions "at least
+// t1" and "at least t2" (MetaFont: "..tension atleast t1 and atleast t2..").
+// The tensions will be increased where necessary to keep the curve within
+// the triangle formed by the two knots and the intersection of their
+// tangents. The sign of t1 and t2 is ignored; tensions are adapted to lie
+// between 3/4 and 4, as with TensionCurve.
+// Part of builder functionality.
+func (path *Path) TensionAtLeastCurve(t1, t2 float64) KnotAdder {
+	return path.TensionCurve(-math.Abs(t1), -math.Abs(t2))
+}
+
+// ExplicitCurve connects two knots with a curve with explicitly given
+// control points c1 and c2 (MetaFont: "z1 .. controls c1 and c2 .. z2").
+// Part of builder functionality.
+func (path *Path) ExplicitCurve(c1, c2 arithm.Pair) KnotAdder {
+	if path.N() == 0 {
+		path.fail("cannot add curve to empty path")
+		return path
+	}
+	if !path.checkPair(c1, "control point") || !path.checkPair(c2, "control point") {
+		return path
+	}
+	path.SetExplicitControls(path.N()-1, c1, c2)
+	path.noteJoin()
 	return path
 }
 
-// AppendSubpath concatenates two paths at an overlapping knot.
+// AppendSubpath appends the knots of another path, together with their
+// parameters and the joins between them. The join preceding the call will
+// connect to the first knot of sp. sp must not be cyclic; it is not altered.
 // Part of builder functionality.
 func (path *Path) AppendSubpath(sp *Path) JoinAdder {
-	T().Errorf("AppendSubpath not yet implemented")
+	if sp == nil || sp.N() == 0 || sp.IsCycle() {
+		path.fail("can only append an open, non-empty subpath")
+		return path
+	}
+	path.resolveArc(sp.Z(0))
+	path.noteKnot()
+	path.mergePreKnotParams(path.N(), sp, 0)
+	path.appendKnots(sp, 0)
+	return path
+}
+
+// Concat concatenates another path at a knot common to both paths, i.e., the
+// first knot of sp has to coincide with the last knot of the path. Contrary
+// to AppendSubpath, the paths will not be smoothed across the junction. The
+// common knot will rather be a breakpoint, keeping the incoming parameters of
+// the path and the outgoing parameters of sp (MetaPost: "p & q").
+// sp must not be cyclic; it is not altered.
+// Part of builder functionality.
+func (path *Path) Concat(sp *Path) JoinAdder {
+	if path.N() == 0 {
+		path.fail("cannot concatenate to empty path")
+		return path
+	}
+	if sp == nil || sp.N() == 0 || sp.IsCycle() {
+		path.fail("can only concatenate an open, non-empty subpath")
+		return path
+	}
+	k := path.N() - 1
+	if cmplx.Abs((sp.Z(0) - path.Z(k)).C()) > path.epsilon() {
+		path.fail(fmt.Sprintf("cannot concatenate paths: knots %s and %s do not coincide",
+			ptstring(path.Z(k), false), ptstring(sp.Z(0), false)))
+		return path
+	}
+	path.noteConcat()
+	if dir := sp.PostDir(0); !cmplx.IsNaN(dir.C()) {
+		path.SetPostDir(k, dir)
+	}
+	path.SetPostCurl(k, sp.PostCurl(0)) // marks junction as rough
+	if math.IsNaN(real(getC(path.curls, k, nocurl))) {
+		path.SetPreCurl(k, 1.0)
+	}
+	path.tensions = extendC(path.tensions, k, 1+1i)
+	path.tensions[k] = arithm.P(real(path.tensions[k]), sp.PostTension(0))
+	if c := sp.ExplicitPostControl(0); !cmplx.IsNaN(c.C()) {
+		path.expostc = extendC(path.expostc, k, arithm.Pair(cmplx.NaN()))
+		path.expostc[k] = c
+	}
+	if path.Label(k) == "" && sp.Label(0) != "" {
+		path.SetLabel(k, sp.Label(0))
+	}
+	path.mergePreKnotParams(path.N(), sp, 1)
+	path.appendKnots(sp, 1)
 	return path
 }
 
+// appendKnots appends knots #from…N-1 of sp, together with their parameters.
+// Incoming parameters of sp's knot #from are merged with parameters already
+// stored at position N of the path, i.e. by a preceding join.
+func (path *Path) appendKnots(sp *Path, from int) {
+	nan := arithm.Pair(cmplx.NaN())
+	for i := from; i < sp.N(); i++ {
+		j := path.N()
+		path.points = append(path.points, sp.Z(i))
+		if info := getInfo(sp.infos, i); !info.empty() {
+			path.infos = extendInfo(path.infos, j)
+			path.infos[j] = info
+		}
+		if i > from {
+			path.mergePreKnotParams(j, sp, i)
+		}
+		if dir := getC(sp.postdirs, i, nan); !cmplx.IsNaN(dir.C()) {
+			path.SetPostDir(j, dir)
+		}
+		if c := getC(sp.curls, i, nocurl); !math.IsNaN(imag(c)) {
+			path.SetPostCurl(j, imag(c))
+		}
+		if t := getC(sp.tensions, i, 1+1i); imag(t) != 1.0 {
+			path.SetPostTension(j, imag(t))
+		}
+		if c := getC(sp.expostc, i, nan); !cmplx.IsNaN(c.C()) {
+			path.expostc = extendC(path.expostc, j, nan)
+			path.expostc[j] = c
+		}
+	}
+}
+
+// mergePreKnotParams copies the incoming parameters of sp's knot #i to
+// position j of the path, if they are explicitly given.
+func (path *Path) mergePreKnotParams(j int, sp *Path, i int) {
+	nan := arithm.Pair(cmplx.NaN())
+	if dir := getC(sp.predirs, i, nan); !cmplx.IsNaN(dir.C()) {
+		path.SetPreDir(j, dir)
+	}
+	if c := getC(sp.curls, i, nocurl); !math.IsNaN(real(c)) {
+		path.SetPreCurl(j, real(c))
+	}
+	if t := getC(sp.tensions, i, 1+1i); real(t) != 1.0 {
+		path.SetPreTension(j, real(t))
+	}
+	if c := getC(sp.exprec, i, nan); !cmplx.IsNaN(c.C()) {
+		path.exprec = extendC(path.exprec, j, nan)
+		path.exprec[j] = c
+	}
+}
+
 // --- Setting Path Properties -----------------------------------------------
 
 // SetPreDir is a property setter.
@@ -323,7 +715,7 @@ func (path *Path) SetPostDir(i int, dir arithm.Pair) *Path {
 
 // SetPreCurl is a property setter.
 func (path *Path) SetPreCurl(i int, curl float64) *Path {
-	path.curls = extendC(path.curls, i, 1+1i)
+	path.curls = extendC(path.curls, i, nocurl)
 	c := path.curls[i]
 	post := imag(c)
 	path.curls[i] = arithm.P(curl, post)
@@ -332,8 +724,7 @@ func (path *Path) SetPreCurl(i int, curl float64) *Path {
 
 // SetPostCurl is a property setter.
 func (path *Path) SetPostCurl(i int, curl float64) *Path {
-	path.curls = extendC(path.curls, i, 1+1i)
-	//fmt.Printf("i = %d, len(path.curls) = %d\n", i, len(path.curls))
+	path.curls = extendC(path.curls, i, nocurl)
 	c := path.curls[i]
 	pre := real(c)
 	path.curls[i] = arithm.P(pre, curl)
@@ -349,13 +740,7 @@ func (path *Path) SetPreTension(i int, tension float64) *Path {
 	path.tensions = extendC(path.tensions, i, 1+1i)
 	t := path.tensions[i]
 	post := imag(t)
-	pretension := tension
-	if pretension < 0.75 {
-		pretension = 0.75
-	} else if pretension > 4.0 {
-		pretension = 4.0
-	}
-	path.tensions[i] = arithm.P(pretension, post)
+	path.tensions[i] = arithm.P(clampTension(tension), post)
 	return path
 }
 
@@ -368,16 +753,74 @@ func (path *Path) SetPostTension(i int, tension float64) *Path {
 	path.tensions = extendC(path.tensions, i, 1+1i)
 	t := path.tensions[i]
 	pre := real(t)
-	posttension := tension
-	if posttension < 0.75 {
-		posttension = 0.75
-	} else if posttension > 4.0 {
-		posttension = 4.0
-	}
-	path.tensions[i] = arithm.P(pre, posttension)
+	path.tensions[i] = arithm.P(pre, clampTension(tension))
+	return path
+}
+
+// SetExplicitControls sets explicit spline control points for the join
+// between knot i and knot i+1. c1 is the control point after knot i, c2 the
+// one before knot i+1.
+func (path *Path) SetExplicitControls(i int, c1, c2 arithm.Pair) *Path {
+	path.expostc = extendC(path.expostc, i, arithm.Pair(cmplx.NaN()))
+	path.expostc[i] = c1
+	path.exprec = extendC(path.exprec, i+1, arithm.Pair(cmplx.NaN()))
+	path.exprec[i+1] = c2
+	return path
+}
+
+// SetEpsilon sets the geometric tolerance for a path. Distances below eps are
+// considered to be zero, e.g., when checking if knots coincide or when
+// calculating curvatures. The tolerance should be chosen according to the
+// units of the path's coordinates: paths in font units (thousands) need a
+// larger tolerance than paths in points (single digits). eps ≤ 0 selects the
+// default tolerance of 1e-7.
+//
+// The tolerance of a path is used for solving it, unless overridden by
+// solve option WithEpsilon.
+func (path *Path) SetEpsilon(eps float64) *Path {
+	path.eps = eps
+	return path
+}
+
+// SetCurvatureContinuity selects solving a path for continuous curvature
+// (G2) at its smooth knots. It is the per-path equivalent of solve option
+// WithCurvatureContinuity, for paths where G2 continuity matters, e.g.
+// glyph outlines, independent of the code calling FindHobbyControls.
+func (path *Path) SetCurvatureContinuity(g2 bool) *Path {
+	path.g2 = g2
 	return path
 }
 
+// CurvatureContinuity is a predicate: is the path solved for continuous
+// curvature (see SetCurvatureContinuity)?
+func (path *Path) CurvatureContinuity() bool {
+	return path.g2
+}
+
+// Epsilon returns the geometric tolerance of a path (see SetEpsilon).
+func (path *Path) Epsilon() float64 {
+	return path.epsilon()
+}
+
+func (path *Path) epsilon() float64 {
+	if path.eps <= 0 {
+		return _epsilon
+	}
+	return path.eps
+}
+
+// epsilonOf returns the geometric tolerance of a path (see SetEpsilon), or
+// the default tolerance for implementations of HobbyPath without one.
+func epsilonOf(path HobbyPath) float64 {
+	switch p := path.(type) {
+	case *Path:
+		return p.epsilon()
+	case *pathPartial:
+		return epsilonOf(p.whole)
+	}
+	return _epsilon
+}
+
 // === Interface Implementation ==============================================
 
 // IsCycle is a predicate: is this path cyclic?
@@ -406,25 +849,36 @@ func (path *Path) Z(i int) arithm.Pair {
 	return z
 }
 
+// cyc maps index i to i mod N for cyclic paths. Open paths use i unchanged.
+func (path *Path) cyc(i int) int {
+	if path.cycle && path.N() > 0 {
+		i %= path.N()
+	}
+	return i
+}
+
 // PreDir gets the incoming tangent / direction vector at z.i .
 //
 // Interface HobbyPath.
 func (path *Path) PreDir(i int) arithm.Pair {
-	return getC(path.predirs, i, arithm.Pair(cmplx.NaN()))
+	return getC(path.predirs, path.cyc(i), arithm.Pair(cmplx.NaN()))
 }
 
 // PostDir gets the outgoing tangent / direction vector at z.i .
 //
 // Interface HobbyPath.
 func (path *Path) PostDir(i int) arithm.Pair {
-	return getC(path.postdirs, i, arithm.Pair(cmplx.NaN()))
+	return getC(path.postdirs, path.cyc(i), arithm.Pair(cmplx.NaN()))
 }
 
 // PreCurl gets the curl before z.i.
 //
 // Interface HobbyPath.
 func (path *Path) PreCurl(i int) float64 {
-	c := getC(path.curls, i, 1+1i)
+	c := getC(path.curls, path.cyc(i), nocurl)
+	if math.IsNaN(real(c)) {
+		return 1.0
+	}
 	return real(c)
 }
 
@@ -432,15 +886,27 @@ func (path *Path) PreCurl(i int) float64 {
 //
 // Interface HobbyPath.
 func (path *Path) PostCurl(i int) float64 {
-	c := getC(path.curls, i, 1+1i)
+	c := getC(path.curls, path.cyc(i), nocurl)
+	if math.IsNaN(imag(c)) {
+		return 1.0
+	}
 	return imag(c)
 }
 
+// IsRough is true if z.i has an explicitly given curl. Knots with curls are
+// breakpoints for the solver, even if the curl is 1.
+//
+// Interface RoughKnots.
+func (path *Path) IsRough(i int) bool {
+	c := getC(path.curls, path.cyc(i), nocurl)
+	return !math.IsNaN(real(c)) || !math.IsNaN(imag(c))
+}
+
 // PreTension returns the tension before z.i.
 //
 // Interface HobbyPath.
 func (path *Path) PreTension(i int) float64 {
-	t := getC(path.tensions, i, 1+1i)
+	t := getC(path.tensions, path.cyc(i), 1+1i)
 	return real(t)
 }
 
@@ -448,12 +914,42 @@ func (path *Path) PreTension(i int) float64 {
 //
 // Interface HobbyPath.
 func (path *Path) PostTension(i int) float64 {
-	t := getC(path.tensions, i, 1+1i)
+	t := getC(path.tensions, path.cyc(i), 1+1i)
 	return imag(t)
 }
 
+// ExplicitPreControl returns the explicit control point before z.i, if any.
+// Returns NaN otherwise.
+//
+// Interface ExplicitControls.
+func (path *Path) ExplicitPreControl(i int) arithm.Pair {
+	return getC(path.exprec, path.cyc(i), arithm.Pair(cmplx.NaN()))
+}
+
+// ExplicitPostControl returns the explicit control point after z.i, if any.
+// Returns NaN otherwise.
+//
+// Interface ExplicitControls.
+func (path *Path) ExplicitPostControl(i int) arithm.Pair {
+	return getC(path.expostc, path.cyc(i), arithm.Pair(cmplx.NaN()))
+}
+
 // --- Segments --------------------------------------------------------------
 
+// contains checks if knot #k of the parent path (of length n) is part of
+// this segment.
+func (pp *pathPartial) contains(k, n int) bool {
+	if n == 0 || k < 0 || k >= n {
+		return false
+	}
+	for m := pp.start; m <= pp.end; m++ {
+		if m%n == k {
+			return true
+		}
+	}
+	return false
+}
+
 func (pp *pathPartial) IsCycle() bool {
 	return pp.whole.IsCycle() && pp.whole.N() == pp.N()
 }
@@ -464,9 +960,25 @@ func (pp *pathPartial) N() int {
 
 func (pp *pathPartial) pmap(i int) int {
 	i = i%pp.N() + pp.start
+	if pp.whole.IsCycle() {
+		i %= pp.whole.N()
+	}
 	return i
 }
 
+// KnotData delegates to the parent path, if it implements DataKnots.
+//
+// Interface DataKnots.
+func (pp *pathPartial) KnotData(i int) interface{} {
+	if d, ok := pp.whole.(DataKnots); ok {
+		if pp.IsCycle() {
+			return d.KnotData(i)
+		}
+		return d.KnotData(pp.pmap(i))
+	}
+	return nil
+}
+
 func (pp *pathPartial) Z(i int) arithm.Pair {
 	if pp.IsCycle() {
 		return pp.whole.Z(i)
@@ -474,12 +986,48 @@ func (pp *pathPartial) Z(i int) arithm.Pair {
 	return pp.whole.Z(pp.pmap(i))
 }
 
+// PreDir returns the incoming direction at a knot. As in MetaFont, a
+// direction given at only one side of an inner knot applies to both sides.
+// If a knot is followed by a join with explicit control points, the
+// direction is derived from the first control point, unless a curl is
+// given for the incoming side.
 func (pp *pathPartial) PreDir(i int) arithm.Pair {
-	return pp.whole.PreDir(pp.pmap(i))
+	j := pp.pmap(i)
+	if dir := pp.whole.PreDir(j); !cmplx.IsNaN(dir.C()) {
+		return dir
+	}
+	if isInnerKnot(pp.whole, j) {
+		if dir := pp.whole.PostDir(j); !cmplx.IsNaN(dir.C()) {
+			return dir
+		}
+	}
+	if c := explicitPostControl(pp.whole, j); !cmplx.IsNaN(c.C()) && c != pp.whole.Z(j) &&
+		!hasGivenCurl(pp.whole, j, false) {
+		return c - pp.whole.Z(j)
+	}
+	return arithm.Pair(cmplx.NaN())
 }
 
+// PostDir returns the outgoing direction at a knot. As in MetaFont, a
+// direction given at only one side of an inner knot applies to both sides.
+// If a knot is preceded by a join with explicit control points, the
+// direction is derived from the second control point, unless a curl is
+// given for the outgoing side.
 func (pp *pathPartial) PostDir(i int) arithm.Pair {
-	return pp.whole.PostDir(pp.pmap(i))
+	j := pp.pmap(i)
+	if dir := pp.whole.PostDir(j); !cmplx.IsNaN(dir.C()) {
+		return dir
+	}
+	if isInnerKnot(pp.whole, j) {
+		if dir := pp.whole.PreDir(j); !cmplx.IsNaN(dir.C()) {
+			return dir
+		}
+	}
+	if c := explicitPreControl(pp.whole, j); !cmplx.IsNaN(c.C()) && c != pp.whole.Z(j) &&
+		!hasGivenCurl(pp.whole, j, true) {
+		return pp.whole.Z(j) - c
+	}
+	return arithm.Pair(cmplx.NaN())
 }
 
 func (pp *pathPartial) PreCurl(i int) float64 {
@@ -516,11 +1064,10 @@ func (pp *pathPartial) PostControl(i int) arithm.Pair {
 
 // --- Control Points --------------------------------------------------------
 
-// BUG(norbert@pillmayer.com): Currently it isn't possible to explicitly set
-// control points. This may or may not change in the future.
 func (ctrls *splcntrls) SetPreControl(i int, c arithm.Pair) {
 	ctrls.prec = extendC(ctrls.prec, i, arithm.Pair(cmplx.NaN()))
 	ctrls.prec[i] = c
+	ctrls.version++
 }
 
 func (ctrls *splcntrls) SetPostControl(i int, c arithm.Pair) {
@@ -529,6 +1076,7 @@ func (ctrls *splcntrls) SetPostControl(i int, c arithm.Pair) {
 	//}
 	ctrls.postc = extendC(ctrls.postc, i, arithm.Pair(cmplx.NaN()))
 	ctrls.postc[i] = c
+	ctrls.version++
 }
 
 func (ctrls *splcntrls) PreControl(i int) arithm.Pair {
@@ -542,26 +1090,88 @@ func (ctrls *splcntrls) PostControl(i int) arithm.Pair {
 // === Calculation API =======================================================
 
 // FindHobbyControls finds the parameters for Hobby-spline control points
-// for a given skeletion path.
+// for a given skeletion path. The calculation may be configured by options,
+// see type SolveOption.
 //
 // BUG(norbert@pillmayer.com): Currently there are slight deviations from
 // MetaFont's calculation, probably due to different rounding. These are under
-// investigation.
-func FindHobbyControls(path HobbyPath, controls SplineControls) SplineControls {
+// investigation. Solve option WithReport gives access to the intermediate
+// angles of the solver.
+func FindHobbyControls(path HobbyPath, controls SplineControls, opts ...SolveOption) SplineControls {
+	conf := newSolveConfig(opts)
+	conf.fromPath(path)
 	if controls == nil {
 		controls = &splcntrls{}
 	}
-	segments := splitSegments(path)
-	if len(segments) > 0 {
-		for _, segment := range segments {
-			segment.controls = controls
-			T().Infof("find controls for segment %s", AsString(segment, nil))
-			findSegmentControls(segment, segment)
+	if c, ok := controls.(*splcntrls); ok {
+		c.path = path
+	}
+	if conf.mergeEps > 0 {
+		if m := mergeDuplicates(path, conf.mergeEps); m != nil {
+			m.controls = controls
+			solveSegments(m, splitSegments(m, conf), m, conf)
+			m.fillDuplicates()
+			conf.sortReport()
+			return controls
+		}
+	}
+	segments := splitSegments(path, conf)
+	solveSegments(path, segments, controls, conf)
+	conf.sortReport()
+	return controls
+}
+
+// ResolveKnots re-calculates the control points of a path after some of its
+// knots have changed, e.g. by moving them with ReplaceKnot or by changing
+// their parameters. Parameter changed lists the positions of the changed
+// knots. Only the segments between rough knots containing a changed knot will
+// be re-solved, which is much cheaper than re-solving a large path as a
+// whole.
+//
+// ResolveKnots relies on controls holding the control points of the path
+// before the change. After inserting or removing knots, clients should
+// use FindHobbyControls instead.
+func ResolveKnots(path HobbyPath, controls SplineControls, changed []int,
+	opts ...SolveOption) SplineControls {
+	//
+	if controls == nil {
+		return FindHobbyControls(path, nil, opts...)
+	}
+	conf := newSolveConfig(opts)
+	conf.fromPath(path)
+	if conf.mergeEps > 0 { // segments of the merged path differ
+		return FindHobbyControls(path, controls, opts...)
+	}
+	n := path.N()
+	for _, segment := range splitSegments(path, conf) {
+		for _, k := range changed {
+			if segment.contains(k, n) {
+				solveSegment(path, segment, controls, conf)
+				break
+			}
 		}
 	}
+	conf.sortReport()
 	return controls
 }
 
+// solveSegment finds the control points for a single segment of a path.
+func solveSegment(path HobbyPath, segment *pathPartial, controls SplineControls, conf *solveConfig) {
+	segment.controls = controls
+	conf.emit(segment, SolveEvent{Kind: SegmentStarted})
+	if segment.N() == 2 && hasExplicitControls(path, segment.start) {
+		segment.SetPostControl(0, explicitPostControl(path, segment.start))
+		segment.SetPreControl(1, explicitPreControl(path, segment.pmap(1)))
+		seg := newSegmentReport(segment)
+		seg.Explicit = true
+		conf.report(seg)
+		conf.emitControls(segment, segment)
+		return
+	}
+	conf.infof("find controls for segment %s", AsString(segment, nil))
+	findSegmentControls(segment, segment, conf)
+}
+
 /*
 Find the Control Points according to Hobby's Algorithm. This is the
 central API function of this package.
@@ -570,117 +1180,159 @@ Clients may proved a container for the spline control points. If none
 is provided, i.e. controls == nil, this function will allocate one.
 
 FindHobbyControls(...) will trace the calculated final path using log-level
-INFO, if tracingchoices=true (as MetaFont does).
+INFO, if tracingchoices=true (as MetaFont does), or to the tracing sink
+given by WithTracing(...).
 */
-func findSegmentControls(path HobbyPath, controls SplineControls) SplineControls {
-	var u = make([]float64, path.N()+2)
-	var v = make([]float64, path.N()+2)
-	var theta = make([]float64, path.N()+2)
+func findSegmentControls(path HobbyPath, controls SplineControls, conf *solveConfig) SplineControls {
+	buf := getScratch(path.N() + 2)
+	defer putScratch(buf)
+	u, v, theta := buf.u, buf.v, buf.theta
 	if path.IsCycle() {
-		var w = make([]float64, path.N()+2)
-		solveCyclePath(path, theta, u, v, w)
+		solveCyclePath(path, theta, u, v, buf.w, conf)
+	} else if path.N() == 2 && cmplx.IsNaN(path.PostDir(0).C()) && cmplx.IsNaN(path.PreDir(1).C()) {
+		straightControls(path, controls) // curl at both ends
+		seg := newSegmentReport(path)
+		seg.Straight = true
+		conf.report(seg)
+		conf.emitControls(path, controls)
+		conf.traceChoices(AsString(path, controls))
+		return controls
 	} else {
-		solveOpenPath(path, theta, u, v)
+		solveOpenPath(path, theta, u, v, conf)
+	}
+	converged := true
+	if conf.g2 {
+		converged = refineCurvature(path, theta, conf)
 	}
+	conf.reportAngles(path, theta, conf.g2, converged)
+	conf.emitAngles(path, theta)
 	setControls(path, theta, controls) // set control points from theta angles
+	conf.emitControls(path, controls)
+	conf.traceChoices(AsString(path, controls))
 	return controls
 }
 
-func solveOpenPath(path HobbyPath, theta, u, v []float64) {
-	startOpen(path, theta, u, v)
-	buildEqs(path, u, v, nil)
-	endOpen(path, theta, u, v)
+/* For a single segment with curls at both ends the solution reduces to a
+ * straight line (see MetaFont §295). Control points are placed at 1/3 of the
+ * chord, scaled by the tensions.
+ */
+func straightControls(path HobbyPath, controls SplineControls) SplineControls {
+	dvec := delta(path, 0)
+	a := recip(path.PostTension(0))
+	b := recip(path.PreTension(1))
+	controls.SetPostControl(0, path.Z(0)+dvec*arithm.P(a/3, 0))
+	controls.SetPreControl(1, path.Z(1)-dvec*arithm.P(b/3, 0))
+	return controls
 }
 
-func solveCyclePath(path HobbyPath, theta, u, v, w []float64) {
-	startCycle(path, theta, u, v, w)
-	buildEqs(path, u, v, w)
-	endCycle(path, theta, u, v, w)
+/* The equations are solved in arithm.Numbers: float64 by default, or
+ * arbitrary precision if requested (see WithArbitraryPrecision). The
+ * coefficients are derived from the knots in float64 precision, conf.num
+ * lifts them to the precision of the solution.
+ */
+func solveOpenPath(path HobbyPath, theta []float64, u, v []arithm.Number, conf *solveConfig) {
+	startOpen(path, u, v, conf)
+	buildEqs(path, u, v, nil, conf)
+	conf.emitEquations(path, u, v, nil)
+	endOpen(path, theta, u, v, conf)
 }
 
-func startOpen(path HobbyPath, theta, u, v []float64) {
+func solveCyclePath(path HobbyPath, theta []float64, u, v, w []arithm.Number, conf *solveConfig) {
+	startCycle(path, u, v, w, conf)
+	buildEqs(path, u, v, w, conf)
+	conf.emitEquations(path, u, v, w)
+	endCycle(path, theta, u, v, w, conf)
+}
+
+func startOpen(path HobbyPath, u, v []arithm.Number, conf *solveConfig) {
 	if cmplx.IsNaN(path.PostDir(0).C()) {
-		a := recip(path.PostTension(0))
-		b := recip(path.PreTension(1))
-		T().Debugf("path.PostCurl(0) = %.4g", path.PostCurl(0))
-		c := square(a) * path.PostCurl(0) / square(b)
-		T().Debugf("a = %.4g, b = %.4g, c = %.4g", a, b, c)
-		u[0] = ((3-a)*c + b) / (a*c + 3 - b)
-		v[0] = -u[0] * psi(path, 1)
+		a := conf.num(recip(path.PostTension(0)))
+		b := conf.num(recip(path.PreTension(1)))
+		curl := conf.curl(path, 0, true)
+		conf.debugf("path.PostCurl(0) = %.4g", curl)
+		c := a.Mul(a).Mul(conf.num(curl)).Quo(b.Mul(b))
+		conf.debugf("a = %.4g, b = %.4g, c = %.4g", a.Float64(), b.Float64(), c.Float64())
+		three := conf.num(3)
+		u[0] = three.Sub(a).Mul(c).Add(b).Quo(a.Mul(c).Add(three).Sub(b))
+		v[0] = u[0].Mul(conf.num(psi(path, 1))).Neg()
 	} else {
-		u[0] = 0
-		v[0] = reduceAngle(angle(path.PostDir(0)) - angle(delta(path, 0)))
+		u[0] = conf.num(0)
+		v[0] = conf.num(arithm.NormalizePi(angle(path.PostDir(0)) - angle(delta(path, 0))))
 	}
-	T().Debugf("u.0 = %.4g, v.0 = %.4g", u[0], v[0])
+	conf.debugf("u.0 = %.4g, v.0 = %.4g", u[0].Float64(), v[0].Float64())
 }
 
-func endOpen(path HobbyPath, theta, u, v []float64) {
+func endOpen(path HobbyPath, theta []float64, u, v []arithm.Number, conf *solveConfig) {
 	last := path.N() - 1
+	var th arithm.Number
 	if cmplx.IsNaN(path.PreDir(last).C()) {
-		a := recip(path.PostTension(last - 1))
-		b := recip(path.PreTension(last))
-		T().Debugf("path.PreCurl(%d) = %.4g", last, path.PostCurl(last))
-		c := square(b) * path.PreCurl(last) / square(a)
-		u[last] = (b*c + 3 - a) / ((3-b)*c + a)
-		T().Debugf("u.%d = %g", last, u[last])
-		theta[last] = v[last-1] / (u[last-1] - u[last])
+		a := conf.num(recip(path.PostTension(last - 1)))
+		b := conf.num(recip(path.PreTension(last)))
+		curl := conf.curl(path, last, false)
+		conf.debugf("path.PreCurl(%d) = %.4g", last, curl)
+		c := b.Mul(b).Mul(conf.num(curl)).Quo(a.Mul(a))
+		three := conf.num(3)
+		u[last] = b.Mul(c).Add(three).Sub(a).Quo(three.Sub(b).Mul(c).Add(a))
+		conf.debugf("u.%d = %g", last, u[last].Float64())
+		th = v[last-1].Quo(u[last-1].Sub(u[last]))
 	} else {
-		theta[last] = reduceAngle(angle(path.PreDir(last)) - angle(delta(path, last-1)))
+		th = conf.num(arithm.NormalizePi(angle(path.PreDir(last)) - angle(delta(path, last-1))))
 	}
-	T().Debugf("theta.%d = %.4g", last, rad2deg(theta[last]))
+	theta[last] = th.Float64()
+	conf.debugf("theta.%d = %.4g", last, rad2deg(theta[last]))
 	for i := last - 1; i >= 0; i-- {
-		theta[i] = v[i] - u[i]*theta[i+1]
-		T().Debugf("theta.%d = %.4g", i, rad2deg(theta[i]))
+		th = v[i].Sub(u[i].Mul(th))
+		theta[i] = th.Float64()
+		conf.debugf("theta.%d = %.4g", i, rad2deg(theta[i]))
 	}
 }
 
-func startCycle(path HobbyPath, theta, u, v, w []float64) {
-	u[0], v[0], w[0] = 0, 0, 1
+func startCycle(path HobbyPath, u, v, w []arithm.Number, conf *solveConfig) {
+	u[0], v[0], w[0] = conf.num(0), conf.num(0), conf.num(1)
 }
 
-func endCycle(path HobbyPath, theta, u, v, w []float64) {
+func endCycle(path HobbyPath, theta []float64, u, v, w []arithm.Number, conf *solveConfig) {
 	n := path.N()
-	var a, b float64 = 0, 1
+	one := conf.num(1)
+	a, b := conf.num(0), one
 	for i := n; i > 0; i-- {
-		a = v[i] - a*u[i]
-		b = w[i] - b*u[i]
+		a = v[i].Sub(a.Mul(u[i]))
+		b = w[i].Sub(b.Mul(u[i]))
 	}
-	t0 := (v[n] - a*u[n]) / (1 - (w[n] - b*u[n]))
+	t0 := v[n].Sub(a.Mul(u[n])).Quo(one.Sub(w[n].Sub(b.Mul(u[n]))))
 	v[0] = t0
 	for i := 1; i <= n; i++ {
-		v[i] += w[i] * t0
+		v[i] = v[i].Add(w[i].Mul(t0))
 	}
-	theta[0], theta[n] = t0, t0
+	theta[0], theta[n] = t0.Float64(), t0.Float64()
+	th := t0
 	for i := n - 1; i > 0; i-- {
-		theta[i] = v[i] - u[i]*theta[i+1]
+		th = v[i].Sub(u[i].Mul(th))
+		theta[i] = th.Float64()
 	}
-	/*
-	   for i := 0; i <= n; i++ {
-	       fmt.Printf("theta.%d = %.2g\n", i, rad2deg(theta[i]))
-	   }
-	*/
 }
 
-func buildEqs(path HobbyPath, u, v, w []float64) {
+func buildEqs(path HobbyPath, u, v, w []arithm.Number, conf *solveConfig) {
 	n := path.N()
+	three := conf.num(3)
 	for i := 1; i <= n; i++ {
-		a0 := recip(path.PostTension(i - 1))
-		a1 := recip(path.PostTension(i))
-		b1 := recip(path.PreTension(i))
-		b2 := recip(path.PreTension(i + 1))
-		T().Debugf("1/tensions: %.4g, %.4g, %.4g, %.4g", a0, a1, b1, b2)
-		A := a0 / (square(b1) * d(path, i-1))
-		B := (3 - a0) / (square(b1) * d(path, i-1))
-		C := (3 - b2) / (square(a1) * d(path, i))
-		D := b2 / (square(a1) * d(path, i))
-		T().Debugf("A, B, C, D: %.4g, %.4g, %.4g, %.4g", A, B, C, D)
-		t := B - u[i-1]*A + C
-		u[i] = D / t
-		v[i] = (-B*psi(path, i) - D*psi(path, i+1) - A*v[i-1]) / t
+		a0 := conf.num(recip(path.PostTension(i - 1)))
+		a1 := conf.num(recip(path.PostTension(i)))
+		b1 := conf.num(recip(path.PreTension(i)))
+		b2 := conf.num(recip(path.PreTension(i + 1)))
+		conf.debugf("1/tensions: %.4g, %.4g, %.4g, %.4g", a0.Float64(), a1.Float64(), b1.Float64(), b2.Float64())
+		d0 := b1.Mul(b1).Mul(conf.num(d(path, i-1)))
+		d1 := a1.Mul(a1).Mul(conf.num(d(path, i)))
+		A, B := a0.Quo(d0), three.Sub(a0).Quo(d0)
+		C, D := three.Sub(b2).Quo(d1), b2.Quo(d1)
+		conf.debugf("A, B, C, D: %.4g, %.4g, %.4g, %.4g", A.Float64(), B.Float64(), C.Float64(), D.Float64())
+		t := B.Sub(u[i-1].Mul(A)).Add(C)
+		u[i] = D.Quo(t)
+		v[i] = B.Mul(conf.num(psi(path, i))).Add(D.Mul(conf.num(psi(path, i+1)))).Add(A.Mul(v[i-1])).Neg().Quo(t)
 		if path.IsCycle() {
-			w[i] = -A * w[i-1] / t
+			w[i] = A.Mul(w[i-1]).Neg().Quo(t)
 		}
-		T().Debugf("u.%d = %.4g, v.%d = %.4g", i, u[i], i, v[i])
+		conf.debugf("u.%d = %.4g, v.%d = %.4g", i, u[i].Float64(), i, v[i].Float64())
 	}
 }
 
@@ -692,7 +1344,11 @@ func setControls(path HobbyPath, theta []float64, controls SplineControls) Splin
 	   const_cc := 0.61803398875 // 1 - c
 	*/
 	n := path.N()
-	for i := 0; i < n; i++ {
+	segcnt := n - 1 // open paths have one segment less than knots
+	if path.IsCycle() {
+		segcnt = n
+	}
+	for i := 0; i < segcnt; i++ {
 		phi := -psi(path, i+1) - theta[i+1]
 		//fmt.Printf("#### phi(%d) = %.2g\n", i, rad2deg(phi))
 		//fmt.Printf("phi.%d = %.4g - %.4g = %.4g\n", i, rad2deg(-path.psi(i+1)),
@@ -721,20 +1377,34 @@ func setControls(path HobbyPath, theta []float64, controls SplineControls) Splin
 		       path.postc[i%n] = path.z(i) + pci
 		       path.prec[(i+1)%n] = path.z(i+1) - pcii
 		*/
-		a := recip(path.PostTension(i))
-		b := recip(path.PreTension(i + 1))
-		dvec := delta(path, i)
-		p2, p3 := controlPoints(i, phi, theta[i], a, b, dvec)
-		controls.SetPostControl(i%n, path.Z(i)+p2)
+		p2, p3 := segmentControls(path, i, theta[i], phi)
+		controls.SetPostControl(i, path.Z(i)+p2)
 		controls.SetPreControl((i+1)%n, path.Z(i+1)-p3)
 	}
-	if gconf.IsSet("tracingchoices") {
-		T().Infof(AsString(path, controls))
-	}
 	return controls
 }
 
-func hobbyParamsAlphaBeta(theta, phi float64) (float64, float64) {
+/* Calculate the offsets of the control points between z.i and z.[i+1] from
+ * the knots, for given angles theta and phi. Respects tensions and "at least"
+ * tensions.
+ */
+func segmentControls(path HobbyPath, i int, theta, phi float64) (arithm.Pair, arithm.Pair) {
+	a := recip(path.PostTension(i))
+	b := recip(path.PreTension(i + 1))
+	dvec := delta(path, i)
+	p2, p3 := controlPoints(i, phi, theta, a, b, dvec)
+	if path.PostTension(i) < 0 || path.PreTension(i+1) < 0 {
+		p2, p3 = boundControls(theta, phi, p2, p3, dvec,
+			path.PostTension(i) < 0, path.PreTension(i+1) < 0)
+	}
+	return p2, p3
+}
+
+// hobbyParamsAlphaBeta calculates the numerator term alpha of Hobby's
+// velocity function, together with the denominators beta (for rho) and
+// gamma (for sigma). As sigma is the velocity with theta and phi swapped,
+// its denominator differs from the one of rho (see MetaFont §116).
+func hobbyParamsAlphaBeta(theta, phi float64) (float64, float64, float64) {
 	const_a := 1.41421356     // sqrt(2) -- empiric constants, as explained by J.Hobby
 	const_b := 0.0625         // 1/16
 	const_c := 0.38196601125  // (3 - sqrt(5)) / 2
@@ -745,12 +1415,13 @@ func hobbyParamsAlphaBeta(theta, phi float64) (float64, float64) {
 	cf := math.Cos(phi)
 	alpha := const_a * (st - const_b*sf) * (sf - const_b*st) * (ct - cf)
 	beta := 1 + const_cc*ct + const_c*cf
-	return alpha, beta
+	gamma := 1 + const_cc*cf + const_c*ct
+	return alpha, beta, gamma
 }
 
-func hobbyParamsRhoSigma(alpha, beta float64) (float64, float64) {
+func hobbyParamsRhoSigma(alpha, beta, gamma float64) (float64, float64) {
 	rho := (2 + alpha) / beta
-	sigma := (2 - alpha) / beta
+	sigma := (2 - alpha) / gamma
 	return rho, sigma
 }
 
@@ -782,8 +1453,7 @@ func controlPoints(i int, phi, theta, a, b float64, dvec arithm.Pair) (arithm.Pa
 	           path.postc[i%n] = path.z(i) + pci
 	           path.prec[(i+1)%n] = path.z(i+1) - pcii
 	*/
-	alpha, beta := hobbyParamsAlphaBeta(theta, phi)
-	rho, sigma := hobbyParamsRhoSigma(alpha, beta)
+	rho, sigma := hobbyParamsRhoSigma(hobbyParamsAlphaBeta(theta, phi))
 	uv1, uv2 := cunitvecs(i, theta, phi, dvec)
 	crho := arithm.P(a/3*rho, 0)
 	csigma := arithm.P(b/3*sigma, 0)
@@ -798,30 +1468,67 @@ func controlPoints(i int, phi, theta, a, b float64, dvec arithm.Pair) (arithm.Pa
 	return p2, p3
 }
 
+/* Restrict the control point offsets p2 and p3 for "at least" tensions,
+ * such that the spline stays within the triangle formed by z.i, z.[i+1] and
+ * the intersection of the tangents (if they intersect). See MetaFont, §299.
+ */
+func boundControls(theta, phi float64, p2, p3, dvec arithm.Pair, atleast2, atleast3 bool) (
+	arithm.Pair, arithm.Pair) {
+	//
+	st, ct := math.Sin(theta), math.Cos(theta)
+	sf, cf := math.Sin(phi), math.Cos(phi)
+	if (st < 0 && sf > 0) || (st > 0 && sf < 0) {
+		return p2, p3 // tangents do not form a triangle with the chord
+	}
+	sine := math.Abs(st)*cf + math.Abs(sf)*ct // = sin(theta+phi)
+	if sine <= 0 {
+		return p2, p3
+	}
+	sine *= 1 + 1.0/4096 // safety factor, as with MetaFont: fraction_one+unity
+	d := cmplx.Abs(dvec.C())
+	if rr := cmplx.Abs(p2.C()) / d; atleast2 && rr > math.Abs(sf)/sine {
+		p2 *= arithm.P(math.Abs(sf)/sine/rr, 0)
+	}
+	if ss := cmplx.Abs(p3.C()) / d; atleast3 && ss > math.Abs(st)/sine {
+		p3 *= arithm.P(math.Abs(st)/sine/ss, 0)
+	}
+	return p2, p3
+}
+
 // --- Splitting Paths into Segments -----------------------------------------
 
 /* Split a path into segments, breaking it up at "rough" knots. Rough knots
  * are those with parameters which create a discontinuity.
+ *
+ * Cyclic paths with at least one rough knot are broken up into open segments,
+ * starting at the first rough knot and wrapping around the end of the path.
  */
-func splitSegments(path HobbyPath) []*pathPartial {
+func splitSegments(path HobbyPath, conf *solveConfig) []*pathPartial {
 	var segments []*pathPartial
-	segcnt, at := 0, 0
-	for i := 1; i < path.N(); i++ {
-		//T().Debugf("analyzing z.%d = %s\n", i, ptstring(path.Z(i), false))
-		if isrough(path, i) {
-			segments = append(segments, makePathSegment(path, at, i))
-			segcnt++
-			at = i
-		}
+	n := path.N()
+	if n == 0 {
+		return segments
 	}
+	from, to := 0, last(path)
 	if path.IsCycle() {
-		if segcnt == 0 {
-			segments = append(segments, makePathSegment(path, 0, last(path)))
-		} else {
-			segments = append(segments, makePathSegment(path, at, path.N()))
+		first := -1
+		for i := 0; i < n; i++ {
+			if isrough(path, i) {
+				first = i
+				break
+			}
+		}
+		if first < 0 { // smooth cycle
+			return append(segments, makePathSegment(path, 0, last(path), conf))
+		}
+		from, to = first, first+n
+	}
+	at := from
+	for i := from + 1; i <= to; i++ {
+		if i == to || isrough(path, i%n) {
+			segments = append(segments, makePathSegment(path, at, i, conf))
+			at = i
 		}
-	} else if at != last(path) {
-		segments = append(segments, makePathSegment(path, at, last(path)))
 	}
 	return segments
 }
@@ -830,16 +1537,16 @@ func splitSegments(path HobbyPath) []*pathPartial {
  * This will create a kind of "projection" onto a subset of knots of
  * the parent path.
  */
-func makePathSegment(path HobbyPath, from, to int) *pathPartial {
+func makePathSegment(path HobbyPath, from, to int, conf *solveConfig) *pathPartial {
 	partial := &pathPartial{
 		whole: path, // parent path
 		start: from, // first index within parent path
 		end:   to,   // last index within parent path
 	}
-	if gconf.IsSet("tracingchoices") {
-		T().Debugf("breaking segment %d - %d of length %d, at %s and %s", from, to, partial.N(),
+	if conf.tracingChoices() {
+		conf.debugf("breaking segment %d - %d of length %d, at %s and %s", from, to, partial.N(),
 			ptstring(path.Z(from), false), ptstring(path.Z(to), false))
-		T().Infof("partial = %s", AsString(partial, nil))
+		conf.infof("partial = %s", AsString(partial, nil))
 	}
 	return partial
 }
@@ -867,21 +1574,79 @@ func psi(path HobbyPath, i int) float64 {
 	if path.IsCycle() || (i > 0 && i < path.N()-1) {
 		psi = cmplx.Phase(delta(path, i).C()) - cmplx.Phase(delta(path, i-1).C())
 	}
-	return reduceAngle(psi)
+	return arithm.NormalizePi(psi)
 }
 
-// Is a knot a breakpoint for splitting a path into segments?
+// Is a knot a breakpoint for splitting a path into segments? As in MetaFont,
+// knots with explicit curls, directions or control points are breakpoints.
 func isrough(path HobbyPath, i int) bool {
 	lc, rc := path.PreCurl(i), path.PostCurl(i)
 	hascurl := lc != 1 || rc != 1
 	ld, rd := path.PreDir(i), path.PostDir(i)
-	has2dirs := (!cmplx.IsNaN(ld.C()) && !cmplx.IsNaN(rd.C())) && !equal(ld, rd)
-	if hascurl || has2dirs {
+	hasdir := !cmplx.IsNaN(ld.C()) || !cmplx.IsNaN(rd.C())
+	if hascurl || hasdir {
+		return true
+	}
+	if isGivenRough(path, i) {
+		return true
+	}
+	if !cmplx.IsNaN(explicitPreControl(path, i).C()) || !cmplx.IsNaN(explicitPostControl(path, i).C()) {
 		return true
 	}
 	return false
 }
 
+// Is knot #i marked as a breakpoint by a path implementing RoughKnots?
+func isGivenRough(path HobbyPath, i int) bool {
+	r, ok := path.(RoughKnots)
+	return ok && r.IsRough(i)
+}
+
+// Is a curl given for the incoming (post=false) or outgoing (post=true) side
+// of knot #i? For paths other than Path, curls of 1 are considered not given.
+func hasGivenCurl(path HobbyPath, i int, post bool) bool {
+	if p, ok := path.(*Path); ok {
+		c := getC(p.curls, p.cyc(i), nocurl)
+		if post {
+			return !math.IsNaN(imag(c))
+		}
+		return !math.IsNaN(real(c))
+	}
+	if post {
+		return path.PostCurl(i) != 1.0
+	}
+	return path.PreCurl(i) != 1.0
+}
+
+// Is knot #i an inner knot of a path, i.e. neither the first nor the last
+// knot of an open path?
+func isInnerKnot(path HobbyPath, i int) bool {
+	return path.IsCycle() || (i > 0 && i < last(path))
+}
+
+// Does the join between knot #i and knot #i+1 have explicit control points?
+func hasExplicitControls(path HobbyPath, i int) bool {
+	return !cmplx.IsNaN(explicitPostControl(path, i).C())
+}
+
+// Get the explicit control point after knot #i, if path implements interface
+// ExplicitControls. Returns NaN otherwise.
+func explicitPostControl(path HobbyPath, i int) arithm.Pair {
+	if ex, ok := path.(ExplicitControls); ok {
+		return ex.ExplicitPostControl(i)
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+// Get the explicit control point before knot #i, if path implements interface
+// ExplicitControls. Returns NaN otherwise.
+func explicitPreControl(path HobbyPath, i int) arithm.Pair {
+	if ex, ok := path.(ExplicitControls); ok {
+		return ex.ExplicitPreControl(i)
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
 // --- Helpers ---------------------------------------------------------------
 
 /* Extend an array/slice of complex numbers to make room for index i.
@@ -899,6 +1664,15 @@ func extendC(arr []arithm.Pair, i int, deflt arithm.Pair) []arithm.Pair {
 	return arr
 }
 
+/* Truncate an array/slice of complex numbers to at most n entries.
+ */
+func truncC(arr []arithm.Pair, n int) []arithm.Pair {
+	if len(arr) > n {
+		return arr[:n]
+	}
+	return arr
+}
+
 /* Get a complex number from an array/slice if present, default value
  * deflt otherwise.
  */
@@ -916,26 +1690,29 @@ func angle(pr arithm.Pair) float64 {
 	return cmplx.Phase(pr.C())
 }
 
-/* Reduce an angle to fit int -pi .. pi.
+/* Return 1/|a| for a tension a. Negative ("at least") tensions enter the
+ * equations with their absolute value.
  */
-func reduceAngle(a float64) float64 {
-	if math.Abs(a) > pi {
-		if a > 0 {
-			a -= pi2
-		} else {
-			a += pi2
-		}
+func recip(a float64) float64 {
+	if math.IsNaN(a) {
+		return 1.0
 	}
-	return a
+	return 1.0 / math.Abs(a)
 }
 
-/* Return 1/a for a.
+/* Clamp a tension to lie between 3/4 and 4 (absolute), keeping its sign.
  */
-func recip(a float64) float64 {
-	if math.IsNaN(a) {
-		return 1.0
+func clampTension(t float64) float64 {
+	sign := 1.0
+	if t < 0 {
+		sign, t = -1.0, -t
+	}
+	if t < 0.75 {
+		t = 0.75
+	} else if t > 4.0 {
+		t = 4.0
 	}
-	return 1.0 / a
+	return sign * t
 }
 
 /* Return a^2 for a.
@@ -971,7 +1748,3 @@ func round(x float64) float64 {
 	}
 	return float64(int64(x*10000.0-0.5)) / 10000.0
 }
-
-func equal(c1, c2 arithm.Pair) bool {
-	return math.Abs(cmplx.Phase(c1.C()-c2.C())) < _epsilon
-}
@@ -1,8 +1,13 @@
 package jhobby
 
 import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"math"
+	"math/cmplx"
+	"strings"
 	"testing"
 
 	"github.com/npillmayer/arithm"
@@ -195,7 +200,7 @@ func TestSegmentProjection(t *testing.T) {
 	teardown := gotestingadapter.RedirectTracing(t)
 	defer teardown()
 	path, _ := Nullpath().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(3, 1)).End()
-	seg := makePathSegment(path, 0, 1)
+	seg := makePathSegment(path, 0, 1, newSolveConfig(nil))
 	if seg.N() != 2 {
 		t.Fail()
 	}
@@ -207,7 +212,7 @@ func TestSegments(t *testing.T) {
 	defer teardown()
 	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(0, 3)).Curve().
 		Knot(arithm.P(5, 3)).Line().DirKnot(arithm.P(3, -1), arithm.P(0, -1)).Curve().Cycle()
-	segs := splitSegments(path)
+	segs := splitSegments(path, newSolveConfig(nil))
 	if len(segs) != 4 {
 		t.Fail()
 	}
@@ -221,3 +226,1796 @@ func TestSegmentedPath(t *testing.T) {
 	path, controls := Nullpath().Knot(arithm.P(1, 1)).Line().Knot(arithm.P(2, 2)).Line().Knot(arithm.P(3, 1)).End()
 	controls = FindHobbyControls(path, controls)
 }
+
+// As in MetaFont, a cyclic path with breakpoints is solved starting at its
+// first breakpoint, and knot #0 remains a smooth knot.
+func TestCycleStartsAtBreakpoint(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(4, 0)).Curve().
+		Knot(arithm.P(1, -3)).Curve().Cycle()
+	path.SetPreDir(2, arithm.P(1, -1)).SetPostDir(2, arithm.P(-1, -1)) // corner at z2
+	segments := splitSegments(path, newSolveConfig(nil))
+	if len(segments) != 1 || segments[0].start != 2 || segments[0].N() != 5 {
+		t.Fatalf("expected a single segment wrapping around from the corner")
+	}
+	controls := FindHobbyControls(path, nil)
+	in, out := path.Z(0)-controls.PreControl(0), controls.PostControl(0)-path.Z(0)
+	if math.Abs(in.Cross(out)) > 1e-9 {
+		t.Errorf("expected path to be smooth at knot #0, have %s", AsString(path, controls))
+	}
+}
+
+// As in MetaFont, a knot with a given direction is a breakpoint, even if
+// both sides have the same direction ("z{dir}").
+func TestDirKnotIsBreakpoint(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	dir := arithm.P(1, -1)
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().DirKnot(arithm.P(2, 2), dir).Curve().
+		Knot(arithm.P(5, 0)).End()
+	if !isrough(path, 1) {
+		t.Errorf("expected knot with a direction to be a breakpoint")
+	}
+	controls = FindHobbyControls(path, controls)
+	in, out := path.Z(1)-controls.PreControl(1), controls.PostControl(1)-path.Z(1)
+	if math.Abs(in.Cross(dir)) > 1e-9 || math.Abs(out.Cross(dir)) > 1e-9 {
+		t.Errorf("expected path to follow direction %v at knot #1, have %s", dir, AsString(path, controls))
+	}
+}
+
+// As in MetaFont, the parameters of the join closing a cycle ("..cycle")
+// belong to knot #0.
+func TestCycleClosingJoin(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 0)).Curve().
+		Knot(arithm.P(1, 2)).TensionCurve(2, 3).Cycle()
+	if path.PostTension(2) != 2 || path.PreTension(0) != 3 {
+		t.Errorf("expected tensions of closing join to be 2 and 3, have %g and %g",
+			path.PostTension(2), path.PreTension(0))
+	}
+}
+
+// As in MetaFont, the knots of a cycle are addressed modulo N.
+func TestCycleIndicesWrap(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).TensionCurve(1, 2).Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(1, 2)).Curve().Cycle()
+	path.SetPreDir(0, arithm.P(0, -1)).SetPostDir(0, arithm.P(1, 0)) // corner at z0
+	n := path.N()
+	if path.PreDir(n) != path.PreDir(0) || path.PostDir(n) != path.PostDir(0) {
+		t.Errorf("expected directions of knot #%d to be those of knot #0", n)
+	}
+	if path.PreTension(n+1) != 2 {
+		t.Errorf("expected tension before knot #%d to be that of knot #1, have %g", n+1, path.PreTension(n+1))
+	}
+	segments := splitSegments(path, newSolveConfig(nil))
+	if seg := segments[0]; seg.PreDir(seg.N()-1) != path.PreDir(0) {
+		t.Errorf("expected last knot of segment to wrap around to knot #0")
+	}
+}
+
+// As in MetaFont, a direction given at one side of an inner knot applies to
+// the other side as well ("z1..{dir}z2..z3").
+func TestOneSidedDirAppliesToBothSides(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	dir := arithm.P(1, -1)
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(5, 0)).End()
+	path.SetPreDir(1, dir)
+	segments := splitSegments(path, newSolveConfig(nil))
+	if len(segments) != 2 || segments[1].PostDir(0) != dir {
+		t.Fatalf("expected incoming direction to apply to the outgoing side as well")
+	}
+	controls := FindHobbyControls(path, nil)
+	if out := controls.PostControl(1) - path.Z(1); math.Abs(out.Cross(dir)) > 1e-9 {
+		t.Errorf("expected path to leave knot #1 in direction %v, have %s", dir, AsString(path, controls))
+	}
+}
+
+// As in MetaFont, an open path of N knots has N-1 joins; there are no control
+// points before its first or after its last knot.
+func TestOpenPathEndControls(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).End()
+	controls = FindHobbyControls(path, controls)
+	if !math.IsNaN(controls.PreControl(0).X()) || !math.IsNaN(controls.PostControl(2).X()) {
+		t.Errorf("expected no controls before start and after end of open path, have %v and %v",
+			controls.PreControl(0), controls.PostControl(2))
+	}
+	indir := arithm.P(1, -1)
+	corner := Nullpath()
+	corner.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).Curve().
+		Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(4, 0)).End()
+	corner.SetPreDir(2, indir).SetPostDir(2, arithm.P(1, 1))
+	controls = FindHobbyControls(corner, nil)
+	if in := corner.Z(2) - controls.PreControl(2); math.Abs(in.Cross(indir)) > 1e-9 {
+		t.Errorf("expected control before corner to be kept, have %s", AsString(corner, controls))
+	}
+}
+
+func TestCubicRootsDouble(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	for _, r := range []float64{7, 100, 1e5} { // (t-d)²(t-r), double root d
+		for _, d := range []float64{0.1, 0.5, 0.7} {
+			roots := cubicRoots(1, -(2*d + r), d*d+2*d*r, -d*d*r)
+			if len(roots) != 1 || math.Abs(roots[0]-d) > 1e-6 {
+				t.Errorf("expected double root %g of (t-%g)²(t-%g), have %v", d, d, r, roots)
+			}
+		}
+	}
+}
+
+func TestIntersectHorizontal(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(3, 1)).
+		Curve().Knot(arithm.P(2, 0)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	pts := IntersectHorizontal(path, controls, 1.0)
+	if len(pts) != 2 || !pts[0].Equal(arithm.P(1, 1)) || !pts[1].Equal(arithm.P(3, 1)) {
+		t.Errorf("expected crossings (1,1) and (3,1), have %v", pts)
+	}
+	pts = IntersectHorizontal(path, controls, 1.5)
+	if len(pts) != 2 || pts[0].X() > pts[1].X() {
+		t.Errorf("expected 2 crossings sorted by x, have %v", pts)
+	}
+	for _, pt := range pts {
+		if !arithm.Is0(pt.Y() - 1.5) {
+			t.Errorf("crossing %v not on scanline y=1.5", pt)
+		}
+	}
+	if pts = IntersectHorizontal(path, controls, 3.0); len(pts) != 0 {
+		t.Errorf("expected no crossings above path, have %v", pts)
+	}
+}
+
+func TestIntersectVertical(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	controls = FindHobbyControls(path, controls)
+	pts := IntersectVertical(path, controls, 2.0)
+	if len(pts) != 1 || !pts[0].Equal(arithm.P(2, 2)) {
+		t.Errorf("expected crossing at (2,2), have %v", pts)
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p := Nullpath()
+	for i := 0; i < 16; i++ {
+		theta := float64(i) * math.Pi / 8
+		p.Knot(arithm.P(10*math.Cos(theta), 10*math.Sin(theta))).Curve()
+	}
+	path, _ := p.Cycle()
+	simple, scontrols := Simplify(path, nil, 0.05)
+	t.Logf("simplified = %s", AsString(simple, scontrols))
+	if simple.N() >= path.N() || simple.N() < 3 {
+		t.Errorf("expected circle to be simplified to fewer knots, has %d", simple.N())
+	}
+	if path.N() != 16 {
+		t.Errorf("input path has been modified")
+	}
+	line, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 0)).Curve().
+		Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 0)).End()
+	simple, _ = Simplify(line, nil, 0.001)
+	if simple.N() != 2 {
+		t.Errorf("expected straight line to be simplified to 2 knots, has %d", simple.N())
+	}
+}
+
+func TestFit(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	var samples []arithm.Pair
+	for i := 0; i <= 100; i++ { // noisy samples of a sine wave
+		x := float64(i) / 10
+		noise := 0.01 * math.Sin(float64(i*7))
+		samples = append(samples, arithm.P(x, math.Sin(x)+noise))
+	}
+	path, controls := Fit(samples, false, 0.05)
+	t.Logf("fitted = %s", AsString(path, controls))
+	if path.N() < 3 || path.N() > 20 {
+		t.Errorf("expected a sparse path, have %d knots", path.N())
+	}
+	curve := sampleCurve(path, controls)
+	for _, pt := range samples {
+		if dist := polylineDistance(pt, curve); dist > 0.05 {
+			t.Errorf("sample %v is %g away from fitted curve", pt, dist)
+		}
+	}
+}
+
+func TestFitSmoothedKnots(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	var samples []arithm.Pair
+	for i := 0; i <= 20; i++ { // zig-zag, flattened by smoothing
+		samples = append(samples, arithm.P(float64(i)/10, 0.2*float64(1-2*(i%2))))
+	}
+	for _, cycle := range []bool{false, true} {
+		path, controls := Fit(samples, cycle, 0.05)
+		curve := sampleCurve(path, controls)
+		for _, pt := range samples {
+			if dist := polylineDistance(pt, curve); dist > 0.05 {
+				t.Errorf("cycle=%v: sample %v is %g away from fitted curve", cycle, pt, dist)
+			}
+		}
+	}
+}
+
+func TestExplicitCurve(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).ExplicitCurve(arithm.P(0, 1), arithm.P(2, 1)).
+		Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(4, 2)).End()
+	controls = FindHobbyControls(path, controls)
+	t.Logf(AsString(path, controls))
+	if controls.PostControl(0) != arithm.P(0, 1) || controls.PreControl(1) != arithm.P(2, 1) {
+		t.Errorf("explicit control points not honored")
+	}
+	c := controls.PostControl(1) // must continue in direction (2,0)-(2,1), i.e. down
+	if !arithm.Is0(c.X()-2) || c.Y() >= 0 {
+		t.Errorf("expected post control of (2,0) to point downwards, is %v", c)
+	}
+}
+
+func TestFromCubics(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 3)).TensionCurve(1.4, 1.4).
+		Knot(arithm.P(5, 3)).Curve().Knot(arithm.P(3, -1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	segs := Segments(path, controls)
+	segs = append(segs, CubicSegment{P0: arithm.P(7, 7), C1: arithm.P(7, 7), C2: arithm.P(1, 1), P3: arithm.P(1, 1)})
+	if _, err := FromCubics(segs); err == nil {
+		t.Errorf("expected error for non-connecting segments")
+	}
+	segs = segs[:len(segs)-1]
+	segs[1].C1 = segs[1].P0 + (segs[1].C1-segs[1].P0)*10 // force explicit controls
+	imported, err := FromCubics(segs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !imported.IsCycle() || imported.N() != 4 {
+		t.Fatalf("expected cyclic path with 4 knots, have %s", AsString(imported, nil))
+	}
+	icontrols := FindHobbyControls(imported, imported.Controls)
+	t.Logf(AsString(imported, icontrols))
+	for i, seg := range Segments(imported, icontrols) {
+		if !seg.C1.Equal(segs[i].C1) || !seg.C2.Equal(segs[i].C2) {
+			t.Errorf("segment %d not reproduced: %v ≠ %v", i, seg, segs[i])
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	if segs := Segments(path, controls); len(segs) != 2 || segs[0].C1 != segs[0].P0 {
+		t.Errorf("expected 2 straight segments for unsolved open path, have %v", segs)
+	}
+	controls = FindHobbyControls(path, controls)
+	segs := Segments(path, controls)
+	if len(segs) != 2 || segs[1].P0 != path.Z(1) || segs[1].C1 != controls.PostControl(1) {
+		t.Errorf("unexpected segments %v", segs)
+	}
+	if !segs[0].At(1).Equal(path.Z(1)) {
+		t.Errorf("expected segment 0 to end at knot 1, ends at %v", segs[0].At(1))
+	}
+	path.cycle = true
+	if segs := Segments(path, FindHobbyControls(path, nil)); len(segs) != 3 || segs[2].P3 != path.Z(0) {
+		t.Errorf("expected 3 segments for cyclic path, have %v", segs)
+	}
+}
+
+func TestKnotEditing(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().DirKnot(arithm.P(2, 2), arithm.P(1, 0)).
+		TensionCurve(1.0, 2.0).Knot(arithm.P(4, 0)).End()
+	path := p.(*Path)
+	path.InsertKnotAt(1, arithm.P(1, 1))
+	if path.N() != 4 || path.Z(1) != arithm.P(1, 1) || path.PostDir(2) != arithm.P(1, 0) {
+		t.Errorf("insert did not shift parameters: %s", AsString(path, nil))
+	}
+	if !cmplx.IsNaN(path.PostDir(1).C()) || path.PreTension(3) != 2.0 {
+		t.Errorf("expected inserted knot to be smooth and tensions to be kept")
+	}
+	path.RemoveKnotAt(2)
+	if path.N() != 3 || path.Z(2) != arithm.P(4, 0) || path.PreTension(2) != 2.0 {
+		t.Errorf("remove did not shift parameters: %s", AsString(path, nil))
+	}
+	if !cmplx.IsNaN(path.PostDir(1).C()) {
+		t.Errorf("expected dir to be removed together with knot")
+	}
+	path.ReplaceKnot(0, arithm.P(-1, 0))
+	controls := FindHobbyControls(path, path.Controls)
+	if path.Z(0) != arithm.P(-1, 0) || len(Segments(path, controls)) != 2 {
+		t.Errorf("unexpected path after editing: %s", AsString(path, controls))
+	}
+}
+
+func TestResolveKnots(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		DirKnot(arithm.P(2, 0), arithm.P(1, 0)).Curve().Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(4, 0)).End()
+	controls = FindHobbyControls(path, controls)
+	before := controls.PostControl(2)
+	path.(*Path).ReplaceKnot(1, arithm.P(1, 2))
+	controls = ResolveKnots(path, controls, []int{1})
+	if controls.PostControl(2) != before {
+		t.Errorf("expected segment after breakpoint to remain unchanged")
+	}
+	expected := FindHobbyControls(path, nil)
+	for i := 0; i < path.N()-1; i++ {
+		if !controls.PostControl(i).Equal(expected.PostControl(i)) ||
+			!controls.PreControl(i+1).Equal(expected.PreControl(i+1)) {
+			t.Errorf("local re-solve differs from full solve at knot %d", i)
+		}
+	}
+}
+
+func TestParallelSolving(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p := Nullpath()
+	for i := 0; i < 200; i++ {
+		x := float64(i)
+		if i%3 == 0 {
+			p.DirKnot(arithm.P(x, math.Sin(x)), arithm.P(1, math.Cos(x))).Curve()
+		} else {
+			p.Knot(arithm.P(x, math.Sin(x))).Curve()
+		}
+	}
+	path, _ := p.Knot(arithm.P(200, 0)).End()
+	parallel := FindHobbyControls(path, nil, WithWorkers(4))
+	sequential := FindHobbyControls(path, nil, WithWorkers(1))
+	for i := 0; i < path.N()-1; i++ {
+		if parallel.PostControl(i) != sequential.PostControl(i) ||
+			parallel.PreControl(i+1) != sequential.PreControl(i+1) {
+			t.Fatalf("parallel solving differs from sequential solving at knot %d", i)
+		}
+	}
+}
+
+type clientKnots []arithm.Pair // a client's path type, e.g. from a DSL interpreter
+
+func (k clientKnots) IsCycle() bool          { return true }
+func (k clientKnots) N() int                 { return len(k) }
+func (k clientKnots) Z(i int) arithm.Pair    { return k[i] }
+func (k clientKnots) PreTension(int) float64 { return 2.0 }
+
+func TestAdaptKnots(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	knots := clientKnots{arithm.P(1, 1), arithm.P(2, 2), arithm.P(3, 1), arithm.P(2, 0)}
+	path := AdaptKnots(knots)
+	if path.Z(5) != knots[1] || path.PreTension(1) != 2.0 || path.PostTension(1) != 1.0 {
+		t.Errorf("adapter does not delegate correctly")
+	}
+	controls := FindHobbyControls(path, nil)
+	tense, _ := Nullpath().Knot(knots[0]).TensionCurve(1, 2).Knot(knots[1]).TensionCurve(1, 2).
+		Knot(knots[2]).TensionCurve(1, 2).Knot(knots[3]).TensionCurve(1, 2).Cycle()
+	expected := FindHobbyControls(tense, nil)
+	for i := range knots {
+		if !controls.PostControl(i).Equal(expected.PostControl(i)) {
+			t.Errorf("adapted path solved differently at knot %d", i)
+		}
+	}
+}
+
+func TestCheckedBuilder(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if !panics(func() { Nullpath().Curve() }) {
+		t.Errorf("expected unchecked builder to panic")
+	}
+	path := CheckedNullpath()
+	path.Curve().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).End()
+	if path.Err() == nil || path.N() != 2 {
+		t.Errorf("expected error for curve on empty path, and builder to continue")
+	}
+	path = CheckedNullpath()
+	err := path.Knot(arithm.P(0, 0)).TensionCurve(math.NaN(), 1).Knot(arithm.P(1, 1)).Err()
+	if err == nil {
+		t.Errorf("expected error for non-finite tension")
+	}
+	path = CheckedNullpath()
+	if path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Err() != nil {
+		t.Errorf("expected no error for well-formed path")
+	}
+}
+
+func TestClone(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	path.SetPostDir(1, arithm.P(1, 0))
+	FindHobbyControls(path, controls)
+	clone := path.Clone()
+	clone.ReplaceKnot(0, arithm.P(0, 0)).SetPostDir(1, arithm.P(0, 1)).SetPostTension(1, 2)
+	FindHobbyControls(clone, clone.Controls)
+	if path.Z(0) != arithm.P(1, 1) || path.PostDir(1) != arithm.P(1, 0) || path.PostTension(1) != 1.0 {
+		t.Errorf("editing a clone changed the original path")
+	}
+	if clone.PostDir(1) != arithm.P(0, 1) || controls.PostControl(0) == clone.Controls.PostControl(0) {
+		t.Errorf("expected clone to copy parameters and have separate controls")
+	}
+}
+
+func TestCloneArcJoin(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(1, 0)).ArcJoin(1, 1, 0, false, true)
+	clone := path.Clone()
+	for _, p := range []*Path{clone, path} { // half circle from (1,0) to (-1,0)
+		p.Knot(arithm.P(-1, 0)).End()
+		controls := FindHobbyControls(p, p.Controls)
+		if p.N() != 3 || !equalPair(p.Z(1), arithm.P(0, 1), 1e-9) {
+			t.Fatalf("expected pending arc to be resolved, have %s", AsString(p, controls))
+		}
+		if r := cmplx.Abs(PointAt(p, controls, 0.5).C()); math.Abs(r-1) > 1e-3 {
+			t.Errorf("expected point on unit circle, distance is %g", r)
+		}
+	}
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().DirKnot(arithm.P(1, 1), arithm.P(1, 0)).
+		TensionCurve(2, 1).Knot(arithm.P(2, 0)).ExplicitCurve(arithm.P(2, -1), arithm.P(1, -1)).Cycle()
+	controls := FindHobbyControls(path, path.(*Path).Controls)
+	data, err := path.(*Path).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Path{}
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsCycle() || decoded.N() != path.N() {
+		t.Fatalf("expected decoded cycle with %d knots, have %d", path.N(), decoded.N())
+	}
+	for i := 0; i < path.N(); i++ {
+		if decoded.Z(i) != path.Z(i) || decoded.PostTension(i) != path.PostTension(i) ||
+			decoded.Controls.PostControl(i) != controls.PostControl(i) ||
+			decoded.Controls.PreControl(i) != controls.PreControl(i) {
+			t.Errorf("knot #%d differs after decoding", i)
+		}
+	}
+	if decoded.PostDir(1) != arithm.P(1, 0) || !cmplx.IsNaN(decoded.PostDir(0).C()) {
+		t.Errorf("expected directions to survive decoding")
+	}
+	if err = decoded.UnmarshalBinary(data[:len(data)-3]); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+}
+
+func TestBinaryEncodingSize(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	const n = 100
+	glyph, circle := Nullpath(), Nullpath()
+	for i := 0; i < n; i++ { // an "o" in font units, and a unit circle
+		a := 2 * math.Pi * float64(i) / n
+		glyph.Knot(arithm.P(math.Round(250+230*math.Cos(a)), math.Round(260+270*math.Sin(a)))).Curve()
+		circle.Knot(arithm.P(math.Cos(a), math.Sin(a))).Curve()
+	}
+	for _, p := range []*Path{glyph, circle} {
+		p.Cycle()
+		FindHobbyControls(p, p.Controls)
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw := n * 3 * 16 // knots and control points as pairs of float64
+		if len(data) >= raw {
+			t.Errorf("expected encoding to be smaller than %d bytes, is %d", raw, len(data))
+		}
+		t.Logf("encoded path of %d knots into %d bytes, raw size is %d", n, len(data), raw)
+	}
+}
+
+func TestBinaryEncodingTolerance(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).End()
+	p := path.(*Path).SetEpsilon(0.01)
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Nullpath()
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Epsilon() != 0.01 {
+		t.Errorf("expected tolerance to survive binary encoding, is %g", decoded.Epsilon())
+	}
+}
+
+// SourcePos is client data of knots for TestBinaryEncodingKnotData.
+type SourcePos struct{ Line, Col int }
+
+func TestBinaryEncodingKnotData(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	gob.Register(SourcePos{})
+	path, _ := Nullpath().KnotLabeled(arithm.P(0, 0), "start").Curve().Knot(arithm.P(1, 1)).
+		Curve().Knot(arithm.P(2, 0)).End()
+	p := path.(*Path).SetKnotData(1, SourcePos{3, 14})
+	FindHobbyControls(p, p.Controls)
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Nullpath()
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(p, p.Controls, decoded, decoded.Controls, 0) {
+		t.Errorf("expected decoded path to be equal, have %s", AsString(decoded, decoded.Controls))
+	}
+	if decoded.Label(0) != "start" || decoded.KnotData(1) != (SourcePos{3, 14}) || decoded.KnotData(2) != nil {
+		t.Errorf("expected labels and client data to survive binary encoding")
+	}
+	type unregistered struct{ x int }
+	p.SetKnotData(2, unregistered{1})
+	if _, err = p.MarshalBinary(); err == nil {
+		t.Errorf("expected error for client data gob cannot encode")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p1, c1 := Nullpath().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 2)).Curve().
+		Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(2, 0)).Curve().Cycle()
+	p2, c2 := Nullpath().Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(2, 0)).Curve().
+		Knot(arithm.P(1, 1+1e-9)).Curve().Knot(arithm.P(2, 2)).Curve().Cycle()
+	if !Equal(p1, nil, p2, nil, 1e-6) {
+		t.Errorf("expected rotated cycles to be equal")
+	}
+	c1 = FindHobbyControls(p1, c1)
+	if Equal(p1, c1, p2, c2, 1e-6) {
+		t.Errorf("expected solved and unsolved paths to differ")
+	}
+	c2 = FindHobbyControls(p2, c2)
+	if !Equal(p1, c1, p2, c2, 1e-6) || Equal(p1, c1, p2, c2, 1e-12) {
+		t.Errorf("expected solved cycles to be equal within tolerance only")
+	}
+	p3, _ := testpath()
+	if Equal(p1, nil, p3, nil, 1e-6) {
+		t.Errorf("expected cycle and open path to differ")
+	}
+}
+
+func TestCornerKnot(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().
+		CornerKnot(arithm.P(1, 1), arithm.P(1, 0), arithm.P(0, 1)).Curve().Knot(arithm.P(2, 3)).End()
+	controls = FindHobbyControls(path, controls)
+	in := controls.PreControl(1) - path.Z(1)
+	out := controls.PostControl(1) - path.Z(1)
+	if !arithm.Is0(in.Y()) || in.X() >= 0 || !arithm.Is0(out.X()) || out.Y() <= 0 {
+		t.Errorf("expected corner with tangents right and up, have %v and %v", in, out)
+	}
+	path, _ = Nullpath().Knot(arithm.P(0, 0)).Curve().PreDirKnot(arithm.P(1, 1), arithm.P(1, 0)).
+		Curve().PostDirKnot(arithm.P(2, 3), arithm.P(0, 1)).End()
+	if path.PreDir(1) != arithm.P(1, 0) || !cmplx.IsNaN(path.PostDir(1).C()) || path.PostDir(2) != arithm.P(0, 1) {
+		t.Errorf("expected one-sided directions to be set")
+	}
+}
+
+func TestBoundedCurve(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	out := arithm.P(math.Cos(10*math.Pi/180), math.Sin(10*math.Pi/180))
+	in := arithm.P(math.Cos(80*math.Pi/180), -math.Sin(80*math.Pi/180))
+	path, controls := Nullpath().PostDirKnot(arithm.P(0, 0), out).Curve().
+		PreDirKnot(arithm.P(1, 0), in).End()
+	controls = FindHobbyControls(path, controls)
+	apex := math.Sin(10*math.Pi/180) / math.Sin(90*math.Pi/180) // distance of tangent intersection from z1
+	if cmplx.Abs((controls.PreControl(1) - path.Z(1)).C()) <= apex {
+		t.Fatalf("test setup: expected ordinary curve to leave the triangle")
+	}
+	path, controls = Nullpath().PostDirKnot(arithm.P(0, 0), out).BoundedCurve().
+		PreDirKnot(arithm.P(1, 0), in).End()
+	controls = FindHobbyControls(path, controls)
+	if d := cmplx.Abs((controls.PreControl(1) - path.Z(1)).C()); d > apex+1e-9 {
+		t.Errorf("expected bounded curve to stay within triangle, control is %.4f away from knot", d)
+	}
+	if path.PostTension(0) != -1.0 || path.PreTension(1) != -1.0 {
+		t.Errorf("expected tensions \"at least 1\"")
+	}
+}
+
+func TestBoundControlsAtLimit(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	theta, phi := math.Pi/6, math.Pi/3 // sin(theta+phi) = 1
+	limit2, limit3 := math.Sin(phi), math.Sin(theta)
+	dvec := arithm.P(2, 0)
+	for _, f := range []float64{1, 1 - 1.0/8192} { // at the bound, and just below
+		p2 := arithm.P(2*f*limit2, 0)
+		p3 := arithm.P(0, 2*f*limit3)
+		b2, b3 := boundControls(theta, phi, p2, p3, dvec, true, true)
+		// MetaFont §299: sine is enlarged by fraction_one+unity, i.e. by 2^-12
+		want2, want3 := 2*limit2/(1+1.0/4096), 2*limit3/(1+1.0/4096)
+		if r := cmplx.Abs(b2.C()); math.Abs(r-want2) > 1e-12 {
+			t.Errorf("f=%g: expected post-control offset to be clipped to %.9f, is %.9f", f, want2, r)
+		}
+		if s := cmplx.Abs(b3.C()); math.Abs(s-want3) > 1e-12 {
+			t.Errorf("f=%g: expected pre-control offset to be clipped to %.9f, is %.9f", f, want3, s)
+		}
+	}
+	p2 := arithm.P(2*limit2*(1-1.0/2048), 0) // well within the bound
+	if b2, _ := boundControls(theta, phi, p2, p2, dvec, true, false); b2 != p2 {
+		t.Errorf("expected control offset within bound to be unchanged, is %v", b2)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p1, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).End()
+	p2, _ := Nullpath().Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(4, 0)).End()
+	path, controls := Nullpath().AppendSubpath(p1.(*Path)).Concat(p2.(*Path)).End()
+	if path.N() != 5 || !isrough(path, 2) {
+		t.Fatalf("expected 5 knots with rough junction, have %s", AsString(path, nil))
+	}
+	controls = FindHobbyControls(path, controls)
+	in := path.Z(2) - controls.PreControl(2)
+	out := controls.PostControl(2) - path.Z(2)
+	if arithm.Is0(cmplx.Phase(in.C()) - cmplx.Phase(out.C())) {
+		t.Errorf("expected a corner at the junction, have %v and %v", in, out)
+	}
+	smooth, _ := Nullpath().AppendSubpath(p1.(*Path)).Curve().AppendSubpath(p2.(*Path)).End()
+	if smooth.N() != 6 || isrough(smooth, 2) {
+		t.Errorf("expected 6 knots without breakpoint, have %s", AsString(smooth, nil))
+	}
+	checked := CheckedNullpath()
+	if checked.Knot(arithm.P(5, 5)).Concat(p2.(*Path)).Err() == nil {
+		t.Errorf("expected error for concatenation without common knot")
+	}
+}
+
+func TestLineIsStraight(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Line().
+		Knot(arithm.P(2, 1)).Curve().Knot(arithm.P(3, 0)).End()
+	controls = FindHobbyControls(path, controls)
+	if !arithm.Is0(controls.PostControl(1).Y()-1) || !arithm.Is0(controls.PreControl(2).Y()-1) {
+		t.Errorf("expected straight line between (1,1) and (2,1), have %s", AsString(path, controls))
+	}
+}
+
+// As in MetaFont, "z1 -- z2" is short for "z1{curl 1}..{curl 1}z2": both
+// knots of a line are breakpoints, the line is straight, and adjacent curves
+// end with curl 1.
+func TestLineBreaksPath(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 1)).Line().
+		Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(4, 3)).Curve().Knot(arithm.P(5, 0)).End()
+	if !isrough(path, 2) || !isrough(path, 3) || isrough(path, 1) {
+		t.Errorf("expected both ends of the line to be breakpoints")
+	}
+	if segments := splitSegments(path, newSolveConfig(nil)); len(segments) != 3 {
+		t.Fatalf("expected path to be split into 3 segments, have %d", len(segments))
+	}
+	controls = FindHobbyControls(path, controls)
+	if !equalPair(controls.PostControl(2), arithm.P(7.0/3, 1), 1e-9) || !equalPair(controls.PreControl(3), arithm.P(8.0/3, 1), 1e-9) {
+		t.Errorf("expected line controls at 1/3 of the chord, have %s", AsString(path, controls))
+	}
+	head, hcontrols := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 1)).End()
+	hcontrols = FindHobbyControls(head, hcontrols)
+	for i := 0; i < 2; i++ { // curve ends at the line with curl 1, like an open path
+		if !equalPair(controls.PostControl(i), hcontrols.PostControl(i), 1e-9) ||
+			!equalPair(controls.PreControl(i+1), hcontrols.PreControl(i+1), 1e-9) {
+			t.Errorf("expected curve before line to end with curl 1, have %s", AsString(path, controls))
+		}
+	}
+}
+
+func TestCurvatureContinuity(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	open, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 3)).Curve().
+		Knot(arithm.P(5, 3)).Curve().Knot(arithm.P(7, 0)).Curve().Knot(arithm.P(8, 2)).End()
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 3)).Curve().
+		Knot(arithm.P(5, 3)).Curve().Knot(arithm.P(3, -1)).Curve().Cycle()
+	for _, path := range []HobbyPath{open, cycle} {
+		g1 := maxCurvatureJump(path, FindHobbyControls(path, nil))
+		g2 := maxCurvatureJump(path, FindHobbyControls(path, nil, WithCurvatureContinuity()))
+		if g1 < 1e-3 || g2 > 1e-8 {
+			t.Errorf("expected curvature jump of G2 solution to vanish, have %g (G1: %g)", g2, g1)
+		}
+	}
+	for _, scale := range []float64{1e-6, 1e4} { // the tolerance scales with the path
+		scaled := open.(*Path).Transformed(arithm.Scaling(scale, scale)).(*Path).SetEpsilon(1e-7 * scale)
+		report := &SolveReport{}
+		FindHobbyControls(scaled, nil, WithCurvatureContinuity(), WithReport(report))
+		if len(report.Segments) != 1 || !report.Segments[0].Converged {
+			t.Errorf("expected G2 refinement to converge for path scaled by %g", scale)
+		}
+	}
+	cycle.(*Path).SetCurvatureContinuity(true)
+	if !Equal(cycle, FindHobbyControls(cycle, nil), cycle, FindHobbyControls(cycle, nil, WithCurvatureContinuity()), 0) {
+		t.Errorf("expected path to select G2 solution")
+	}
+	data, err := cycle.(*Path).MarshalBinary()
+	decoded := &Path{}
+	if err != nil || decoded.UnmarshalBinary(data) != nil || !decoded.CurvatureContinuity() {
+		t.Errorf("expected binary encoding to keep curvature continuity")
+	}
+}
+
+func maxCurvatureJump(path HobbyPath, controls SplineControls) float64 {
+	segs := Segments(path, controls)
+	jump := 0.0
+	for i := 1; i <= len(segs); i++ {
+		if i == len(segs) && !path.IsCycle() {
+			break
+		}
+		in, out := segs[i-1], segs[i%len(segs)]
+		k := endCurvature(in.C1, in.C2, in.P3, _epsilon) - startCurvature(out.P0, out.C1, out.C2, _epsilon)
+		jump = math.Max(jump, math.Abs(k))
+	}
+	return jump
+}
+
+// As in MetaFont and MetaPost, the velocity of the control point before
+// z.[i+1] is Hobby's velocity function with theta and phi swapped, divided by
+// the tension before z.[i+1].
+func TestMetaPostVelocity(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	dir := func(deg float64) arithm.Pair {
+		return arithm.P(math.Cos(deg*math.Pi/180), math.Sin(deg*math.Pi/180))
+	}
+	// control points as calculated by MetaPost's velocity function (mp.w)
+	tests := []struct {
+		z0, z1    arithm.Pair
+		d0, d1    float64 // directions at z0 and z1, in degrees
+		t0, t1    float64 // tensions after z0 and before z1
+		post, pre arithm.Pair
+	}{
+		{arithm.P(0, 0), arithm.P(1, 0), 45, -45, 1, 1, arithm.P(0.27614, 0.27614), arithm.P(0.72386, 0.27614)},
+		{arithm.P(0, 0), arithm.P(1, 0), 30, -60, 1, 1, arithm.P(0.36668, 0.21170), arithm.P(0.81631, 0.31817)},
+		{arithm.P(0, 0), arithm.P(3, 1), 90, 0, 1, 1, arithm.P(0, 1.20886), arithm.P(1.63324, 1)},
+		{arithm.P(1, 2), arithm.P(4, 0), 10, -80, 1.5, 1, arithm.P(1.93174, 2.16429), arithm.P(3.75738, 1.37597)},
+		{arithm.P(0, 0), arithm.P(4, 1), 60, -30, 1.2, 2, arithm.P(0.66709, 1.15543), arithm.P(3.29874, 1.40487)},
+		{arithm.P(0, 0), arithm.P(4, 1), 60, -30, 2, 1.2, arithm.P(0.40025, 0.69326), arithm.P(2.83123, 1.67479)},
+	}
+	for _, test := range tests {
+		path, controls := Nullpath().PostDirKnot(test.z0, dir(test.d0)).TensionCurve(test.t0, test.t1).
+			PreDirKnot(test.z1, dir(test.d1)).End()
+		controls = FindHobbyControls(path, controls)
+		if !equalPair(controls.PostControl(0), test.post, 5e-5) || !equalPair(controls.PreControl(1), test.pre, 5e-5) {
+			t.Errorf("expected controls %v and %v, have %s", test.post, test.pre, AsString(path, controls))
+		}
+	}
+}
+
+func TestMirrored(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(1, 1)).Curve().DirKnot(arithm.P(2, 2), arithm.P(1, 0)).
+		Curve().Knot(arithm.P(4, 1)).Curve().Knot(arithm.P(2, 0)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	mirrored := path.(*Path).MirroredX()
+	if mirrored.Z(0) != arithm.P(-1, 1) || mirrored.Z(1) != arithm.P(-2, 0) || mirrored.Z(3) != arithm.P(-2, 2) {
+		t.Errorf("expected mirrored cycle in reverse order, have %s", AsString(mirrored, nil))
+	}
+	if mirrored.PreDir(3) != arithm.P(1, 0) {
+		t.Errorf("expected direction of mirrored, reversed knot to be right, is %v", mirrored.PreDir(3))
+	}
+	resolved := FindHobbyControls(mirrored, nil)
+	if !Equal(mirrored, mirrored.Controls, mirrored, resolved, 1e-6) {
+		t.Errorf("expected mirrored controls to match solution of mirrored path")
+	}
+	twice := path.(*Path).ReflectedAbout(arithm.P(0, 1), arithm.P(1, 2)).ReflectedAbout(arithm.P(0, 1), arithm.P(1, 2))
+	if !Equal(path, controls, twice, twice.Controls, 1e-9) {
+		t.Errorf("expected reflecting twice to restore the path, have %s", AsString(twice, twice.Controls))
+	}
+	if p := path.(*Path).MirroredY(); p.Z(0) != arithm.P(1, -1) {
+		t.Errorf("expected y-coordinates to be negated, have %s", AsString(p, nil))
+	}
+}
+
+func TestTransformed(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	path.SetPostDir(1, arithm.P(1, 0))
+	controls = FindHobbyControls(path, controls)
+	shifted := path.Shifted(arithm.P(1, 2))
+	if shifted.Z(0) != arithm.P(2, 3) || shifted.Controls.PostControl(0) != controls.PostControl(0)+arithm.P(1, 2) {
+		t.Errorf("expected knots and controls to be shifted, have %s", AsString(shifted, shifted.Controls))
+	}
+	for _, p := range []*Path{shifted, path.Scaled(2), path.Rotated(math.Pi / 3), path.ZScaled(arithm.P(1, 1))} {
+		resolved := FindHobbyControls(p, nil)
+		if !Equal(p, p.Controls, p, resolved, 1e-9) {
+			t.Errorf("expected transformed controls to match solution of transformed path")
+		}
+	}
+	if dir := path.ZScaled(arithm.P(0, 2)).PostDir(1); !arithm.Is0(dir.X()) || !arithm.Is1(dir.Y()/2) {
+		t.Errorf("expected zscaled direction to be rotated by 90°, is %v", dir)
+	}
+	if path.Z(0) != arithm.P(1, 1) {
+		t.Errorf("expected original path to be unchanged")
+	}
+}
+
+func TestArbitraryPrecision(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	builders := []func() (HobbyPath, SplineControls){
+		func() (HobbyPath, SplineControls) {
+			return Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 3)).TensionCurve(1.5, 2).
+				Knot(arithm.P(5, 3)).Curve().CurlKnot(arithm.P(6, 0), 2, 2).Curve().Knot(arithm.P(8, 1)).End()
+		},
+		func() (HobbyPath, SplineControls) {
+			return Nullpath().PostDirKnot(arithm.P(0, 0), arithm.P(0, 1)).Curve().Knot(arithm.P(2, 1)).
+				Curve().PreDirKnot(arithm.P(4, 0), arithm.P(1, -1)).End()
+		},
+		func() (HobbyPath, SplineControls) {
+			return Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 3)).Curve().
+				Knot(arithm.P(5, 3)).Curve().Knot(arithm.P(3, -1)).Curve().Cycle()
+		},
+	}
+	for k, build := range builders {
+		path, controls := build()
+		controls = FindHobbyControls(path, controls)
+		bpath, bcontrols := build()
+		bcontrols = FindHobbyControls(bpath, bcontrols, WithArbitraryPrecision(200))
+		if !Equal(path, controls, bpath, bcontrols, 1e-9) {
+			t.Errorf("path #%d: expected high precision solution to agree, have\n%s\nand\n%s",
+				k, AsString(path, controls), AsString(bpath, bcontrols))
+		}
+	}
+}
+
+func TestTracingSink(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	var buf bytes.Buffer
+	controls = FindHobbyControls(path, controls, WithTracing(&buf))
+	if !strings.Contains(buf.String(), "controls") {
+		t.Errorf("expected solution to be traced to sink, have %q", buf.String())
+	}
+	buf.Reset()
+	open, ocontrols := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).
+		Curve().DirKnot(arithm.P(2, 0), arithm.P(1, 0)).Curve().Knot(arithm.P(3, 1)).End()
+	FindHobbyControls(open, ocontrols, WithTracing(&buf), WithCurvatureContinuity())
+	for _, s := range []string{"breaking segment", "u.0 = ", "theta.1 = "} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected solver tracing %q in sink, have %q", s, buf.String())
+		}
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).ExplicitCurve(arithm.P(0, 1), arithm.P(1, 2)).
+		Knot(arithm.P(2, 2)).Line().Knot(arithm.P(3, 1.0/3)).End()
+	controls = FindHobbyControls(path, controls)
+	expected := map[Formatter]string{
+		MetaPostFormatter(WithPrecision(2)): "(0,0)..controls (0,1) and (1,2)..(2,2)..controls (2.33,1.44) and (2.67,0.89)..(3,0.33)",
+		SVGFormatter(WithPrecision(1)):      "M0 0 C0 1 1 2 2 2 C2.3 1.4 2.7 0.9 3 0.3",
+	}
+	for f, s := range expected {
+		if out := f.Format(path, controls); out != s {
+			t.Errorf("expected %q, have %q", s, out)
+		}
+	}
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(1, 0)).Line().Knot(arithm.P(1, 1)).Line().Cycle()
+	if out := TikZFormatter().Format(cycle, nil); out != "(0,0) -- (1,0) -- (1,1) -- cycle" {
+		t.Errorf("unexpected TikZ output %q", out)
+	}
+	if out := MetaPostFormatter().Format(cycle, nil); out != "(0,0)..(1,0)..(1,1)..cycle" {
+		t.Errorf("unexpected MetaPost output %q", out)
+	}
+	var v struct {
+		Cycle bool
+		Knots []struct{ Z, Pre, Post []float64 }
+	}
+	if err := json.Unmarshal([]byte(JSONFormatter().Format(path, controls)), &v); err != nil {
+		t.Fatalf("expected valid JSON, have error %v", err)
+	}
+	if v.Cycle || len(v.Knots) != 3 || v.Knots[0].Pre != nil || v.Knots[1].Pre[1] != 2 {
+		t.Errorf("unexpected JSON content %v", v)
+	}
+}
+
+func TestControlsSegment(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	segs := controls.(BezierSegments)
+	if segs.NumSegments() != 2 {
+		t.Errorf("expected 2 segments, have %d", segs.NumSegments())
+	}
+	p0, c1, _, p3 := segs.Segment(1)
+	if p0 != arithm.P(2, 2) || p3 != arithm.P(3, 1) || c1 != p0 {
+		t.Errorf("expected straight placeholder segment from (2,2) to (3,1)")
+	}
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Cycle()
+	segs = FindHobbyControls(cycle, nil).(BezierSegments)
+	if _, _, c2, p3 := segs.Segment(2); segs.NumSegments() != 3 || p3 != arithm.P(0, 0) || cmplx.IsNaN(c2.C()) {
+		t.Errorf("expected closing segment to end at first knot")
+	}
+	if !panics(func() { path.Controls.Segment(2) }) {
+		t.Errorf("expected panic for invalid segment index")
+	}
+}
+
+// panics reports whether f panics.
+func panics(f func()) (p bool) {
+	defer func() {
+		p = recover() != nil
+	}()
+	f()
+	return false
+}
+
+type recordingSink []string
+
+func (s *recordingSink) MoveTo(p arithm.Pair) {
+	*s = append(*s, fmt.Sprintf("M%v", p))
+}
+
+func (s *recordingSink) CurveTo(c1, c2, p arithm.Pair) {
+	*s = append(*s, fmt.Sprintf("C%v", p))
+}
+
+func (s *recordingSink) ClosePath() {
+	*s = append(*s, "Z")
+}
+
+func TestRender(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	sink := &recordingSink{}
+	Render(path, controls, sink)
+	if len(*sink) != 5 || (*sink)[0] != "M(0,0)" || (*sink)[3] != "C(0,0)" || (*sink)[4] != "Z" {
+		t.Errorf("unexpected rendering sequence %v", *sink)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 0)).Curve().
+		Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(0, 2)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	fp := Fingerprint(path, controls)
+	rotated, rcontrols := Nullpath().Knot(arithm.P(2, 2)).Curve().Knot(arithm.P(0, 2)).Curve().
+		Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 0)).Curve().Cycle()
+	rcontrols = FindHobbyControls(rotated, rcontrols)
+	if Fingerprint(rotated, rcontrols) != fp {
+		t.Errorf("expected fingerprint of cycle to be independent of starting knot")
+	}
+	if Fingerprint(path, nil) == fp {
+		t.Errorf("expected fingerprint to depend on control points")
+	}
+	moved := path.(*Path).Shifted(arithm.P(0, 0.01))
+	if Fingerprint(moved, moved.Controls) == fp {
+		t.Errorf("expected fingerprint of shifted path to differ")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().KnotLabeled(arithm.P(1, 1), "shoulder").Curve().
+		KnotLabeled(arithm.P(2, 0), "hand").End()
+	p := path.(*Path)
+	if i, ok := p.KnotByLabel("shoulder"); !ok || i != 1 {
+		t.Errorf("expected knot #1 to be labeled 'shoulder', have %d", i)
+	}
+	if _, ok := p.KnotByLabel("elbow"); ok {
+		t.Errorf("did not expect to find knot labeled 'elbow'")
+	}
+	moved := p.Shifted(arithm.P(1, 0)).InsertKnotAt(0, arithm.P(-1, 0))
+	if i, _ := moved.KnotByLabel("hand"); i != 3 || moved.Z(i) != arithm.P(3, 0) {
+		t.Errorf("expected label 'hand' to stick to knot (3,0) after transform and insert")
+	}
+	arm, _ := Nullpath().KnotLabeled(arithm.P(2, 0), "wrist").Curve().KnotLabeled(arithm.P(3, 1), "finger").End()
+	whole, _ := p.Clone().Concat(arm.(*Path)).End()
+	w := whole.(*Path)
+	if w.N() != 4 || w.Label(2) != "hand" || w.Label(3) != "finger" {
+		t.Errorf("expected labels to survive concatenation, have %q, %q", w.Label(2), w.Label(3))
+	}
+	cycle, _ := Nullpath().KnotLabeled(arithm.P(0, 0), "a").Curve().KnotLabeled(arithm.P(1, 0), "b").Curve().
+		KnotLabeled(arithm.P(1, 1), "c").Curve().Cycle()
+	mirrored := cycle.(*Path).MirroredX()
+	if mirrored.Label(1) != "c" || mirrored.Z(1) != arithm.P(-1, 1) {
+		t.Errorf("expected label 'c' at knot #1 of mirrored cycle, have %q", mirrored.Label(1))
+	}
+	data, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected labeled path to encode, have %v", err)
+	}
+	decoded := Nullpath()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected labeled path to decode, have %v", err)
+	}
+	if decoded.Label(1) != "shoulder" || decoded.Label(3) != "finger" || decoded.Label(0) != "" {
+		t.Errorf("expected labels to survive binary encoding")
+	}
+}
+
+func TestKnotData(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	type srcpos struct{ line, col int }
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Line().
+		Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 1)).End()
+	p := path.(*Path)
+	p.SetKnotData(2, srcpos{3, 14}).SetKnotData(3, []string{"z3"})
+	FindHobbyControls(path, controls)
+	if d, ok := p.KnotData(2).(srcpos); !ok || d.line != 3 {
+		t.Errorf("expected source position as data of knot #2, have %v", p.KnotData(2))
+	}
+	if p.KnotData(0) != nil {
+		t.Errorf("expected knot #0 to carry no data")
+	}
+	segments := splitSegments(path, newSolveConfig(nil))
+	last := segments[len(segments)-1]
+	if last.KnotData(0) != p.KnotData(2) {
+		t.Errorf("expected segment to delegate to data of whole path")
+	}
+	rev := p.RemoveKnotAt(0)
+	if d, ok := rev.KnotData(2).([]string); !ok || d[0] != "z3" {
+		t.Errorf("expected data to stick to knot after removing knot #0")
+	}
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(2, 0)).Curve().Cycle()
+	c := cycle.(*Path)
+	c.SetLabel(-1, "last").SetKnotData(4, "second")
+	if c.Label(2) != "last" || c.Label(-1) != "last" || c.Label(5) != "last" {
+		t.Errorf("expected label of knot #-1 to wrap to knot #2, have %q", c.Label(2))
+	}
+	if c.KnotData(1) != "second" || c.KnotData(-2) != "second" {
+		t.Errorf("expected data of knot #4 to wrap to knot #1, have %v", c.KnotData(1))
+	}
+	p.SetLabel(-1, "none").SetKnotData(-1, "none")
+	if p.Label(-1) != "" || p.KnotData(-1) != nil {
+		t.Errorf("expected negative indices of open path to be ignored")
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(1, 1+1e-9)).Curve().Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 1)).End()
+	controls = FindHobbyControls(path, controls, WithMergeDuplicates(1e-6))
+	clean, ccontrols := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 1)).End()
+	ccontrols = FindHobbyControls(clean, ccontrols)
+	if !equalPair(controls.PostControl(0), ccontrols.PostControl(0), 1e-9) ||
+		!equalPair(controls.PreControl(1), ccontrols.PreControl(1), 1e-9) ||
+		!equalPair(controls.PostControl(2), ccontrols.PostControl(1), 1e-9) ||
+		!equalPair(controls.PreControl(4), ccontrols.PreControl(3), 1e-9) {
+		t.Errorf("expected merged path to have controls of path without duplicate")
+	}
+	if controls.PostControl(1) != arithm.P(1, 1) || controls.PreControl(2) != arithm.P(1, 1) {
+		t.Errorf("expected join between duplicates to have controls at knot")
+	}
+	cycle, cycontrols := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 0)).Curve().
+		Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(0, 0)).Curve().Cycle()
+	cycontrols = FindHobbyControls(cycle, cycontrols, WithMergeDuplicates(1e-6))
+	for i := 0; i < cycle.N(); i++ {
+		if cmplx.IsNaN(cycontrols.PreControl(i).C()) || cmplx.IsNaN(cycontrols.PostControl(i).C()) {
+			t.Errorf("expected control points of knot #%d to be defined", i)
+		}
+	}
+}
+
+func TestEpsilon(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1000, 0)).End()
+	p := path.(*Path)
+	if p.Epsilon() != _epsilon {
+		t.Errorf("expected default tolerance, have %g", p.Epsilon())
+	}
+	sp, _ := Nullpath().Knot(arithm.P(1000.001, 0)).Curve().Knot(arithm.P(2000, 500)).End()
+	checked := CheckedNullpath()
+	checked.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1000, 0)).Concat(sp.(*Path))
+	if checked.Err() == nil {
+		t.Errorf("expected knots to not coincide with default tolerance")
+	}
+	checked = CheckedNullpath()
+	checked.SetEpsilon(0.01).Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1000, 0)).Concat(sp.(*Path))
+	if checked.Err() != nil || checked.N() != 3 {
+		t.Errorf("expected knots to coincide with tolerance 0.01, error is %v", checked.Err())
+	}
+	conf := newSolveConfig([]SolveOption{WithEpsilon(0.5)})
+	conf.fromPath(checked)
+	if conf.eps != 0.5 {
+		t.Errorf("expected solve option to override tolerance of path, have %g", conf.eps)
+	}
+	conf = newSolveConfig(nil)
+	conf.fromPath(checked)
+	if conf.eps != 0.01 {
+		t.Errorf("expected tolerance of path to be used for solving, have %g", conf.eps)
+	}
+}
+
+func TestArcLengthTable(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	// circle of radius 1, approximated by Hobby's algorithm
+	path, controls := Nullpath().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(0, 1)).Curve().
+		Knot(arithm.P(-1, 0)).Curve().Knot(arithm.P(0, -1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	tab := NewArcLengthTable(path, controls)
+	if l := tab.Length(); math.Abs(l-2*math.Pi) > 1e-2 {
+		t.Errorf("expected length of circle to be 2π, is %g", l)
+	}
+	if l := tab.LengthAtTime(1); math.Abs(l-tab.Length()/4) > 1e-6 {
+		t.Errorf("expected quarter circle at time 1, have length %g", l)
+	}
+	for _, s := range []float64{0.1, 1, 2.5, 4, 6} {
+		if l := tab.LengthAtTime(tab.TimeAtLength(s)); math.Abs(l-s) > 1e-9 {
+			t.Errorf("expected LengthAtTime(TimeAtLength(%g)) = %g, have %g", s, s, l)
+		}
+	}
+	if tm := tab.TimeAtLength(100); tm != 4 {
+		t.Errorf("expected time to be clamped to 4, is %g", tm)
+	}
+	path.(*Path).ReplaceKnot(0, arithm.P(2, 0))
+	FindHobbyControls(path, controls)
+	if l := tab.Length(); l < 2*math.Pi+0.5 {
+		t.Errorf("expected table to be rebuilt after re-solving, length is %g", l)
+	}
+}
+
+func TestNormalAt(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(0, 1)).Curve().
+		Knot(arithm.P(-1, 0)).Curve().Knot(arithm.P(0, -1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	if n := NormalAt(path, controls, 1); !equalPair(n, arithm.P(0, -1), 1e-6) {
+		t.Errorf("expected normal at top of ccw circle to point inside, is %v", n)
+	}
+	if p := OffsetPointAt(path, controls, 6, 0.5); !equalPair(p, arithm.P(-0.5, 0), 1e-6) {
+		t.Errorf("expected offset point at (-0.5,0) for time 6 ≡ 2, is %v", p)
+	}
+	line, lcontrols := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(2, 0)).End()
+	lcontrols = FindHobbyControls(line, lcontrols)
+	if p := OffsetPointAt(line, lcontrols, 0.5, 1); !equalPair(p, arithm.P(1, 1), 1e-9) {
+		t.Errorf("expected offset point (1,1) on line, is %v", p)
+	}
+	if d := DirectionAt(line, nil, 1); !equalPair(d, arithm.P(1, 0), 1e-9) {
+		t.Errorf("expected direction (1,0) at end of straight segment, is %v", d)
+	}
+	// the first control point is 0.001 off the knot, which is below a
+	// tolerance of 0.01
+	hook, hcontrols := Nullpath().Knot(arithm.P(0, 0)).ExplicitCurve(arithm.P(0.001, 0.001), arithm.P(1, 0)).
+		Knot(arithm.P(2, 0)).End()
+	hcontrols = FindHobbyControls(hook, hcontrols)
+	if d := DirectionAt(hook, hcontrols, 0); !equalPair(d, arithm.P(1, 1).Unit(), 1e-9) {
+		t.Errorf("expected direction towards first control point, is %v", d)
+	}
+	hook.(*Path).SetEpsilon(0.01)
+	if d := DirectionAt(hook, hcontrols, 0); !equalPair(d, arithm.P(1, 0), 1e-9) {
+		t.Errorf("expected direction to skip control point within tolerance, is %v", d)
+	}
+}
+
+func TestSolveReport(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(2, 0)).Line().Knot(arithm.P(3, 0)).End()
+	report := &SolveReport{}
+	controls = FindHobbyControls(path, controls, WithReport(report))
+	if len(report.Segments) != 2 {
+		t.Fatalf("expected report for 2 segments, have %d", len(report.Segments))
+	}
+	curved, line := report.Segments[0], report.Segments[1]
+	if curved.From != 0 || curved.To != 2 || len(curved.Theta) != 2 || len(curved.Phi) != 2 {
+		t.Errorf("expected curved segment 0…2 with angles for 2 joins, have %+v", curved)
+	}
+	if math.Abs(curved.Theta[0]-math.Pi/4) > 1e-6 || math.Abs(curved.Phi[1]-math.Pi/4) > 1e-6 {
+		t.Errorf("expected symmetric angles of π/4 at end points, have θ=%g, φ=%g", curved.Theta[0], curved.Phi[1])
+	}
+	if !line.Straight || line.From != 2 || line.To != 3 {
+		t.Errorf("expected straight segment 2…3, have %+v", line)
+	}
+}
+
+func TestSolveEvents(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(2, 0)).Line().Knot(arithm.P(3, 0)).End()
+	var events []SolveEvent
+	controls = FindHobbyControls(path, controls, WithWorkers(1),
+		WithEvents(func(ev SolveEvent) { events = append(events, ev) }))
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind.String())
+	}
+	expected := "SegmentStarted EquationsBuilt AnglesChosen ControlsSet SegmentStarted ControlsSet"
+	if strings.Join(kinds, " ") != expected {
+		t.Fatalf("unexpected sequence of events: %v", kinds)
+	}
+	if eqs := events[1]; eqs.From != 0 || eqs.To != 2 || len(eqs.U) != 4 || eqs.W != nil {
+		t.Errorf("expected coefficients for open segment 0…2, have %+v", eqs)
+	}
+	if ctrls := events[5]; ctrls.From != 2 || ctrls.Post[0] != controls.PostControl(2) {
+		t.Errorf("expected control points of straight segment in event, have %+v", ctrls)
+	}
+}
+
+func TestStrokeOutline(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	line, lcontrols := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(10, 0)).End()
+	lcontrols = FindHobbyControls(line, lcontrols)
+	outlines := StrokeOutline(line, lcontrols, 2, ButtCap, MiterJoin)
+	if len(outlines) != 1 || !outlines[0].IsCycle() {
+		t.Fatalf("expected a single closed outline for an open path")
+	}
+	for i := 0; i < outlines[0].N(); i++ {
+		if z := outlines[0].Z(i); math.Abs(math.Abs(z.Y())-1) > 1e-9 || z.X() < -1e-9 || z.X() > 10+1e-9 {
+			t.Errorf("expected butt-capped outline knots on y = ±1, have %v", z)
+		}
+	}
+	outlines = StrokeOutline(line, lcontrols, 2, RoundCap, MiterJoin)
+	if p := PointAt(outlines[0], outlines[0].Controls, 5); !equalPair(p, arithm.P(11, 0), 1e-2) {
+		t.Errorf("expected round cap to reach (11,0), have %v", p)
+	}
+	corner, ccontrols := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(10, 0)).Line().Knot(arithm.P(10, 10)).End()
+	ccontrols = FindHobbyControls(corner, ccontrols)
+	outlines = StrokeOutline(corner, ccontrols, 2, ButtCap, MiterJoin)
+	found := false
+	for i := 0; i < outlines[0].N(); i++ {
+		found = found || equalPair(outlines[0].Z(i), arithm.P(11, -1), 1e-9)
+	}
+	if !found {
+		t.Errorf("expected miter corner at (11,-1)")
+	}
+	circle, cicontrols := Nullpath().Knot(arithm.P(5, 0)).Curve().Knot(arithm.P(0, 5)).Curve().
+		Knot(arithm.P(-5, 0)).Curve().Knot(arithm.P(0, -5)).Curve().Cycle()
+	cicontrols = FindHobbyControls(circle, cicontrols)
+	outlines = StrokeOutline(circle, cicontrols, 2, ButtCap, RoundJoin)
+	if len(outlines) != 2 {
+		t.Fatalf("expected two outlines for a cycle, have %d", len(outlines))
+	}
+	for k, r := range []float64{4, 6} {
+		o := outlines[k]
+		for _, tm := range []float64{0.3, 2.5, 7.7} {
+			if d := cmplx.Abs(PointAt(o, o.Controls, tm).C()); math.Abs(d-r) > 0.02 {
+				t.Errorf("expected outline #%d at distance %g from center, have %g", k, r, d)
+			}
+		}
+	}
+}
+
+func TestArrowHead(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	line, lcontrols := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(10, 0)).End()
+	lcontrols = FindHobbyControls(line, lcontrols)
+	shaft, head := WithArrowHead(line, lcontrols, TriangleArrow, 4)
+	base := 10 - 4*math.Cos(math.Pi/8)
+	if shaft == nil || !equalPair(shaft.Z(shaft.N()-1), arithm.P(base, 0), 1e-6) {
+		t.Fatalf("expected shaft to be cut at base of arrow head")
+	}
+	if head.N() != 3 || head.Z(0) != arithm.P(10, 0) || !equalPair(head.Z(1), arithm.P(base, 4*math.Sin(math.Pi/8)), 1e-9) {
+		t.Errorf("unexpected triangle arrow head %s", AsString(head, nil))
+	}
+	curve, ccontrols := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(5, 3)).Curve().Knot(arithm.P(10, 0)).End()
+	ccontrols = FindHobbyControls(curve, ccontrols)
+	_, head = WithArrowHead(curve, ccontrols, CurvedArrow, 2)
+	if !head.IsCycle() || head.Z(0) == arithm.P(10, 0) {
+		t.Errorf("expected curved arrow head to start at its base")
+	}
+	if _, short := WithArrowHead(line, lcontrols, CurvedArrow, 20); short == nil {
+		t.Errorf("expected arrow head for short path")
+	}
+}
+
+func TestSubdivide(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().KnotLabeled(arithm.P(5, 3), "top").Curve().
+		Knot(arithm.P(10, 0)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	sub := Subdivide(path, controls, 3)
+	if sub.N() != 9 || !sub.IsCycle() || sub.Label(3) != "top" {
+		t.Fatalf("expected cycle of 9 knots with label at knot #3, have %d knots", sub.N())
+	}
+	for _, tm := range []float64{0.5, 1.2, 2.9} {
+		if p, q := PointAt(path, controls, tm), PointAt(sub, sub.Controls, 3*tm); !equalPair(p, q, 1e-9) {
+			t.Errorf("expected subdivided path to describe the same curve, %v ≠ %v", p, q)
+		}
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(0, 1)).Curve().
+		Knot(arithm.P(-1, 0)).Curve().Knot(arithm.P(0, -1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	ll, ur := BoundingBox(path, controls)
+	if !equalPair(ll, arithm.P(-1, -1), 1e-9) || !equalPair(ur, arithm.P(1, 1), 1e-9) {
+		t.Errorf("expected tight box (-1,-1)…(1,1) for circle, have %v…%v", ll, ur)
+	}
+	arc, acontrols := Nullpath().Knot(arithm.P(0, 0)).ExplicitCurve(arithm.P(0, 10), arithm.P(10, 10)).
+		Knot(arithm.P(10, 0)).End()
+	acontrols = FindHobbyControls(arc, acontrols)
+	if _, ur := BoundingBox(arc, acontrols); !equalPair(ur, arithm.P(10, 7.5), 1e-9) {
+		t.Errorf("expected tight box to end at (10,7.5), have %v", ur)
+	}
+	if _, ur := ControlHullBox(arc, acontrols); !equalPair(ur, arithm.P(10, 10), 1e-9) {
+		t.Errorf("expected control hull box to end at (10,10), have %v", ur)
+	}
+	if ll, _ := BoundingBox(Nullpath(), nil); !cmplx.IsNaN(ll.C()) {
+		t.Errorf("expected NaN box for empty path")
+	}
+}
+
+func TestArcJoin(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	// half circle of radius 1 from (1,0) to (-1,0), counter-clockwise
+	path, controls := Nullpath().Knot(arithm.P(1, 0)).ArcJoin(1, 1, 0, false, true).Knot(arithm.P(-1, 0)).End()
+	controls = FindHobbyControls(path, controls)
+	if path.N() != 3 || !equalPair(path.Z(1), arithm.P(0, 1), 1e-9) {
+		t.Fatalf("expected half circle to be split at (0,1), have %s", AsString(path, nil))
+	}
+	for _, tm := range []float64{0.3, 1.5, 1.9} {
+		if r := cmplx.Abs(PointAt(path, controls, tm).C()); math.Abs(r-1) > 1e-3 {
+			t.Errorf("expected point on unit circle at time %g, distance is %g", tm, r)
+		}
+	}
+	// half ellipse from (0,0) to (4,0), counter-clockwise, closed by a line
+	cycle, ccontrols := Nullpath().Knot(arithm.P(0, 0)).ArcJoin(2, 1, 0, true, true).Knot(arithm.P(4, 0)).
+		Line().Cycle()
+	ccontrols = FindHobbyControls(cycle, ccontrols)
+	if _, ur := BoundingBox(cycle, ccontrols); ur.Y() > 1e-9 {
+		t.Errorf("expected counter-clockwise arc below the x-axis, box ends at %v", ur)
+	}
+	if ll, _ := BoundingBox(cycle, ccontrols); math.Abs(ll.Y()+1) > 1e-3 {
+		t.Errorf("expected arc with ry = 1 to reach y = -1, box starts at %v", ll)
+	}
+	checked := CheckedNullpath()
+	checked.ArcJoin(1, 1, 0, false, false)
+	if checked.Err() == nil {
+		t.Errorf("expected error for arc on empty path")
+	}
+}
+
+func TestRoundedRect(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := RoundedRect(arithm.P(4, 3), arithm.P(0, 0), 1)
+	controls = FindHobbyControls(path, controls)
+	if path.N() != 8 {
+		t.Fatalf("expected rounded rectangle to have 8 knots, has %d", path.N())
+	}
+	ll, ur := BoundingBox(path, controls)
+	if !equalPair(ll, arithm.P(0, 0), 1e-9) || !equalPair(ur, arithm.P(4, 3), 1e-9) {
+		t.Errorf("expected box (0,0)…(4,3), have %v…%v", ll, ur)
+	}
+	// corner arc between (3,0) and (4,1) is a quarter circle around (3,1)
+	if d := cmplx.Abs((PointAt(path, controls, 1.5) - arithm.P(3, 1)).C()); math.Abs(d-1) > 1e-3 {
+		t.Errorf("expected corner to be a quarter circle, distance from center is %g", d)
+	}
+	for i := 0; i < path.N(); i++ { // smooth transitions
+		d1 := DirectionAt(path, controls, float64(i)-1e-9)
+		d2 := DirectionAt(path, controls, float64(i))
+		if math.Abs(d1.Cross(d2)) > 1e-6*cmplx.Abs(d1.C())*cmplx.Abs(d2.C()) {
+			t.Errorf("expected smooth transition at knot #%d, directions %v and %v", i, d1, d2)
+		}
+	}
+	stadium, scontrols := Stadium(arithm.P(0, 0), arithm.P(4, 2))
+	scontrols = FindHobbyControls(stadium, scontrols)
+	if stadium.N() != 6 {
+		t.Errorf("expected stadium to have 6 knots, has %d", stadium.N())
+	}
+	if ll, ur := BoundingBox(stadium, scontrols); !equalPair(ll, arithm.P(0, 0), 1e-9) || !equalPair(ur, arithm.P(4, 2), 1e-9) {
+		t.Errorf("expected box (0,0)…(4,2), have %v…%v", ll, ur)
+	}
+	circle, _ := Stadium(arithm.P(0, 0), arithm.P(2, 2))
+	if circle.N() != 4 {
+		t.Errorf("expected stadium of a square to be a circle of 4 knots, has %d", circle.N())
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(0, 1)).Curve().
+		Knot(arithm.P(-1, 0)).Curve().Knot(arithm.P(0, -1)).Curve().Cycle()
+	controls = FindHobbyControls(path, controls)
+	if IsClockwise(path, controls) {
+		t.Errorf("expected circle to run counter-clockwise")
+	}
+	if a := signedArea(path, controls); math.Abs(a-math.Pi) > 1e-2 {
+		t.Errorf("expected area of unit circle to be π, is %g", a)
+	}
+	p := path.(*Path)
+	cw := p.WithOrientation(false)
+	if !IsClockwise(cw, cw.Controls) || !equalPair(cw.Z(0), p.Z(0), 1e-9) {
+		t.Errorf("expected clockwise circle starting at (1,0), have %s", AsString(cw, cw.Controls))
+	}
+	if ccw := cw.WithOrientation(true); IsClockwise(ccw, ccw.Controls) {
+		t.Errorf("expected counter-clockwise circle")
+	}
+	if same := p.WithOrientation(true); !Equal(same, same.Controls, p, p.Controls, 1e-9) {
+		t.Errorf("expected correctly oriented path to be unchanged")
+	}
+}
+
+func TestPruneCollinear(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(1, 0)).Line().Knot(arithm.P(2, 0.001)).Line().
+		Knot(arithm.P(3, 0)).Line().Knot(arithm.P(3, 1)).Curve().Knot(arithm.P(3, 2)).Line().Knot(arithm.P(3, 3)).End()
+	pruned := PruneCollinear(path, 0.01)
+	if pruned.N() != 5 {
+		t.Fatalf("expected 5 knots after pruning, have %s", AsString(pruned, nil))
+	}
+	if !equalPair(pruned.Z(1), arithm.P(3, 0), 1e-9) || !equalPair(pruned.Z(2), arithm.P(3, 1), 1e-9) {
+		t.Errorf("expected corner and knot before curve to be kept, have %s", AsString(pruned, nil))
+	}
+	controls := FindHobbyControls(pruned, pruned.Controls)
+	if c := controls.PostControl(0); math.Abs(c.Y()) > 1e-9 {
+		t.Errorf("expected pruned join to remain a line, control is %v", c)
+	}
+	if strict := PruneCollinear(path, 1e-6); strict.N() != 7 {
+		t.Errorf("expected knots off the line to be kept, have %s", AsString(strict, nil))
+	}
+	square, _ := Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(1, 0)).Line().Knot(arithm.P(2, 0)).Line().
+		Knot(arithm.P(2, 2)).Line().Knot(arithm.P(0, 2)).Line().Knot(arithm.P(0, 1)).Line().Cycle()
+	if pruned := PruneCollinear(square, 1e-6); pruned.N() != 4 || !pruned.IsCycle() {
+		t.Errorf("expected square with 4 knots, have %s", AsString(pruned, nil))
+	}
+	// the first knot of the cycle is collinear
+	shifted, _ := Nullpath().Knot(arithm.P(1, 0)).Line().Knot(arithm.P(2, 0)).Line().Knot(arithm.P(2, 2)).Line().
+		Knot(arithm.P(0, 2)).Line().Knot(arithm.P(0, 0)).Line().Cycle()
+	if pruned := PruneCollinear(shifted, 1e-6); pruned.N() != 4 || !pruned.IsCycle() {
+		t.Errorf("expected collinear start knot to be removed, have %s", AsString(pruned, nil))
+	}
+}
+
+func TestEndpointCurl(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).End()
+	dflt := FindHobbyControls(path, nil)
+	one := FindHobbyControls(path, nil, WithEndpointCurl(1))
+	if !Equal(path, dflt, path, one, 1e-9) {
+		t.Errorf("expected endpoint curl 1 to be the default")
+	}
+	given, _ := Nullpath().CurlKnot(arithm.P(0, 0), 2, 2).Curve().Knot(arithm.P(1, 1)).Curve().
+		CurlKnot(arithm.P(2, 0), 2, 2).End()
+	expected := FindHobbyControls(given, nil)
+	curled := FindHobbyControls(path, nil, WithEndpointCurl(2))
+	if !Equal(path, curled, given, expected, 1e-9) {
+		t.Errorf("expected endpoint curl 2 to act like given curls, have %s", AsString(path, curled))
+	}
+	if Equal(path, dflt, path, curled, 1e-6) {
+		t.Errorf("expected endpoint curl to change the path")
+	}
+	// given curls take precedence
+	partly, _ := Nullpath().CurlKnot(arithm.P(0, 0), 1, 1).Curve().Knot(arithm.P(1, 1)).Curve().Knot(arithm.P(2, 0)).End()
+	c := FindHobbyControls(partly, nil, WithEndpointCurl(2))
+	if equalPair(c.PostControl(0), curled.PostControl(0), 1e-6) {
+		t.Errorf("expected given curl to take precedence, have %s", AsString(partly, c))
+	}
+}
+
+func TestTensionAtLeast(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, _ := Nullpath().Knot(arithm.P(0, 0)).TensionAtLeastCurve(-2, 0.5).Knot(arithm.P(1, 1)).
+		TensionAtLeastCurve(1, 1).Knot(arithm.P(2, 0)).End()
+	if path.PostTension(0) != -2 || path.PreTension(1) != -0.75 {
+		t.Errorf("expected tensions atleast 2 and atleast 3/4, have %g and %g", path.PostTension(0), path.PreTension(1))
+	}
+	if path.PostTension(1) != -1 || path.PreTension(2) != -1 {
+		t.Errorf("expected tensions atleast 1, have %g and %g", path.PostTension(1), path.PreTension(2))
+	}
+	bounded, _ := Nullpath().Knot(arithm.P(0, 0)).BoundedCurve().Knot(arithm.P(1, 1)).End()
+	if bounded.PostTension(0) != -1 || bounded.PreTension(1) != -1 {
+		t.Errorf("expected bounded curve to have tensions atleast 1")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Line().Knot(arithm.P(2, 0)).Curve().Cycle()
+	if err := path.Validate(); err != nil {
+		t.Errorf("expected valid cycle, have error: %v", err)
+	}
+	path = Nullpath()
+	path.Knot(arithm.P(0, 0))
+	path.Knot(arithm.P(1, 1))
+	if err := path.Validate(); err == nil {
+		t.Errorf("expected error for consecutive knots")
+	}
+	path = Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve()
+	path.Line()
+	if err := path.Validate(); err == nil {
+		t.Errorf("expected error for consecutive joins")
+	}
+	path = Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve()
+	path.End()
+	if err := path.Validate(); err == nil {
+		t.Errorf("expected error for dangling join")
+	}
+	path = Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1))
+	path.Cycle()
+	if err := path.Validate(); err == nil {
+		t.Errorf("expected error for cycle without closing join")
+	}
+	if err := Subdivide(path, path.Controls, 2).Validate(); err != nil {
+		t.Errorf("expected derived path to be valid, have error: %v", err)
+	}
+}
+
+func TestValidateAgreesWithErr(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	checked := CheckedNullpath()
+	checked.Curve().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).End()
+	if err := checked.Validate(); err == nil || err != checked.Err() {
+		t.Errorf("expected Validate to report builder error %v, have %v", checked.Err(), err)
+	}
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0))
+	path.Knot(arithm.P(1, 1))
+	if err := path.Err(); err == nil || err != path.Validate() {
+		t.Errorf("expected Err to report structural error %v, have %v", path.Validate(), err)
+	}
+}
+
+type segmentSink struct {
+	start arithm.Pair
+	segs  []CubicSegment
+}
+
+func (s *segmentSink) MoveTo(p arithm.Pair) {
+	s.start = p
+}
+
+func (s *segmentSink) CurveTo(c1, c2, p arithm.Pair) {
+	p0 := s.start
+	if len(s.segs) > 0 {
+		p0 = s.segs[len(s.segs)-1].P3
+	}
+	s.segs = append(s.segs, CubicSegment{p0, c1, c2, p})
+}
+
+func (s *segmentSink) ClosePath() {}
+
+func TestStream(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	sink := &segmentSink{}
+	stream := NewStream(sink, 10)
+	path := Nullpath()
+	for i := 0; i < 100; i++ {
+		p := arithm.P(float64(i), 5*math.Sin(float64(i)/4))
+		stream.Knot(p)
+		if i > 0 {
+			path.Curve()
+		}
+		path.Knot(p)
+	}
+	stream.End()
+	controls := FindHobbyControls(path, path.Controls)
+	if len(sink.segs) != 99 {
+		t.Fatalf("expected 99 segments to be streamed, have %d", len(sink.segs))
+	}
+	for i, seg := range sink.segs {
+		expected := segmentAt(path, controls, i)
+		if !equalPair(seg.P0, expected.P0, 1e-9) || !equalPair(seg.P3, expected.P3, 1e-9) {
+			t.Fatalf("expected segment #%d to connect knots #%d and #%d", i, i, i+1)
+		}
+		if !equalPair(seg.C1, expected.C1, 1e-3) || !equalPair(seg.C2, expected.C2, 1e-3) {
+			t.Errorf("expected segment #%d to be close to solution of whole path, have %v", i, seg)
+		}
+	}
+	short := &segmentSink{}
+	NewStream(short, 10).Knot(arithm.P(0, 0)).Knot(arithm.P(1, 1)).Knot(arithm.P(2, 0)).End()
+	if len(short.segs) != 2 || !equalPair(short.start, arithm.P(0, 0), 0) {
+		t.Errorf("expected short stream to be solved at the end, have %v", short.segs)
+	}
+}
+
+func TestScratchReuse(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().
+		Knot(arithm.P(2, 0)).Curve().Knot(arithm.P(3, 1)).End()
+	expected := FindHobbyControls(path, nil)
+	buf := getScratch(64) // leave garbage in pooled buffers
+	for i := range buf.u {
+		buf.u[i], buf.v[i], buf.w[i] = arithm.Float(1e9), arithm.Float(1e9), arithm.Float(1e9)
+		buf.theta[i] = 1e9
+	}
+	putScratch(buf)
+	for i := 0; i < 3; i++ {
+		controls = FindHobbyControls(path, controls)
+		if !Equal(path, controls, path, expected, 1e-12) {
+			t.Fatalf("expected identical results when re-solving with pooled buffers")
+		}
+	}
+	if b := getScratch(5); len(b.u) != 5 || b.theta[4] != 0 {
+		t.Errorf("expected zeroed buffers of requested length")
+	}
+}
+
+func TestSplitAtRoughKnots(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().KnotLabeled(arithm.P(1, 1), "top").Curve().
+		DirKnot(arithm.P(2, 0), arithm.P(1, -1)).Curve().Knot(arithm.P(3, -1)).Line().
+		Knot(arithm.P(4, 0)).ExplicitCurve(arithm.P(4, 1), arithm.P(3, 2)).Knot(arithm.P(2, 2)).Curve().Cycle()
+	controls := FindHobbyControls(path, path.Controls)
+	pieces := SplitAtRoughKnots(path)
+	if len(pieces) != 4 {
+		t.Fatalf("expected 4 pieces, have %d", len(pieces))
+	}
+	var segs []CubicSegment
+	for _, piece := range pieces {
+		if piece.IsCycle() {
+			t.Errorf("expected open pieces for cycle with rough knots")
+		}
+		segs = append(segs, Segments(piece, FindHobbyControls(piece, piece.Controls))...)
+	}
+	if len(segs) != path.N() {
+		t.Fatalf("expected pieces to cover all %d segments, have %d", path.N(), len(segs))
+	}
+	first := 2 // first rough knot
+	for k, seg := range segs {
+		expected := segmentAt(path, controls, (first+k)%path.N())
+		if !equalPair(seg.C1, expected.C1, 1e-9) || !equalPair(seg.C2, expected.C2, 1e-9) {
+			t.Errorf("expected piece segment #%d to equal segment of whole path, have %v vs %v", k, seg, expected)
+		}
+	}
+	if i, ok := pieces[3].KnotByLabel("top"); !ok || !equalPair(pieces[3].Z(i), arithm.P(1, 1), 0) {
+		t.Errorf("expected label to be retained in last piece")
+	}
+	smooth, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Cycle()
+	if pieces := SplitAtRoughKnots(smooth); len(pieces) != 1 || !pieces[0].IsCycle() {
+		t.Errorf("expected smooth cycle to remain a single cyclic piece")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p1 := Nullpath()
+	p1.Knot(arithm.P(0, 0)).Curve().KnotLabeled(arithm.P(1, 1), "mid").Curve().Knot(arithm.P(2, 0)).End()
+	p2 := Nullpath()
+	p2.KnotLabeled(arithm.P(2, 0.001), "junction").Curve().Knot(arithm.P(3, -1)).Line().Knot(arithm.P(4, 0)).End()
+	if _, err := Join(p1, p2, 1e-6); err == nil {
+		t.Errorf("expected error for ends which do not meet")
+	}
+	path, err := Join(p1, p2, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.N() != 5 || !equalPair(path.Z(2), arithm.P(2, 0), 0) || path.Label(2) != "junction" {
+		t.Fatalf("expected 5 knots with junction at (2,0), have %s", AsString(path, nil))
+	}
+	if isrough(path, 2) {
+		t.Errorf("expected junction to be smooth")
+	}
+	if !hasGivenCurl(path, 3, true) || !hasGivenCurl(path, 4, false) {
+		t.Errorf("expected line of p2 to be retained")
+	}
+	controls := FindHobbyControls(path, path.Controls)
+	in, out := segmentAt(path, controls, 1), segmentAt(path, controls, 2)
+	if math.Abs((in.P3 - in.C2).Cross(out.C1-out.P0)) > 1e-9 {
+		t.Errorf("expected path to be smooth at junction, have %s", AsString(path, controls))
+	}
+	if p1.N() != 3 || p2.N() != 3 {
+		t.Errorf("expected input paths to remain unchanged")
+	}
+	corner := Nullpath()
+	corner.PostDirKnot(arithm.P(2, 0), arithm.P(0, -1)).Curve().Knot(arithm.P(3, -1)).End()
+	cornered, _ := Join(p1, corner, 0)
+	if dir := cornered.PostDir(2); !equalPair(dir, arithm.P(0, -1), 0) {
+		t.Errorf("expected outgoing direction of p2 at junction, have %v", dir)
+	}
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Cycle()
+	if _, err := Join(p1, cycle.(*Path), 0); err == nil {
+		t.Errorf("expected error for cyclic path")
+	}
+}
+
+func TestSolveWithEndDirections(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path := Nullpath()
+	path.Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(2, 1)).Curve().Knot(arithm.P(4, 0)).End()
+	solved, controls, err := SolveWithEndDirections(path, 30, -90)
+	if err != nil {
+		t.Fatalf("expected open path to be solved, have error: %v", err)
+	}
+	if d := DirectionAt(solved, controls, 0); math.Abs(rad2deg(cmplx.Phase(d.C()))-30) > 1e-6 {
+		t.Errorf("expected path to leave at 30°, leaves at %g°", rad2deg(cmplx.Phase(d.C())))
+	}
+	if d := DirectionAt(solved, controls, 2); math.Abs(rad2deg(cmplx.Phase(d.C()))+90) > 1e-6 {
+		t.Errorf("expected path to arrive at -90°, arrives at %g°", rad2deg(cmplx.Phase(d.C())))
+	}
+	expected, _ := Nullpath().PostDirKnot(arithm.P(0, 0), dir(30)).Curve().Knot(arithm.P(2, 1)).Curve().
+		PreDirKnot(arithm.P(4, 0), dir(-90)).End()
+	if !Equal(solved, controls, expected, FindHobbyControls(expected, nil), 1e-9) {
+		t.Errorf("expected same result as building path with directions")
+	}
+	if !cmplx.IsNaN(path.PostDir(0).C()) || !cmplx.IsNaN(path.PreDir(2).C()) {
+		t.Errorf("expected caller's path to be unchanged, has directions %v and %v", path.PostDir(0), path.PreDir(2))
+	}
+	cycle, _ := Nullpath().Knot(arithm.P(0, 0)).Curve().Knot(arithm.P(1, 1)).Curve().Cycle()
+	if _, _, err := SolveWithEndDirections(cycle.(*Path), 0, 0); err == nil {
+		t.Errorf("expected error for cycle")
+	}
+	single, _ := Nullpath().Knot(arithm.P(0, 0)).End()
+	if _, _, err := SolveWithEndDirections(single.(*Path), 0, 0); err == nil {
+		t.Errorf("expected error for single knot")
+	}
+}
+
+func TestTransformable(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := testpath()
+	controls = FindHobbyControls(path, controls)
+	at := arithm.Rotation(math.Pi / 4)
+	var geometry []arithm.Transformable = []arithm.Transformable{path, path.Controls}
+	for i, g := range geometry {
+		geometry[i] = g.Transformed(at)
+	}
+	p := geometry[0].(*Path)
+	if !p.Z(1).Equal(at.Transform(path.Z(1))) {
+		t.Errorf("expected knots to be rotated, have %s", AsString(p, p.Controls))
+	}
+	c := geometry[1].(SplineControls)
+	if !c.PostControl(0).Equal(at.Transform(controls.PostControl(0))) {
+		t.Errorf("expected controls to be rotated, is %v", c.PostControl(0))
+	}
+	if p0, _, _, _ := c.(BezierSegments).Segment(0); !p0.Equal(at.Transform(path.Z(0))) {
+		t.Errorf("expected controls to belong to rotated path, segment starts at %v", p0)
+	}
+}
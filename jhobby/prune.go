@@ -0,0 +1,96 @@
+package jhobby
+
+import (
+	"math/cmplx"
+)
+
+// --- Pruning Collinear Knots -----------------------------------------------
+
+// PruneCollinear removes knots from a path which lie on the straight line
+// between their neighbours, within tolerance, where both joins at the knot
+// are lines (see Line). Polygonal input, e.g. from digitizing or from
+// generated data, often contains many of those knots. Removing them before
+// solving reduces the size of the path without changing its shape.
+//
+// Knots are considered for removal only if they carry no directions, no
+// explicit control points and no label or client data. Runs of collinear
+// knots are replaced by a single line. The end points of an open path are
+// never removed, and a cyclic path will keep at least 3 knots.
+//
+// The input path remains unchanged; PruneCollinear returns a new skeleton
+// path, for which clients will have to call FindHobbyControls.
+func PruneCollinear(path HobbyPath, tolerance float64) *Path {
+	n := path.N()
+	keep := make([]bool, n)
+	for i := range keep {
+		keep[i] = true
+	}
+	minKnots := 2
+	if path.IsCycle() {
+		minKnots = 3
+	}
+	start := 0 // an open path starts at its first knot, a cycle at a corner
+	if path.IsCycle() {
+		for i := 0; i < n; i++ {
+			if !isPrunable(path, i) || !withinLine(path, (i-1+n)%n, i, (i+1)%n, tolerance) {
+				start = i
+				break
+			}
+		}
+	}
+	count := n
+	anchor := start // last knot kept
+	for k := 1; k < n && count > minKnots; k++ {
+		i, next := (start+k)%n, (start+k+1)%n
+		if !path.IsCycle() && next == 0 {
+			break // i is the end point
+		}
+		if !isPrunable(path, i) || !withinLine(path, anchor, i, next, tolerance) {
+			anchor = i
+			continue
+		}
+		T().Debugf("prune: removing collinear knot %s", ptstring(path.Z(i), false))
+		keep[i] = false
+		count--
+	}
+	return copyPath(path, keep)
+}
+
+// isPrunable checks if knot #i is an inner knot of a polyline, i.e. both
+// adjacent joins are lines and the knot carries no other parameters.
+func isPrunable(path HobbyPath, i int) bool {
+	n := path.N()
+	if !path.IsCycle() && (i == 0 || i == n-1) {
+		return false
+	}
+	if !cmplx.IsNaN(path.PreDir(i).C()) || !cmplx.IsNaN(path.PostDir(i).C()) ||
+		!cmplx.IsNaN(explicitPreControl(path, i).C()) || !cmplx.IsNaN(explicitPostControl(path, i).C()) {
+		return false
+	}
+	if p, ok := path.(*Path); ok && !getInfo(p.infos, i).empty() {
+		return false
+	}
+	return isLineJoin(path, (i-1+n)%n) && isLineJoin(path, i)
+}
+
+// isLineJoin checks if the join from knot #i to knot #i+1 is a straight line,
+// i.e. it is bounded by curls on both sides.
+func isLineJoin(path HobbyPath, i int) bool {
+	j := (i + 1) % path.N()
+	return hasGivenCurl(path, i, true) && hasGivenCurl(path, j, false) &&
+		cmplx.IsNaN(explicitPostControl(path, i).C()) && cmplx.IsNaN(explicitPreControl(path, j).C())
+}
+
+// withinLine checks if the knots after knot #from up to and including knot
+// #i are within tolerance of the line from knot #from to knot #to.
+func withinLine(path HobbyPath, from, i, to int, tolerance float64) bool {
+	n := path.N()
+	for k := (from + 1) % n; ; k = (k + 1) % n {
+		if segmentDistance(path.Z(k), path.Z(from), path.Z(to)) > tolerance {
+			return false
+		}
+		if k == i {
+			return true
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Package raster rasterizes solved Hobby-paths, using golang.org/x/image/vector.
+It is intended for previewing and testing paths without an external
+graphics stack.
+
+Path coordinates are interpreted as pixel coordinates, i.e. with the y-axis
+pointing downwards, relative to the top left corner of the target image.
+Clients may use jhobby's Path.Transformed to map paths from other coordinate
+systems. Open paths are closed implicitly by a straight line.
+
+BSD License
+
+Copyright (c) 2017–21, Norbert Pillmayer (norbert@pillmayer.com)
+
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions
+are met:
+
+1. Redistributions of source code must retain the above copyright
+notice, this list of conditions and the following disclaimer.
+
+2. Redistributions in binary form must reproduce the above copyright
+notice, this list of conditions and the following disclaimer in the
+documentation and/or other materials provided with the distribution.
+
+3. Neither the name of this software nor the names of its contributors
+may be used to endorse or promote products derived from this software
+without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+"AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE. */
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/npillmayer/arithm"
+	"github.com/npillmayer/arithm/jhobby"
+	"golang.org/x/image/vector"
+)
+
+// Mask rasterizes a path into an alpha mask of size w×h. The covered area
+// of the path will be opaque, with anti-aliased edges. Areas are filled
+// according to the non-zero winding rule.
+func Mask(path jhobby.HobbyPath, controls jhobby.SplineControls, w, h int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	rasterize(path, controls, w, h).Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask
+}
+
+// Fill paints the area covered by a path onto an image, using color c.
+// The path is drawn over the existing content of dst.
+func Fill(dst draw.Image, path jhobby.HobbyPath, controls jhobby.SplineControls, c color.Color) {
+	r := dst.Bounds()
+	z := rasterize(path, controls, r.Dx(), r.Dy())
+	z.Draw(dst, r, image.NewUniform(c), image.Point{})
+}
+
+func rasterize(path jhobby.HobbyPath, controls jhobby.SplineControls, w, h int) *vector.Rasterizer {
+	z := vector.NewRasterizer(w, h)
+	if path.N() == 0 {
+		return z
+	}
+	jhobby.Render(path, controls, sink{z})
+	if !path.IsCycle() {
+		z.ClosePath()
+	}
+	return z
+}
+
+// sink adapts a vector.Rasterizer to interface jhobby.PathSink.
+type sink struct {
+	z *vector.Rasterizer
+}
+
+func (s sink) MoveTo(p arithm.Pair) {
+	s.z.MoveTo(float32(p.X()), float32(p.Y()))
+}
+
+func (s sink) CurveTo(c1, c2, p arithm.Pair) {
+	s.z.CubeTo(float32(c1.X()), float32(c1.Y()), float32(c2.X()), float32(c2.Y()),
+		float32(p.X()), float32(p.Y()))
+}
+
+func (s sink) ClosePath() {
+	s.z.ClosePath()
+}
@@ -0,0 +1,42 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/npillmayer/arithm"
+	"github.com/npillmayer/arithm/jhobby"
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestMask(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := jhobby.Nullpath().Knot(arithm.P(10, 2)).Curve().Knot(arithm.P(18, 10)).Curve().
+		Knot(arithm.P(10, 18)).Curve().Knot(arithm.P(2, 10)).Curve().Cycle()
+	controls = jhobby.FindHobbyControls(path, controls)
+	mask := Mask(path, controls, 20, 20)
+	if a := mask.AlphaAt(10, 10).A; a != 0xff {
+		t.Errorf("expected center of circle to be opaque, alpha is %d", a)
+	}
+	if a := mask.AlphaAt(3, 3).A; a != 0 {
+		t.Errorf("expected corner outside of circle to be transparent, alpha is %d", a)
+	}
+}
+
+func TestFill(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	path, controls := jhobby.Nullpath().Knot(arithm.P(0, 0)).Line().Knot(arithm.P(4, 0)).Line().
+		Knot(arithm.P(4, 4)).Line().Knot(arithm.P(0, 4)).End()
+	controls = jhobby.FindHobbyControls(path, controls)
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	Fill(img, path, controls, color.RGBA{0, 0, 0xff, 0xff})
+	if c := img.RGBAAt(2, 2); c.B != 0xff || c.A != 0xff {
+		t.Errorf("expected pixel within (implicitly closed) square to be blue, is %v", c)
+	}
+	if c := img.RGBAAt(6, 6); c.A != 0 {
+		t.Errorf("expected pixel outside of square to be untouched, is %v", c)
+	}
+}
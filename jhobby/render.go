@@ -0,0 +1,52 @@
+package jhobby
+
+import (
+	"github.com/npillmayer/arithm"
+)
+
+// --- Rendering Paths -------------------------------------------------------
+
+// PathSink is a minimal interface for drawing backends. Render feeds a solved
+// path into a PathSink, decoupling drawing backends from the internals of
+// this package.
+//
+// Backends usually need a small adapter. For example, for fogleman/gg:
+//
+//	type ggSink struct{ *gg.Context }
+//
+//	func (s ggSink) MoveTo(p arithm.Pair) { s.Context.MoveTo(p.X(), p.Y()) }
+//	func (s ggSink) CurveTo(c1, c2, p arithm.Pair) {
+//		s.CubicTo(c1.X(), c1.Y(), c2.X(), c2.Y(), p.X(), p.Y())
+//	}
+//	func (s ggSink) ClosePath() { s.Context.ClosePath() }
+//
+// and for Gio's clip.Path:
+//
+//	type gioSink struct{ *clip.Path }
+//
+//	func pt(p arithm.Pair) f32.Point { return f32.Pt(float32(p.X()), float32(p.Y())) }
+//	func (s gioSink) MoveTo(p arithm.Pair) { s.Path.MoveTo(pt(p)) }
+//	func (s gioSink) CurveTo(c1, c2, p arithm.Pair) { s.CubeTo(pt(c1), pt(c2), pt(p)) }
+//	func (s gioSink) ClosePath() { s.Close() }
+type PathSink interface {
+	MoveTo(p arithm.Pair)          // start a new (sub-)path at p
+	CurveTo(c1, c2, p arithm.Pair) // cubic Bézier curve to p
+	ClosePath()                    // close the current (sub-)path
+}
+
+// Render feeds a path with its control points into a PathSink: a MoveTo for
+// the first knot, a CurveTo for every segment, and a final ClosePath for
+// cyclic paths. Control points which have not been calculated are replaced
+// by the adjacent knots, resulting in straight lines.
+func Render(path HobbyPath, controls SplineControls, sink PathSink) {
+	if path.N() == 0 {
+		return
+	}
+	sink.MoveTo(path.Z(0))
+	for _, seg := range Segments(path, controls) {
+		sink.CurveTo(seg.C1, seg.C2, seg.P3)
+	}
+	if path.IsCycle() {
+		sink.ClosePath()
+	}
+}
@@ -0,0 +1,95 @@
+package jhobby
+
+import (
+	"sort"
+)
+
+// --- Solver Reports --------------------------------------------------------
+
+// SolveReport collects intermediate quantities of a call to
+// FindHobbyControls, for diagnostic purposes, e.g. for comparing the
+// solver's results to MetaFont's. Clients request a report with solve option
+// WithReport.
+type SolveReport struct {
+	Segments []SegmentReport // solved segments, ordered by their first knot
+}
+
+// SegmentReport holds intermediate quantities for a single segment of a
+// path, i.e. a part of the path between two rough knots. Angles are given in
+// radians.
+//
+// For join #k of the segment, i.e. the join between knots From+k and
+// From+k+1, Theta[k] is the angle between the outgoing direction at the
+// start of the join and its chord, and Phi[k] is the angle between the chord
+// and the incoming direction at the end of the join (MetaFont: θ and φ).
+// Psi[k] is the turning angle of the chords at the knot #From+k (MetaFont: ψ).
+type SegmentReport struct {
+	From, To  int       // first and last knot of the segment (mod N)
+	Cycle     bool      // is the segment the whole of a cyclic path?
+	Explicit  bool      // controls have been given explicitly, no angles
+	Straight  bool      // segment has been solved as a straight line
+	Theta     []float64 // outgoing angle at the start of join #k
+	Phi       []float64 // incoming angle at the end of join #k
+	Psi       []float64 // turning angle at knot #k of the segment
+	Refined   bool      // angles have been refined for curvature continuity
+	Converged bool      // refinement for curvature continuity converged
+}
+
+// WithReport lets FindHobbyControls and ResolveKnots fill r with the
+// intermediate quantities of the solver. Previous content of r is replaced.
+// If solve option WithMergeDuplicates is in effect, knot indices of the
+// report refer to the path with duplicates merged.
+func WithReport(r *SolveReport) SolveOption {
+	return func(conf *solveConfig) {
+		conf.rep = r
+		if r != nil {
+			r.Segments = r.Segments[:0]
+		}
+	}
+}
+
+// report adds a report for a segment, if a report has been requested.
+func (conf *solveConfig) report(seg SegmentReport) {
+	if conf.rep == nil {
+		return
+	}
+	conf.repMx.Lock()
+	defer conf.repMx.Unlock()
+	conf.rep.Segments = append(conf.rep.Segments, seg)
+}
+
+// reportAngles reports the angles found for a segment.
+func (conf *solveConfig) reportAngles(path HobbyPath, theta []float64, refined, converged bool) {
+	if conf.rep == nil {
+		return
+	}
+	seg := newSegmentReport(path)
+	seg.Refined, seg.Converged = refined, converged
+	n := path.N()
+	for k := 0; k < joinCount(path); k++ {
+		seg.Theta = append(seg.Theta, theta[k])
+		seg.Phi = append(seg.Phi, -psi(path, k+1)-theta[k+1])
+	}
+	for k := 0; k < n; k++ {
+		seg.Psi = append(seg.Psi, psi(path, k))
+	}
+	conf.report(seg)
+}
+
+// newSegmentReport creates a report for a segment, setting its knot range.
+func newSegmentReport(path HobbyPath) SegmentReport {
+	seg := SegmentReport{Cycle: path.IsCycle()}
+	seg.From, seg.To = segmentRange(path)
+	return seg
+}
+
+// sortReport orders the segments of a report by their first knot, as
+// segments may be solved concurrently.
+func (conf *solveConfig) sortReport() {
+	if conf.rep == nil {
+		return
+	}
+	sort.SliceStable(conf.rep.Segments, func(i, j int) bool {
+		return conf.rep.Segments[i].From < conf.rep.Segments[j].From
+	})
+}
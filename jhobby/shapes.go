@@ -0,0 +1,62 @@
+package jhobby
+
+import (
+	"math"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Shapes ----------------------------------------------------------------
+
+// RoundedRect creates a closed path for a rectangle with rounded corners,
+// given two opposite corners of the rectangle. The corners are rounded by
+// quarter circles of the given radius, which is clamped to half of the
+// shorter side of the rectangle. A radius of 0 results in a plain rectangle.
+//
+// The path runs counter-clockwise (with the y-axis pointing upwards),
+// starting at the left end of the bottom side. Knots at the transitions
+// between straight sides and rounded corners carry the direction of the
+// adjacent side, so the path will be smooth at the transitions. Sides
+// shorter than the tolerance of the path (see Path.Epsilon) are left out.
+//
+// The result is a skeleton path; clients will have to call FindHobbyControls
+// to calculate the control points.
+func RoundedRect(corner1, corner2 arithm.Pair, radius float64) (*Path, SplineControls) {
+	ll := arithm.P(math.Min(corner1.X(), corner2.X()), math.Min(corner1.Y(), corner2.Y()))
+	ur := arithm.P(math.Max(corner1.X(), corner2.X()), math.Max(corner1.Y(), corner2.Y()))
+	w, h := ur.X()-ll.X(), ur.Y()-ll.Y()
+	r := math.Max(0, math.Min(radius, math.Min(w, h)/2))
+	path := Nullpath()
+	eps := path.epsilon()
+	if r < eps {
+		path.Knot(ll).Line().Knot(arithm.P(ur.X(), ll.Y())).Line().
+			Knot(ur).Line().Knot(arithm.P(ll.X(), ur.Y())).Line().Cycle()
+		return path, path.Controls
+	}
+	sides := []struct {
+		from, to, dir arithm.Pair
+	}{
+		{arithm.P(ll.X()+r, ll.Y()), arithm.P(ur.X()-r, ll.Y()), arithm.P(1, 0)},
+		{arithm.P(ur.X(), ll.Y()+r), arithm.P(ur.X(), ur.Y()-r), arithm.P(0, 1)},
+		{arithm.P(ur.X()-r, ur.Y()), arithm.P(ll.X()+r, ur.Y()), arithm.P(-1, 0)},
+		{arithm.P(ll.X(), ur.Y()-r), arithm.P(ll.X(), ll.Y()+r), arithm.P(0, -1)},
+	}
+	for _, side := range sides {
+		if equalPair(side.from, side.to, eps) {
+			path.DirKnot(side.from, side.dir).Curve()
+			continue
+		}
+		path.DirKnot(side.from, side.dir).Line().DirKnot(side.to, side.dir).Curve()
+	}
+	path.Cycle()
+	return path, path.Controls
+}
+
+// Stadium creates a closed path for the stadium shape fitting into the
+// rectangle given by two opposite corners, i.e. a rectangle with its shorter
+// sides replaced by half circles. For a square the result is a circle.
+// Like RoundedRect, the result is a skeleton path.
+func Stadium(corner1, corner2 arithm.Pair) (*Path, SplineControls) {
+	w, h := math.Abs(corner2.X()-corner1.X()), math.Abs(corner2.Y()-corner1.Y())
+	return RoundedRect(corner1, corner2, math.Min(w, h)/2)
+}
@@ -0,0 +1,147 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Path Simplification ---------------------------------------------------
+
+// samplesPerSegment is the number of sample points per cubic segment used
+// for measuring the distance between two curves.
+const samplesPerSegment = 16
+
+// Simplify removes knots from a path whose omission changes the solved curve
+// by less than tolerance. The deviation is measured as the (sampled)
+// Hausdorff distance between the original curve and the curve of the
+// re-solved candidate path. Digitized input often has far more knots than
+// necessary to describe a shape.
+//
+// Knots carrying explicit parameters (dirs or curls) which make them
+// breakpoints for the solver are never removed, neither are the end points of
+// an open path. A cyclic path will keep at least 3 knots.
+// If controls is nil, the control points of the input path will be calculated
+// first. The input path remains unchanged; Simplify returns a new path together
+// with its (solved) control points.
+//
+// Simplification is rather expensive, as every candidate knot requires
+// re-solving the path.
+func Simplify(path HobbyPath, controls SplineControls, tolerance float64) (*Path, SplineControls) {
+	if controls == nil {
+		controls = FindHobbyControls(path, nil)
+	}
+	original := sampleCurve(path, controls)
+	keep := make([]bool, path.N())
+	for i := range keep {
+		keep[i] = true
+	}
+	minKnots := 2
+	if path.IsCycle() {
+		minKnots = 3
+	}
+	count := path.N()
+	for i := 0; i < path.N() && count > minKnots; i++ {
+		if isrough(path, i) || (!path.IsCycle() && (i == 0 || i == last(path))) {
+			continue
+		}
+		keep[i] = false
+		candidate := copyPath(path, keep)
+		cctrls := FindHobbyControls(candidate, candidate.Controls)
+		if hausdorff(original, sampleCurve(candidate, cctrls)) < tolerance {
+			T().Debugf("simplify: removing knot %s", ptstring(path.Z(i), false))
+			count--
+		} else {
+			keep[i] = true
+		}
+	}
+	simple := copyPath(path, keep)
+	return simple, FindHobbyControls(simple, simple.Controls)
+}
+
+// copyPath creates a new skeleton path from the knots of path for which
+// keep[i] is true, preserving the knot and join parameters.
+func copyPath(path HobbyPath, keep []bool) *Path {
+	p := Nullpath()
+	for i := 0; i < path.N(); i++ {
+		if keep != nil && !keep[i] {
+			continue
+		}
+		p.points = append(p.points, path.Z(i))
+		j := p.N() - 1
+		if lp, ok := path.(*Path); ok {
+			if info := getInfo(lp.infos, i); !info.empty() {
+				p.infos = extendInfo(p.infos, j)
+				p.infos[j] = info
+			}
+		}
+		if dir := path.PreDir(i); !cmplx.IsNaN(dir.C()) {
+			p.SetPreDir(j, dir)
+		}
+		if dir := path.PostDir(i); !cmplx.IsNaN(dir.C()) {
+			p.SetPostDir(j, dir)
+		}
+		rough := isGivenRough(path, i)
+		if curl := path.PreCurl(i); curl != 1.0 || rough {
+			p.SetPreCurl(j, curl)
+		}
+		if curl := path.PostCurl(i); curl != 1.0 || rough {
+			p.SetPostCurl(j, curl)
+		}
+		if t := path.PreTension(i); t != 1.0 {
+			p.SetPreTension(j, t)
+		}
+		if t := path.PostTension(i); t != 1.0 {
+			p.SetPostTension(j, t)
+		}
+		if c := explicitPreControl(path, i); !cmplx.IsNaN(c.C()) {
+			p.exprec = extendC(p.exprec, j, arithm.Pair(cmplx.NaN()))
+			p.exprec[j] = c
+		}
+		if c := explicitPostControl(path, i); !cmplx.IsNaN(c.C()) {
+			p.expostc = extendC(p.expostc, j, arithm.Pair(cmplx.NaN()))
+			p.expostc[j] = c
+		}
+	}
+	p.cycle = path.IsCycle()
+	return p
+}
+
+// sampleCurve returns a polyline approximating a solved path.
+func sampleCurve(path HobbyPath, controls SplineControls) []arithm.Pair {
+	segs := Segments(path, controls)
+	if len(segs) == 0 {
+		if path.N() == 1 {
+			return []arithm.Pair{path.Z(0)}
+		}
+		return nil
+	}
+	pts := make([]arithm.Pair, 0, len(segs)*samplesPerSegment+1)
+	for _, seg := range segs {
+		for k := 0; k < samplesPerSegment; k++ {
+			pts = append(pts, seg.At(float64(k)/samplesPerSegment))
+		}
+	}
+	return append(pts, segs[len(segs)-1].P3)
+}
+
+// hausdorff calculates the symmetric Hausdorff distance between two polylines.
+func hausdorff(a, b []arithm.Pair) float64 {
+	return math.Max(directedHausdorff(a, b), directedHausdorff(b, a))
+}
+
+// directedHausdorff is the maximum distance of a point of a from polyline b.
+func directedHausdorff(a, b []arithm.Pair) float64 {
+	var dmax float64
+	for _, pt := range a {
+		dmax = math.Max(dmax, polylineDistance(pt, b))
+	}
+	return dmax
+}
+
+// segmentDistance is the distance of point pt from the line segment a–b.
+func segmentDistance(pt, a, b arithm.Pair) float64 {
+	q, _ := arithm.ProjectOnSegment(pt, a, b)
+	return cmplx.Abs((pt - q).C())
+}
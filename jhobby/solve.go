@@ -0,0 +1,342 @@
+package jhobby
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"runtime"
+	"sync"
+
+	"github.com/npillmayer/arithm"
+	"github.com/npillmayer/schuko/gconf"
+)
+
+// --- Solver Options --------------------------------------------------------
+
+// SolveOption configures the calculation of spline control points.
+// Options are passed to FindHobbyControls.
+type SolveOption func(*solveConfig)
+
+// solveConfig collects the options for a call to FindHobbyControls.
+type solveConfig struct {
+	workers  int        // max number of goroutines solving segments concurrently
+	g2       bool       // iterate to curvature continuity
+	trace    io.Writer  // tracing sink for this call, or nil
+	traceMx  sync.Mutex // segments may be traced concurrently
+	mergeEps float64    // merge consecutive knots closer than this, if > 0
+	eps      float64    // geometric tolerance, 0 for default
+	rep      *SolveReport
+	repMx    sync.Mutex // segments may be reported concurrently
+	events   func(SolveEvent)
+	eventMx  sync.Mutex // calls to events are serialized
+	endCurl  float64    // curl at the end points of open paths, if not NaN
+	prec     uint       // bits of mantissa for solving the equations, 0 for float64
+}
+
+// parallelThreshold is the minimum number of segments of a path for which
+// solving segments concurrently pays off.
+const parallelThreshold = 32
+
+func newSolveConfig(opts []SolveOption) *solveConfig {
+	conf := &solveConfig{
+		workers: runtime.GOMAXPROCS(0),
+		endCurl: math.NaN(),
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return conf
+}
+
+// WithWorkers sets the maximum number of goroutines used to solve independent
+// segments of a path concurrently. Segments are separated by rough knots,
+// i.e., knots with explicit directions or curls. Paths with many segments
+// are solved concurrently by default, using up to GOMAXPROCS workers.
+// A value of n ≤ 1 disables concurrent solving.
+func WithWorkers(n int) SolveOption {
+	return func(conf *solveConfig) {
+		conf.workers = n
+	}
+}
+
+// WithCurvatureContinuity selects an alternative solver mode, which
+// produces splines with continuous curvature (G2) at smooth knots. Hobby's
+// algorithm only guarantees continuous tangents (G1), as it equalizes a
+// "mock curvature" only. With this option, the tangent angles found by
+// Hobby's algorithm are refined iteratively until the true curvatures at
+// both sides of every smooth knot match. Rough knots and end points of open
+// segments keep the tangents found by Hobby's algorithm.
+//
+// The refinement is more expensive than Hobby's algorithm and is intended
+// for applications where G2 continuity matters, e.g. font outlines. Paths
+// may select it for themselves, see Path.SetCurvatureContinuity.
+func WithCurvatureContinuity() SolveOption {
+	return func(conf *solveConfig) {
+		conf.g2 = true
+	}
+}
+
+// WithTracing directs the tracing output of a single call to
+// FindHobbyControls to w. Tracing output includes the segments of the path
+// and the calculated control points (as with MetaFont's "tracingchoices"),
+// as well as the intermediate values of the solver.
+// Tracing to w is independent of the configuration key "tracingchoices" and
+// of the global graphics tracer, thus it will not affect other paths or other
+// packages of the same process.
+func WithTracing(w io.Writer) SolveOption {
+	return func(conf *solveConfig) {
+		conf.trace = w
+	}
+}
+
+// WithEpsilon sets the geometric tolerance for a call to FindHobbyControls,
+// overriding the tolerance of the path (see Path.SetEpsilon). Lengths below
+// eps are considered to be zero. The tolerance should be chosen according
+// to the units of the path's coordinates; for example, paths in font units
+// need a much larger tolerance than paths in points. eps ≤ 0 selects the
+// default tolerance.
+func WithEpsilon(eps float64) SolveOption {
+	return func(conf *solveConfig) {
+		conf.eps = eps
+	}
+}
+
+// WithEndpointCurl sets the curl at the end points of open paths, for end
+// points without an explicitly given curl. The default curl is 1, larger
+// curls result in straighter ends of a path (e.g., "{curl 2}" in MetaFont).
+// The option sets this boundary condition for all open paths of a call,
+// instead of having to give the curl at every end point.
+//
+// Curls at rough knots inside of a path are not affected.
+func WithEndpointCurl(curl float64) SolveOption {
+	return func(conf *solveConfig) {
+		conf.endCurl = curl
+	}
+}
+
+// WithArbitraryPrecision selects solving the linear equations for the
+// tangent angles at the knots in arbitrary precision arithmetic, with prec
+// bits of mantissa (see arithm.Number). The coefficients of the equations
+// are derived from the knots, tensions and curls in float64 precision, but
+// eliminating and back-substituting them, where rounding errors accumulate
+// for long paths, is done at precision prec. The resulting angles are
+// rounded to float64.
+//
+// The option is intended for producing reference values when investigating
+// the rounding behaviour of the solver; it is considerably slower.
+// prec = 0 selects the default float64 arithmetic.
+func WithArbitraryPrecision(prec uint) SolveOption {
+	return func(conf *solveConfig) {
+		conf.prec = prec
+	}
+}
+
+// num converts x to the number type the equations are solved in.
+func (conf *solveConfig) num(x float64) arithm.Number {
+	if conf.prec == 0 {
+		return arithm.Float(x)
+	}
+	return arithm.NewBigNumber(x, conf.prec)
+}
+
+// curl returns the curl before (post=false) or after (post=true) knot #i of
+// a segment, replacing the default curl at the end points of an open path
+// with the one set by WithEndpointCurl.
+func (conf *solveConfig) curl(path HobbyPath, i int, post bool) float64 {
+	c := path.PreCurl(i)
+	if post {
+		c = path.PostCurl(i)
+	}
+	if math.IsNaN(conf.endCurl) {
+		return c
+	}
+	whole, k := path, i
+	if pp, ok := path.(*pathPartial); ok {
+		whole, k = pp.whole, pp.pmap(i)
+	}
+	if whole.IsCycle() || (k != 0 && k != whole.N()-1) || hasGivenCurl(whole, k, post) {
+		return c
+	}
+	return conf.endCurl
+}
+
+// fromPath sets the options a path carries itself: its geometric tolerance,
+// if none has been given as an option, and curvature continuity.
+func (conf *solveConfig) fromPath(path HobbyPath) {
+	if conf.eps <= 0 {
+		conf.eps = epsilonOf(path)
+	}
+	if p, ok := path.(*Path); ok && p.g2 {
+		conf.g2 = true
+	}
+}
+
+// infof traces a message to the tracing sink of the call, if set, or to the
+// global graphics tracer otherwise.
+func (conf *solveConfig) infof(format string, args ...interface{}) {
+	if conf.trace == nil {
+		T().Infof(format, args...)
+		return
+	}
+	conf.traceMx.Lock()
+	defer conf.traceMx.Unlock()
+	fmt.Fprintf(conf.trace, format+"\n", args...)
+}
+
+// debugf traces intermediate values of the solver to the tracing sink of the
+// call, if set, or to the global graphics tracer otherwise.
+func (conf *solveConfig) debugf(format string, args ...interface{}) {
+	if conf.trace == nil {
+		T().Debugf(format, args...)
+		return
+	}
+	conf.infof(format, args...)
+}
+
+// tracingChoices is true if tracing to a sink or if "tracingchoices" is set.
+func (conf *solveConfig) tracingChoices() bool {
+	return conf.trace != nil || gconf.IsSet("tracingchoices")
+}
+
+// traceChoices traces the result of solving a segment, if tracing to a sink
+// or if "tracingchoices" is set.
+func (conf *solveConfig) traceChoices(s string) {
+	if conf.tracingChoices() {
+		conf.infof("%s", s)
+	}
+}
+
+// --- Concurrent Solving ----------------------------------------------------
+
+// solveSegments finds the control points for all segments of a path,
+// concurrently if configured and worthwhile.
+func solveSegments(path HobbyPath, segments []*pathPartial, controls SplineControls, conf *solveConfig) {
+	if conf.workers <= 1 || len(segments) < parallelThreshold {
+		for _, segment := range segments {
+			solveSegment(path, segment, controls, conf)
+		}
+		return
+	}
+	conf.debugf("solving %d segments with %d workers", len(segments), conf.workers)
+	recorders := make([]*ctrlRecorder, len(segments))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < conf.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				recorders[k] = newCtrlRecorder()
+				solveSegment(path, segments[k], recorders[k], conf)
+			}
+		}()
+	}
+	for k := range segments {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+	for _, rec := range recorders { // copy results in segment order
+		for i, c := range rec.post {
+			controls.SetPostControl(i, c)
+		}
+		for i, c := range rec.pre {
+			controls.SetPreControl(i, c)
+		}
+	}
+}
+
+// ctrlRecorder collects the control points of a single segment. Segments are
+// solved into private recorders when solving concurrently, as clients'
+// implementations of SplineControls are not expected to be safe for
+// concurrent use.
+type ctrlRecorder struct {
+	pre, post map[int]arithm.Pair
+}
+
+var _ SplineControls = &ctrlRecorder{}
+
+func newCtrlRecorder() *ctrlRecorder {
+	return &ctrlRecorder{
+		pre:  make(map[int]arithm.Pair),
+		post: make(map[int]arithm.Pair),
+	}
+}
+
+func (rec *ctrlRecorder) PreControl(i int) arithm.Pair {
+	if c, ok := rec.pre[i]; ok {
+		return c
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (rec *ctrlRecorder) PostControl(i int) arithm.Pair {
+	if c, ok := rec.post[i]; ok {
+		return c
+	}
+	return arithm.Pair(cmplx.NaN())
+}
+
+func (rec *ctrlRecorder) SetPreControl(i int, c arithm.Pair) {
+	rec.pre[i] = c
+}
+
+func (rec *ctrlRecorder) SetPostControl(i int, c arithm.Pair) {
+	rec.post[i] = c
+}
+
+// --- Scratch Buffers -------------------------------------------------------
+
+// scratch holds the buffers of the linear equations for solving a segment.
+// Buffers are pooled, as applications re-solving paths frequently (e.g., for
+// every frame of an animation) would otherwise allocate them for every
+// segment and every call.
+type scratch struct {
+	u, v, w []arithm.Number
+	theta   []float64
+}
+
+var scratchPool = sync.Pool{
+	New: func() interface{} { return &scratch{} },
+}
+
+// getScratch returns zeroed buffers of length n from the pool.
+func getScratch(n int) *scratch {
+	buf := scratchPool.Get().(*scratch)
+	buf.u = zeroedNumbers(buf.u, n)
+	buf.v = zeroedNumbers(buf.v, n)
+	buf.w = zeroedNumbers(buf.w, n)
+	buf.theta = zeroed(buf.theta, n)
+	return buf
+}
+
+// putScratch returns buffers to the pool. Buffers must not be referenced
+// after returning them.
+func putScratch(buf *scratch) {
+	scratchPool.Put(buf)
+}
+
+// zeroed resizes a buffer to length n and clears it, re-allocating only if
+// its capacity is too small.
+func zeroed(arr []float64, n int) []float64 {
+	if cap(arr) < n {
+		return make([]float64, n)
+	}
+	arr = arr[:n]
+	for i := range arr {
+		arr[i] = 0
+	}
+	return arr
+}
+
+func zeroedNumbers(arr []arithm.Number, n int) []arithm.Number {
+	if cap(arr) < n {
+		return make([]arithm.Number, n)
+	}
+	arr = arr[:n]
+	for i := range arr {
+		arr[i] = nil
+	}
+	return arr
+}
@@ -0,0 +1,83 @@
+package jhobby
+
+import (
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Splitting Paths at Rough Knots ----------------------------------------
+
+// SplitAtRoughKnots splits a path into the independent pieces which are
+// solved separately by FindHobbyControls. Pieces are separated by rough
+// knots, i.e. knots with explicit directions, curls or control points, and
+// every rough knot is the last knot of one piece and the first knot of the
+// next one. A cyclic path without rough knots results in a single cyclic
+// piece. A cyclic path with rough knots is broken up into open pieces,
+// starting at its first rough knot.
+//
+// Every piece is a skeleton path carrying the parameters of its knots and
+// joins, as seen by the solver: directions implied by adjacent explicit
+// control points (see MetaFont's rules for "controls") are made explicit,
+// and parameters of the first and last knot pointing outside of the piece
+// are dropped. Labels and client data of knots are retained. Solving the
+// pieces results in the same control points as solving the path as a whole.
+func SplitAtRoughKnots(path HobbyPath) []*Path {
+	segments := splitSegments(path, newSolveConfig(nil))
+	pieces := make([]*Path, len(segments))
+	for k, segment := range segments {
+		pieces[k] = copyPartial(segment)
+	}
+	return pieces
+}
+
+// copyPartial creates a skeleton path from a segment of a path.
+func copyPartial(pp *pathPartial) *Path {
+	p := Nullpath()
+	n := pp.N()
+	cycle := pp.IsCycle()
+	nan := arithm.Pair(cmplx.NaN())
+	for i := 0; i < n; i++ {
+		j := pp.pmap(i)
+		p.points = append(p.points, pp.Z(i))
+		if lp, ok := pp.whole.(*Path); ok {
+			if info := getInfo(lp.infos, j); !info.empty() {
+				p.infos = extendInfo(p.infos, i)
+				p.infos[i] = info
+			}
+		}
+		hasPre, hasPost := cycle || i > 0, cycle || i < n-1
+		if hasPre {
+			if dir := pp.PreDir(i); !cmplx.IsNaN(dir.C()) {
+				p.SetPreDir(i, dir)
+			}
+			if hasGivenCurl(pp.whole, j, false) {
+				p.SetPreCurl(i, pp.PreCurl(i))
+			}
+			if t := pp.PreTension(i); t != 1.0 {
+				p.SetPreTension(i, t)
+			}
+			if c := explicitPreControl(pp.whole, j); !cmplx.IsNaN(c.C()) {
+				p.exprec = extendC(p.exprec, i, nan)
+				p.exprec[i] = c
+			}
+		}
+		if hasPost {
+			if dir := pp.PostDir(i); !cmplx.IsNaN(dir.C()) {
+				p.SetPostDir(i, dir)
+			}
+			if hasGivenCurl(pp.whole, j, true) {
+				p.SetPostCurl(i, pp.PostCurl(i))
+			}
+			if t := pp.PostTension(i); t != 1.0 {
+				p.SetPostTension(i, t)
+			}
+			if c := explicitPostControl(pp.whole, j); !cmplx.IsNaN(c.C()) {
+				p.expostc = extendC(p.expostc, i, nan)
+				p.expostc[i] = c
+			}
+		}
+	}
+	p.cycle = cycle
+	return p
+}
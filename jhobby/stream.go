@@ -0,0 +1,110 @@
+package jhobby
+
+import (
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Streaming Paths -------------------------------------------------------
+
+// streamLookahead is the number of knots beyond a window which are taken into
+// account when solving the window. The influence of a knot on the tangents
+// of its predecessors decays by a factor of about 0.27 per knot.
+const streamLookahead = 8
+
+// Stream constructs very long open paths of smooth knots, e.g. from plotted
+// data, in bounded memory. Knots are collected in windows; as soon as a
+// window and its lookahead are complete, the window is solved and its
+// segments are fed into a PathSink. Only the knots of the current window and
+// its lookahead are kept in memory.
+//
+// The tangent at the first knot of a window is fixed to the one found for
+// the previous window, so the resulting curve is smooth at the window
+// boundaries. As Hobby's algorithm considers all knots of a segment, the
+// control points will differ slightly from solving the path as a whole, due
+// to the limited lookahead. Increasing the window size does not decrease
+// this error, but reduces the number of window boundaries.
+//
+//	s := NewStream(sink, 1000)
+//	for _, pt := range samples {
+//	    s.Knot(pt)
+//	}
+//	s.End()
+type Stream struct {
+	sink    PathSink
+	window  int
+	opts    []SolveOption
+	knots   []arithm.Pair
+	dir     arithm.Pair // tangent at the first knot of the current window, or NaN
+	started bool        // MoveTo has been emitted
+}
+
+// NewStream creates a stream for an open path, feeding solved segments into
+// sink. Parameter window is the number of segments to solve at a time.
+// Solve options are passed on to FindHobbyControls for every window.
+func NewStream(sink PathSink, window int, opts ...SolveOption) *Stream {
+	if window < 1 {
+		window = 1
+	}
+	return &Stream{
+		sink:   sink,
+		window: window,
+		opts:   opts,
+		knots:  make([]arithm.Pair, 0, window+streamLookahead+1),
+		dir:    arithm.Pair(cmplx.NaN()),
+	}
+}
+
+// Knot adds a smooth knot to the stream. Knots are connected by curves
+// (see Path.Curve). Segments are emitted as soon as a window is complete.
+func (s *Stream) Knot(p arithm.Pair) *Stream {
+	s.knots = append(s.knots, p)
+	if len(s.knots) > s.window+streamLookahead {
+		s.flush(s.window)
+	}
+	return s
+}
+
+// End solves and emits the remaining segments of the stream. The stream may
+// not be used afterwards.
+func (s *Stream) End() {
+	if len(s.knots) == 1 && !s.started {
+		s.sink.MoveTo(s.knots[0])
+	}
+	if len(s.knots) > 1 {
+		s.flush(len(s.knots) - 1)
+	}
+	s.knots = nil
+}
+
+// flush solves the buffered knots and emits the first n segments. The knot
+// ending the last emitted segment starts the next window.
+func (s *Stream) flush(n int) {
+	path := Nullpath()
+	if cmplx.IsNaN(s.dir.C()) {
+		path.Knot(s.knots[0])
+	} else {
+		path.PostDirKnot(s.knots[0], s.dir)
+	}
+	for _, p := range s.knots[1:] {
+		path.Curve().Knot(p)
+	}
+	controls := FindHobbyControls(path, path.Controls, s.opts...)
+	if !s.started {
+		s.sink.MoveTo(path.Z(0))
+		s.started = true
+	}
+	for i := 0; i < n; i++ {
+		seg := segmentAt(path, controls, i)
+		s.sink.CurveTo(seg.C1, seg.C2, seg.P3)
+	}
+	if n < path.N()-1 {
+		seg := segmentAt(path, controls, n)
+		s.dir = seg.C1 - seg.P0
+		if cmplx.Abs(s.dir.C()) == 0 {
+			s.dir = seg.P3 - seg.P0
+		}
+	}
+	s.knots = append(s.knots[:0], s.knots[n:]...)
+}
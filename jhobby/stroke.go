@@ -0,0 +1,242 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Stroking Paths --------------------------------------------------------
+
+// LineCap is the shape of the end points of a stroked open path.
+type LineCap int
+
+// Line caps, as known from PDF and SVG.
+const (
+	ButtCap   LineCap = iota // stroke ends at the end point
+	RoundCap                 // half circle around the end point
+	SquareCap                // stroke extends beyond the end point by half the width
+)
+
+// LineJoin is the shape of a stroked path at corners, i.e. at knots where the
+// direction of the path is discontinuous.
+type LineJoin int
+
+// Line joins, as known from PDF and SVG.
+const (
+	MiterJoin LineJoin = iota // outer edges extended until they meet
+	RoundJoin                 // circular arc around the corner
+	BevelJoin                 // outer edges connected by a straight line
+)
+
+// MiterLimit is the maximum ratio of the length of a miter to half the
+// stroke width. Corners exceeding it are beveled. The value is the default
+// of PDF.
+const MiterLimit = 10.0
+
+// strokePieces is the number of pieces every segment is split into before
+// offsetting it.
+const strokePieces = 4
+
+// StrokeOutline creates the outline of a solved path stroked with a given
+// width, as PDF or SVG renderers do. The outline consists of closed paths
+// with explicit control points, suitable for filling with the non-zero
+// winding rule. An open path results in a single outline, with caps at its
+// end points. A cyclic path results in two outlines, one for either side.
+// The control points of the outlines are calculated (see Path.Controls).
+//
+// Stroking is different from drawing with a pen (as MetaFont does), as the
+// outline is created by offsetting the path to either side. Offset curves
+// are approximated by cubic Bézier segments.
+func StrokeOutline(path HobbyPath, controls SplineControls, width float64,
+	lineCap LineCap, lineJoin LineJoin) []*Path {
+	//
+	var segs []CubicSegment
+	eps := epsilonOf(path)
+	for _, seg := range Segments(path, controls) {
+		if !seg.isPoint(eps) {
+			segs = append(segs, seg)
+		}
+	}
+	if len(segs) == 0 || width <= 0 {
+		return nil
+	}
+	h := width / 2
+	rev := make([]CubicSegment, len(segs))
+	for i, seg := range segs {
+		rev[len(segs)-1-i] = seg.Reversed()
+	}
+	if path.IsCycle() {
+		left, right := &contour{eps: eps}, &contour{eps: eps}
+		left.offsetSide(segs, h, lineJoin, true)
+		right.offsetSide(rev, h, lineJoin, true)
+		return []*Path{left.path(), right.path()}
+	}
+	outline := &contour{eps: eps}
+	outline.offsetSide(segs, h, lineJoin, false)
+	outline.cap(segs[len(segs)-1].P3, segs[len(segs)-1].direction(1, eps), h, lineCap)
+	outline.offsetSide(rev, h, lineJoin, false)
+	outline.cap(rev[len(rev)-1].P3, rev[len(rev)-1].direction(1, eps), h, lineCap)
+	return []*Path{outline.path()}
+}
+
+// contour collects cubic Bézier segments of a closed outline.
+type contour struct {
+	knots  []arithm.Pair
+	c1, c2 []arithm.Pair // control points of the join starting at knots[k]
+	eps    float64       // geometric tolerance of the stroked path
+}
+
+func (c *contour) current() arithm.Pair {
+	return c.knots[len(c.knots)-1]
+}
+
+func (c *contour) curveTo(c1, c2, p arithm.Pair) {
+	c.c1 = append(c.c1, c1)
+	c.c2 = append(c.c2, c2)
+	c.knots = append(c.knots, p)
+}
+
+// lineTo adds a straight line, if p differs from the current point.
+func (c *contour) lineTo(p arithm.Pair) {
+	if len(c.knots) == 0 {
+		c.knots = append(c.knots, p)
+		return
+	}
+	a := c.current()
+	if cmplx.Abs((p - a).C()) > c.eps {
+		c.curveTo(a+(p-a).Scaled(1.0/3), a+(p-a).Scaled(2.0/3), p)
+	}
+}
+
+// arcTo adds a circular arc around center from the current point, turning
+// by sweep (radians, counter-clockwise if positive).
+func (c *contour) arcTo(center arithm.Pair, sweep float64) {
+	pieces := int(math.Ceil(math.Abs(sweep) / (math.Pi / 2)))
+	theta := sweep / float64(pieces)
+	k := 4.0 / 3.0 * math.Tan(theta/4)
+	rot := arithm.Pair(cmplx.Rect(1, theta))
+	for i := 0; i < pieces; i++ {
+		p0 := c.current()
+		p3 := center + arithm.Pair((p0-center).C()*rot.C())
+		c1 := p0 + arithm.P(-(p0-center).Y(), (p0-center).X()).Scaled(k)
+		c2 := p3 - arithm.P(-(p3-center).Y(), (p3-center).X()).Scaled(k)
+		c.curveTo(c1, c2, p3)
+	}
+}
+
+// offsetSide adds the left offset curve of a sequence of segments, with
+// joins between them. For cycles, the join at the first knot is added, too.
+func (c *contour) offsetSide(segs []CubicSegment, h float64, lineJoin LineJoin, cycle bool) {
+	for i, seg := range segs {
+		for k := 0; k < strokePieces; k++ {
+			o := seg.piece(float64(k)/strokePieces, float64(k+1)/strokePieces).offset(h, c.eps)
+			c.lineTo(o.P0) // moves to the start of the contour, otherwise closes gaps
+			c.curveTo(o.C1, o.C2, o.P3)
+		}
+		if i < len(segs)-1 || cycle {
+			next := segs[(i+1)%len(segs)]
+			c.join(seg.P3, seg.direction(1, c.eps), next.direction(0, c.eps), h, lineJoin)
+		}
+	}
+}
+
+// join adds a line join at knot p, from incoming direction din to outgoing
+// direction dout, for the left side of a path.
+func (c *contour) join(p, din, dout arithm.Pair, h float64, lineJoin LineJoin) {
+	turn := din.Cross(dout)
+	dot := din.Dot(dout)
+	if math.Abs(turn) < 1e-9 && dot > 0 {
+		return // smooth
+	}
+	b := p + leftNormal(dout).Scaled(h)
+	if turn > 0 { // inner side of a left turn
+		c.lineTo(p)
+		c.lineTo(b)
+		return
+	}
+	switch lineJoin {
+	case RoundJoin:
+		c.arcTo(p, math.Atan2(turn, dot))
+	case MiterJoin:
+		a := c.current()
+		// intersect a + s⋅din with b − t⋅dout
+		s := (b - a).Cross(dout) / din.Cross(dout)
+		m := a + din.Scaled(s)
+		if !math.IsNaN(s) && s > 0 && cmplx.Abs((m-p).C()) <= MiterLimit*h {
+			c.lineTo(m)
+		}
+	}
+	c.lineTo(b)
+}
+
+// cap adds a line cap at end point p of a path with end direction d, from the
+// left side of the path to the right side.
+func (c *contour) cap(p, d arithm.Pair, h float64, lineCap LineCap) {
+	right := p - leftNormal(d).Scaled(h)
+	switch lineCap {
+	case RoundCap:
+		c.arcTo(p, -math.Pi)
+	case SquareCap:
+		c.lineTo(c.current() + d.Scaled(h))
+		c.lineTo(right + d.Scaled(h))
+	}
+	c.lineTo(right)
+}
+
+// path converts a contour into a closed path with explicit control points.
+func (c *contour) path() *Path {
+	c.lineTo(c.knots[0])
+	n := len(c.knots) - 1 // last knot closes the contour
+	p := Nullpath().SetEpsilon(c.eps)
+	for k := 0; k < n; k++ {
+		p.Knot(c.knots[k]).ExplicitCurve(c.c1[k], c.c2[k])
+	}
+	p.Cycle()
+	FindHobbyControls(p, p.Controls)
+	return p
+}
+
+// isPoint is true if all Bézier points of a segment coincide within eps.
+func (seg CubicSegment) isPoint(eps float64) bool {
+	for _, z := range []arithm.Pair{seg.C1, seg.C2, seg.P3} {
+		if cmplx.Abs((z - seg.P0).C()) > eps {
+			return false
+		}
+	}
+	return true
+}
+
+// piece returns the part of a segment between times t0 and t1.
+func (seg CubicSegment) piece(t0, t1 float64) CubicSegment {
+	if t1 < 1 {
+		seg, _ = seg.Split(t1)
+	}
+	if t0 > 0 {
+		_, seg = seg.Split(t0 / t1)
+	}
+	return seg
+}
+
+// offset approximates the curve parallel to a segment at distance h to the
+// left. The end points are displaced along the normals, the tangent lengths
+// are scaled according to the curvature at the end points. Lengths below eps
+// are considered to be zero.
+func (seg CubicSegment) offset(h, eps float64) CubicSegment {
+	d0, d1 := seg.direction(0, eps), seg.direction(1, eps)
+	o := CubicSegment{
+		P0: seg.P0 + leftNormal(d0).Scaled(h),
+		P3: seg.P3 + leftNormal(d1).Scaled(h),
+	}
+	f0 := math.Max(0, 1-h*startCurvature(seg.P0, seg.C1, seg.C2, eps))
+	f1 := math.Max(0, 1-h*endCurvature(seg.C1, seg.C2, seg.P3, eps))
+	o.C1 = o.P0 + (seg.C1 - seg.P0).Scaled(f0)
+	o.C2 = o.P3 + (seg.C2 - seg.P3).Scaled(f1)
+	return o
+}
+
+// leftNormal returns d rotated by 90° counter-clockwise.
+func leftNormal(d arithm.Pair) arithm.Pair {
+	return arithm.P(-d.Y(), d.X())
+}
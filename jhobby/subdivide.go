@@ -0,0 +1,41 @@
+package jhobby
+
+// --- Subdividing Paths -----------------------------------------------------
+
+// Subdivide creates a new path from a solved path, with every cubic segment
+// split into n pieces of equal time. The new path has explicit control points,
+// which are calculated, and describes the same curve as the original one.
+// Clients may use it before applying non-linear transforms which operate on
+// knots only.
+//
+// Knot labels and data of the original knots are preserved. Knot #i of the
+// original path is knot #i⋅n of the subdivided path.
+func Subdivide(path HobbyPath, controls SplineControls, n int) *Path {
+	if n < 1 {
+		n = 1
+	}
+	segs := Segments(path, controls)
+	if len(segs) == 0 {
+		p := Nullpath()
+		if path.N() > 0 {
+			p.Knot(path.Z(0))
+		}
+		return p
+	}
+	var pieces []CubicSegment
+	for _, seg := range segs {
+		for k := 0; k < n; k++ {
+			pieces = append(pieces, seg.piece(float64(k)/float64(n), float64(k+1)/float64(n)))
+		}
+	}
+	sub := pathFromSegments(pieces, path.IsCycle())
+	if p, ok := path.(*Path); ok {
+		for i, info := range p.infos {
+			if !info.empty() && i < p.N() {
+				sub.infos = extendInfo(sub.infos, i*n)
+				sub.infos[i*n] = info
+			}
+		}
+	}
+	return sub
+}
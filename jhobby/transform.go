@@ -0,0 +1,175 @@
+package jhobby
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/npillmayer/arithm"
+)
+
+// --- Transforming Paths ----------------------------------------------------
+
+// Transformed returns a copy of a path with an affine transform applied to
+// its knots, directions, and explicit and calculated control points.
+// Calculated control points are transformed as well, so there is no need
+// to solve the copy again.
+//
+// Please note that Hobby's algorithm is invariant to translation, rotation
+// and uniform scaling only. Solving a path with other transforms applied
+// may result in control points which differ from the transformed ones.
+//
+// Transformed is part of interface arithm.Transformable; the result is a
+// *Path.
+func (path *Path) Transformed(at arithm.AT) arithm.Transformable {
+	return path.transformed(at)
+}
+
+var _ arithm.Transformable = &Path{}
+var _ arithm.Transformable = &splcntrls{}
+
+// Transformed returns a copy of a container of control points with an affine
+// transform applied to all known control points. If the control points have
+// been calculated for a *Path, the copy is associated with a transformed
+// copy of that path, otherwise with no path.
+//
+// Transformed is part of interface arithm.Transformable; the result is a
+// SplineControls.
+func (ctrls *splcntrls) Transformed(at arithm.AT) arithm.Transformable {
+	c := &splcntrls{
+		prec:  clonePairs(ctrls.prec),
+		postc: clonePairs(ctrls.postc),
+	}
+	for _, arr := range [][]arithm.Pair{c.prec, c.postc} {
+		for i, z := range arr {
+			if !cmplx.IsNaN(z.C()) {
+				arr[i] = at.Transform(z)
+			}
+		}
+	}
+	if p, ok := ctrls.path.(*Path); ok {
+		tp := p.transformed(at)
+		tp.Controls, c.path = c, tp
+	}
+	return c
+}
+
+// Shifted returns a copy of a path, translated by v (MetaPost: "shifted v").
+func (path *Path) Shifted(v arithm.Pair) *Path {
+	return path.transformed(arithm.Translation(v))
+}
+
+// Scaled returns a copy of a path, scaled by factor f around the origin
+// (MetaPost: "scaled f").
+func (path *Path) Scaled(f float64) *Path {
+	return path.transformed(arithm.AT{f, 0, 0, 0, f, 0, 0, 0, 1})
+}
+
+// Rotated returns a copy of a path, rotated counter-clockwise around the
+// origin by theta (MetaPost: "rotated theta"). Contrary to MetaPost, theta
+// is given in radians.
+func (path *Path) Rotated(theta float64) *Path {
+	return path.transformed(arithm.Rotation(theta))
+}
+
+// ZScaled returns a copy of a path, rotated and scaled by multiplying every
+// point with p as a complex number (MetaPost: "zscaled p").
+func (path *Path) ZScaled(p arithm.Pair) *Path {
+	return path.transformed(arithm.AT{p.X(), -p.Y(), 0, p.Y(), p.X(), 0, 0, 0, 1})
+}
+
+// MirroredX returns a copy of a path, mirrored at the y-axis, i.e. with all
+// x-coordinates negated. Directions and (explicit or calculated) control
+// points are mirrored as well.
+//
+// Mirroring inverts the orientation of a path. To keep the winding direction
+// of a cyclic path intact, the knots of the mirrored copy of a cycle are
+// arranged in reverse order, starting with the (mirrored) first knot.
+func (path *Path) MirroredX() *Path {
+	return path.reflected(arithm.AT{-1, 0, 0, 0, 1, 0, 0, 0, 1})
+}
+
+// MirroredY returns a copy of a path, mirrored at the x-axis, i.e. with all
+// y-coordinates negated.
+//
+// See MirroredX.
+func (path *Path) MirroredY() *Path {
+	return path.reflected(arithm.AT{1, 0, 0, 0, -1, 0, 0, 0, 1})
+}
+
+// ReflectedAbout returns a copy of a path, reflected about the line through
+// p1 and p2 (MetaPost: "reflectedabout(p1,p2)"). If p1 and p2 coincide, an
+// unchanged copy is returned.
+//
+// See MirroredX.
+func (path *Path) ReflectedAbout(p1, p2 arithm.Pair) *Path {
+	v := p2 - p1
+	if cmplx.Abs(v.C()) < path.epsilon() {
+		return path.Clone()
+	}
+	a := 2 * cmplx.Phase(v.C())
+	cos, sin := math.Cos(a), math.Sin(a)
+	reflect := arithm.AT{cos, sin, 0, sin, -cos, 0, 0, 0, 1}
+	at := arithm.Translation(-p1).Combine(reflect).Combine(arithm.Translation(p1))
+	return path.reflected(at)
+}
+
+// reflected applies a reflection to a copy of a path and reverses cycles to
+// restore their orientation.
+func (path *Path) reflected(at arithm.AT) *Path {
+	p := path.transformed(at)
+	if p.cycle {
+		p = p.reversed()
+	}
+	return p
+}
+
+// transformed returns a copy of a path with an affine transform applied to
+// knots, directions, and explicit and calculated control points.
+func (path *Path) transformed(at arithm.AT) *Path {
+	p := path.Clone()
+	origin := at.Transform(arithm.Origin)
+	mapAll := func(arr []arithm.Pair, f func(arithm.Pair) arithm.Pair) {
+		for i, z := range arr {
+			if !cmplx.IsNaN(z.C()) {
+				arr[i] = f(z)
+			}
+		}
+	}
+	point := func(z arithm.Pair) arithm.Pair { return at.Transform(z) }
+	dir := func(d arithm.Pair) arithm.Pair { return at.Transform(d) - origin }
+	mapAll(p.points, point)
+	mapAll(p.exprec, point)
+	mapAll(p.expostc, point)
+	mapAll(p.Controls.prec, point)
+	mapAll(p.Controls.postc, point)
+	mapAll(p.predirs, dir)
+	mapAll(p.postdirs, dir)
+	return p
+}
+
+// reversed returns a copy of a path with its knots in reverse order
+// (MetaPost: "reverse p"). For a cycle, the first knot remains the first.
+// Incoming and outgoing parameters of every knot are swapped.
+func (path *Path) reversed() *Path {
+	p := path.Clone()
+	p.normalize()
+	n := p.N()
+	old := path.Clone()
+	old.normalize()
+	swap := func(c arithm.Pair) arithm.Pair { return arithm.Pair(complex(imag(c), real(c))) }
+	for j := 0; j < n; j++ {
+		i := n - 1 - j
+		if p.cycle {
+			i = (n - j) % n
+		}
+		p.points[j] = old.points[i]
+		p.predirs[j] = -old.postdirs[i]
+		p.postdirs[j] = -old.predirs[i]
+		p.curls[j] = swap(old.curls[i])
+		p.tensions[j] = swap(old.tensions[i])
+		p.exprec[j], p.expostc[j] = old.expostc[i], old.exprec[i]
+		p.infos[j] = old.infos[i]
+		p.Controls.prec[j], p.Controls.postc[j] = old.Controls.postc[i], old.Controls.prec[i]
+	}
+	return p
+}
@@ -0,0 +1,84 @@
+package jhobby
+
+import (
+	"errors"
+	"fmt"
+)
+
+// --- Structural Validation -------------------------------------------------
+
+// buildState records the kind of the last builder call for a path.
+type buildState uint8
+
+const (
+	buildNone buildState = iota // no knot added yet, or path closed
+	buildKnot                   // last call added a knot
+	buildJoin                   // last call added a join
+)
+
+// Validate checks a path for misuse of the builder functions. Builder calls
+// have to alternate between knots and joins, starting with a knot, and open
+// paths have to end with a knot, whereas Cycle() has to follow a join. The
+// interfaces KnotAdder and JoinAdder enforce this for chained calls, but
+// clients calling the builder functions on a *Path directly, e.g. in a loop,
+// may violate it. Validate reports
+//
+//   - knots which follow another knot without a join,
+//   - joins which follow another join without a knot,
+//   - a dangling join at the end of an open path,
+//   - a cycle closed without a join.
+//
+// Validate returns the first violation, or nil. Paths which have not been
+// created by builder calls (e.g., decoded or imported paths) are always
+// valid. Violations are recorded together with invalid arguments to builder
+// functions, thus Validate returns the error reported by Err, if any, and
+// additionally checks for a dangling join, which Err cannot detect while the
+// path is being built.
+func (path *Path) Validate() error {
+	if path.err != nil {
+		return path.err
+	}
+	if !path.cycle && path.build == buildJoin {
+		return errors.New("dangling join after last knot")
+	}
+	return nil
+}
+
+// misused records the first error of builder calls.
+func (path *Path) misused(msg string) {
+	if path.err == nil {
+		path.err = errors.New(msg)
+	}
+}
+
+// noteKnot records the addition of a knot by a builder call.
+func (path *Path) noteKnot() {
+	if path.build == buildKnot {
+		path.misused(fmt.Sprintf("knot #%d follows knot #%d without a join", path.N(), path.N()-1))
+	}
+	path.build = buildKnot
+}
+
+// noteJoin records the addition of a join by a builder call.
+func (path *Path) noteJoin() {
+	if path.build == buildJoin {
+		path.misused(fmt.Sprintf("join after knot #%d follows another join", path.N()-1))
+	}
+	path.build = buildJoin
+}
+
+// noteConcat records the concatenation of a path, which has to follow a knot.
+func (path *Path) noteConcat() {
+	if path.build == buildJoin {
+		path.misused(fmt.Sprintf("concatenation at knot #%d follows a join", path.N()-1))
+	}
+	path.build = buildKnot
+}
+
+// noteCycle records the closing of a cycle, which has to follow a join.
+func (path *Path) noteCycle() {
+	if path.build == buildKnot {
+		path.misused(fmt.Sprintf("cycle closed without a join after knot #%d", path.N()-1))
+	}
+	path.build = buildNone
+}
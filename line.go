@@ -0,0 +1,58 @@
+package arithm
+
+import "math"
+
+// === Lines =================================================================
+
+// Line is an infinite straight line, given by a point on the line and its
+// direction. The direction must not be (0,0).
+type Line struct {
+	P   Pair // a point on the line
+	Dir Pair // direction of the line
+}
+
+// LineThrough creates the line through points a and b, directed from a to b.
+func LineThrough(a, b Pair) Line {
+	return Line{P: a, Dir: b - a}
+}
+
+// At returns the point at parameter t of a line, i.e. l.P + t⋅l.Dir.
+func (l Line) At(t float64) Pair {
+	return l.P + l.Dir.Scaled(t)
+}
+
+// Intersect returns the intersection point of two lines. If the lines are
+// parallel (or one of them is degenerate), the second return value is false.
+// This corresponds to MetaPost's idiom "z = whatever[z1,z2] = whatever[z3,z4]".
+func (l Line) Intersect(m Line) (Pair, bool) {
+	d := l.Dir.Cross(m.Dir)
+	if l.Dir.IsOrigin() || m.Dir.IsOrigin() || Is0(d/(l.Dir.Length()*m.Dir.Length())) {
+		return Origin, false
+	}
+	t := (m.P - l.P).Cross(m.Dir) / d
+	return P(l.P.X()+t*l.Dir.X(), l.P.Y()+t*l.Dir.Y()), true
+}
+
+// Project returns the point on the line closest to p, i.e. the foot of the
+// perpendicular from p.
+func (l Line) Project(p Pair) Pair {
+	l2 := l.Dir.LengthSquared()
+	if l2 == 0 {
+		return l.P
+	}
+	t := (p - l.P).Dot(l.Dir) / l2
+	return P(l.P.X()+t*l.Dir.X(), l.P.Y()+t*l.Dir.Y())
+}
+
+// Side tells on which side of the (directed) line p lies: 1 for the left
+// side, -1 for the right side (with the y-axis pointing upwards), and 0 if p
+// lies on the line, within Epsilon.
+func (l Line) Side(p Pair) int {
+	dist := l.Dir.Cross(p-l.P) / l.Dir.Length()
+	if Is0(dist) || math.IsNaN(dist) { // on the line or degenerate line
+		return 0
+	} else if dist > 0 {
+		return 1
+	}
+	return -1
+}
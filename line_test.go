@@ -0,0 +1,29 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestLine(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	l := LineThrough(P(0, 0), P(2, 2))
+	m := LineThrough(P(0, 4), P(4, 0))
+	if p, ok := l.Intersect(m); !ok || !p.Equal(P(2, 2)) {
+		t.Errorf("Expected lines to intersect at (2,2), is %v", p)
+	}
+	if _, ok := l.Intersect(LineThrough(P(1, 0), P(3, 2))); ok {
+		t.Errorf("Expected parallel lines not to intersect")
+	}
+	if p := l.Project(P(2, 0)); !p.Equal(P(1, 1)) {
+		t.Errorf("Expected projection of (2,0) to be (1,1), is %v", p)
+	}
+	if l.Side(P(0, 1)) != 1 || l.Side(P(1, 0)) != -1 || l.Side(P(3, 3)) != 0 {
+		t.Errorf("Expected (0,1) left of, (1,0) right of and (3,3) on the line")
+	}
+	if p := l.At(0.5); !p.Equal(P(1, 1)) {
+		t.Errorf("Expected midpoint (1,1), is %v", p)
+	}
+}
@@ -0,0 +1,53 @@
+package arithm
+
+import "math"
+
+// === Dense Linear Systems ==================================================
+
+// SolveLinear solves a square system of linear equations a⋅x = b by
+// Gaussian elimination with partial pivoting. a is given by rows; a and b
+// are not altered. SolveLinear is intended for the small, dense systems
+// arising in fitting and in Newton iterations; sparse systems of equations
+// are the domain of package polyn.
+//
+// SolveLinear returns false if a is singular, i.e. if a pivot is 0 within
+// Epsilon, relative to the largest coefficient of a. The test is thus
+// independent of the units of the coefficients.
+func SolveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n) // augmented matrix
+	scale := 0.0
+	for r := range m {
+		m[r] = append(append(make([]float64, 0, n+1), a[r]...), b[r])
+		for _, v := range a[r] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) <= Epsilon*scale {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for r := col + 1; r < n; r++ {
+			f := m[r][col] / m[col][col]
+			for c := col; c <= n; c++ {
+				m[r][c] -= f * m[col][c]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := m[r][n]
+		for c := r + 1; c < n; c++ {
+			s -= m[r][c] * x[c]
+		}
+		x[r] = s / m[r][r]
+	}
+	return x, true
+}
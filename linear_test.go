@@ -0,0 +1,29 @@
+package arithm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestSolveLinear(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	a := [][]float64{{0, 2, 1}, {1, 1, 1}, {2, 0, -1}} // needs pivoting
+	b := []float64{7, 6, -1}
+	x, ok := SolveLinear(a, b)
+	if !ok || math.Abs(x[0]-1) > 1e-12 || math.Abs(x[1]-2) > 1e-12 || math.Abs(x[2]-3) > 1e-12 {
+		t.Errorf("Expected solution (1,2,3), is %v", x)
+	}
+	if a[0][0] != 0 || b[0] != 7 {
+		t.Errorf("Expected system to be unaltered")
+	}
+	tiny := [][]float64{{1e-9, 2e-9}, {3e-9, 1e-9}} // not singular, in small units
+	if x, ok := SolveLinear(tiny, []float64{5e-9, 5e-9}); !ok || math.Abs(x[0]-1) > 1e-9 || math.Abs(x[1]-2) > 1e-9 {
+		t.Errorf("Expected solution (1,2) for small coefficients, is %v", x)
+	}
+	if _, ok := SolveLinear([][]float64{{1e6, 2e6}, {2e6, 4e6}}, []float64{1, 2}); ok {
+		t.Errorf("Expected singular system to be detected")
+	}
+}
@@ -0,0 +1,45 @@
+package arithm
+
+import "math"
+
+// === Point Sets ============================================================
+
+// Centroid returns the arithmetic mean of a set of points. The centroid of
+// an empty set is the origin.
+func Centroid(pts []Pair) Pair {
+	if len(pts) == 0 {
+		return Origin
+	}
+	var sx, sy float64
+	for _, p := range pts {
+		sx += p.X()
+		sy += p.Y()
+	}
+	n := float64(len(pts))
+	return P(sx/n, sy/n)
+}
+
+// Bounds returns the smallest axis-aligned rectangle enclosing a set of
+// points. The bounds of an empty set are empty.
+func Bounds(pts []Pair) Rect {
+	return EnclosingRect(pts...)
+}
+
+// PrincipalAxis returns the line which fits a set of points best in the
+// least-squares sense, i.e. the line through the centroid which minimizes
+// the sum of squared perpendicular distances of the points. Its direction
+// is a unit vector pointing to the right half-plane (or upwards, for
+// vertical axes). For sets without a preferred direction, e.g. a single
+// point, the axis is horizontal.
+func PrincipalAxis(pts []Pair) Line {
+	c := Centroid(pts)
+	var sxx, sxy, syy float64
+	for _, p := range pts {
+		d := p - c
+		sxx += d.X() * d.X()
+		sxy += d.X() * d.Y()
+		syy += d.Y() * d.Y()
+	}
+	theta := math.Atan2(2*sxy, sxx-syy) / 2 // in (-π/2,π/2]
+	return Line{P: c, Dir: P(math.Cos(theta), math.Sin(theta))}
+}
@@ -0,0 +1,29 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestPointSets(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	pts := []Pair{P(0, 0), P(4, 0), P(4, 2), P(0, 2)}
+	if c := Centroid(pts); !c.Equal(P(2, 1)) {
+		t.Errorf("Expected centroid (2,1), is %v", c)
+	}
+	if b := Bounds(pts); b != NewRect(P(0, 0), P(4, 2)) {
+		t.Errorf("Expected bounds (0,0)…(4,2), is %v", b)
+	}
+	if a := PrincipalAxis(pts); !a.Dir.Equal(P(1, 0)) {
+		t.Errorf("Expected horizontal principal axis, is %v", a.Dir)
+	}
+	diag := []Pair{P(1, 1.1), P(2, 1.9), P(3, 3.1), P(4, 3.9)}
+	if a := PrincipalAxis(diag); a.Dir.X() <= 0 || !Tolerance(2).Is0(a.Dir.AngleDeg()-45) {
+		t.Errorf("Expected principal axis at 45°, is %v", a.Dir)
+	}
+	if Centroid(nil) != Origin || !Bounds(nil).IsEmpty() {
+		t.Errorf("Expected empty point set to have centroid at origin and empty bounds")
+	}
+}
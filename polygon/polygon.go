@@ -248,6 +248,15 @@ func Transform(pg Polygon, t arithm.AT) Polygon {
 	return ptransformed
 }
 
+var _ arithm.Transformable = &GPPolygon{}
+
+// Transformed applies an affine transform to (all knots of) a polygon and
+// returns a new polygon. It is part of interface arithm.Transformable; the
+// result is a *GPPolygon.
+func (pg *GPPolygon) Transformed(t arithm.AT) arithm.Transformable {
+	return Transform(pg, t).(*GPPolygon)
+}
+
 // Union constructs the union of 2 polygons. Returns a new polygon.
 func Union(pg1 Polygon, pg2 Polygon) Polygon {
 	contour1 := getOrMakeContours(pg1)
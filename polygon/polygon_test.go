@@ -26,3 +26,13 @@ func TestBox(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestTransformed(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	var box arithm.Transformable = Box(arithm.P(0, 5), arithm.P(4, 1))
+	pg := box.Transformed(arithm.Translation(arithm.P(1, 1))).(*GPPolygon)
+	if pg.N() != 4 || !pg.IsCycle() || pg.Pt(0) != arithm.P(1, 6) {
+		t.Errorf("Expected box to be shifted by (1,1), is %s", AsString(pg))
+	}
+}
@@ -0,0 +1,161 @@
+package polyn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/emirpasic/gods/maps/treemap"
+	"github.com/npillmayer/arithm"
+)
+
+// === Bound Constraints =====================================================
+
+// bound is an interval lo ≤ x.i ≤ hi of admissible values for a variable.
+type bound struct {
+	lo, hi float64
+}
+
+// ErrBoundViolated is reported for equations which would solve a variable
+// to a value outside of its bounds.
+var ErrBoundViolated = errors.New("bound violated")
+
+// BoundError is returned for equations or bounds which would put a
+// variable out of its bounds. Var is the variable, Value its value and
+// Lower and Upper are its bounds. For a dependent variable, Value is the
+// value within reach of its dependency which is closest to its bounds.
+type BoundError struct {
+	Var          int     // the variable x.i
+	Value        float64 // the value x.i is solved to
+	Lower, Upper float64 // bounds of x.i, possibly infinite
+}
+
+func (e *BoundError) Error() string {
+	return fmt.Sprintf("%v: x.%d = %g is not within [%g,%g]", ErrBoundViolated,
+		e.Var, e.Value, e.Lower, e.Upper)
+}
+
+// Unwrap returns ErrBoundViolated, making the error usable with errors.Is.
+func (e *BoundError) Unwrap() error {
+	return ErrBoundViolated
+}
+
+// AtLeast constrains variable x.i to values x.i ≥ c. Bounds are checked
+// whenever a variable is solved, and whenever an equation is substituted
+// into the dependency of a variable: a dependency x.i = p is checked for
+// values within the bounds of x.i, given the bounds of the variables of p.
+// Equations solving a variable to a value out of its bounds, or leaving a
+// dependent variable no value within its bounds, are rejected with a
+// *BoundError, leaving the LEQ unchanged. If x.i is already solved to a value less than c, AtLeast
+// returns a *BoundError and the bound is not set.
+func (leq *LinEqSolver) AtLeast(i int, c float64) error {
+	b := leq.bound(i)
+	b.lo = math.Max(b.lo, c)
+	return leq.setBound(i, b)
+}
+
+// AtMost constrains variable x.i to values x.i ≤ c. See AtLeast.
+func (leq *LinEqSolver) AtMost(i int, c float64) error {
+	b := leq.bound(i)
+	b.hi = math.Min(b.hi, c)
+	return leq.setBound(i, b)
+}
+
+// Bounds returns the bounds of variable x.i. Unconstrained directions are
+// reported as infinite.
+func (leq *LinEqSolver) Bounds(i int) (lower, upper float64) {
+	b := leq.bound(i)
+	return b.lo, b.hi
+}
+
+// bound returns the bounds of x.i, which are infinite if not set.
+func (leq *LinEqSolver) bound(i int) bound {
+	if b, ok := leq.bounds[i]; ok {
+		return b
+	}
+	return bound{lo: math.Inf(-1), hi: math.Inf(1)}
+}
+
+// setBound checks a new bound against the value of a solved x.i, and the
+// bounds of x.i against each other.
+func (leq *LinEqSolver) setBound(i int, b bound) error {
+	if b.lo > b.hi {
+		return leq.boundError(i, math.NaN(), b)
+	}
+	if p, ok := leq.solved.Get(i); ok {
+		if c := p.(Polynomial).GetConstantValue(); !b.contains(c) {
+			return leq.boundError(i, c, b)
+		}
+	}
+	leq.bounds[i] = b
+	return nil
+}
+
+// checkBounds checks a set S of newly solved variables against their bounds.
+// Returns a *BoundError for the first variable out of bounds.
+func (leq *LinEqSolver) checkBounds(S *treemap.Map) error {
+	it := S.Iterator()
+	for it.Next() {
+		i, c := it.Key().(int), it.Value().(Polynomial).GetConstantValue()
+		if b, ok := leq.bounds[i]; ok && !b.contains(c) {
+			return leq.boundError(i, c, b)
+		}
+	}
+	return nil
+}
+
+// checkDependentBounds checks a set D of new dependencies x.i = p(i) against
+// the bounds of x.i. The range of values of p(i) is derived from the bounds
+// of its variables. Returns a *BoundError for the first dependent variable
+// which cannot be within its bounds.
+func (leq *LinEqSolver) checkDependentBounds(D *treemap.Map) error {
+	it := D.Iterator()
+	for it.Next() {
+		i := it.Key().(int)
+		b, ok := leq.bounds[i]
+		if !ok {
+			continue
+		}
+		r := leq.reach(it.Value().(Polynomial))
+		if r.hi-b.lo <= -arithm.Epsilon {
+			return leq.boundError(i, r.hi, b)
+		} else if r.lo-b.hi >= arithm.Epsilon {
+			return leq.boundError(i, r.lo, b)
+		}
+	}
+	return nil
+}
+
+// reach calculates the interval of values of p for all values of its
+// variables within their bounds. Unbounded variables result in infinite
+// ends of the interval.
+func (leq *LinEqSolver) reach(p Polynomial) bound {
+	c := p.GetConstantValue()
+	r := bound{lo: c, hi: c}
+	it := p.terms.Iterator()
+	for it.Next() {
+		i, a := it.Key(), it.Value()
+		if i == 0 || a == 0 {
+			continue
+		}
+		b := leq.bound(i)
+		if a > 0 {
+			r.lo, r.hi = r.lo+a*b.lo, r.hi+a*b.hi
+		} else {
+			r.lo, r.hi = r.lo+a*b.hi, r.hi+a*b.lo
+		}
+	}
+	return r
+}
+
+// contains is a predicate: is c within the bounds, up to rounding errors?
+func (b bound) contains(c float64) bool {
+	return b.lo-c < arithm.Epsilon && c-b.hi < arithm.Epsilon
+}
+
+// boundError creates an error for a variable out of bounds.
+func (leq *LinEqSolver) boundError(i int, c float64, b bound) error {
+	T().P("var", leq.VarString(i)).Errorf("%v: %s = %g is not within [%g,%g]",
+		ErrBoundViolated, leq.VarString(i), c, b.lo, b.hi)
+	return &BoundError{Var: i, Value: c, Lower: b.lo, Upper: b.hi}
+}
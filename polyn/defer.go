@@ -0,0 +1,108 @@
+package polyn
+
+import "github.com/npillmayer/arithm"
+
+// === Deferred Solving ======================================================
+
+// Defer switches the LEQ to deferred mode. Equations added in deferred mode
+// are queued instead of being solved immediately; AddEq and AddEqs return
+// handles for them, but no errors. Flush solves all queued equations in one
+// elimination pass and ends deferred mode.
+//
+// Deferred mode is intended for adding many equations at once, where
+// substituting every single equation into all dependencies would be
+// wasteful. Equations off by no more than the slack are not fitted in
+// deferred mode.
+func (leq *LinEqSolver) Defer() {
+	leq.deferred = true
+}
+
+// deferEq queues an equation and returns its handle.
+func (leq *LinEqSolver) deferEq(p Polynomial) EqHandle {
+	leq.lastHandle++
+	leq.queue = append(leq.queue, loggedEq{leq.lastHandle, p.CopyPolynomial().Zap()})
+	return leq.lastHandle
+}
+
+// Flush solves all equations queued in deferred mode and ends deferred mode.
+//
+// Queued equations are eliminated together, choosing pivots by Markowitz'
+// rule (see SparseLinEqSolver), and the resulting dependencies and solved
+// variables are merged into the LEQ. Flush is all-or-nothing: if one of the
+// queued equations contradicts the system or solves a variable out of its
+// bounds, Flush returns an error for it and the LEQ is left unchanged, with
+// none of the queued equations added. The equations remain queued and the
+// LEQ stays in deferred mode, thus clients may retract the offending
+// equation by its handle (see Retract) and call Flush again. Redundant
+// equations are reported to the redundancy handler.
+func (leq *LinEqSolver) Flush() error {
+	queue := leq.queue
+	if len(queue) == 0 {
+		leq.deferred = false
+		return nil
+	}
+	T().P("op", "flush").Infof("solving %d deferred equations", len(queue))
+	rows := make([]activeRow, 0, len(queue))
+	for _, eq := range queue { // rows will contain free variables only
+		p := leq.substituteSolved(0, eq.p.CopyPolynomial(), leq.solved)
+		p = leq.substituteDependents(p)
+		rows = append(rows, activeRow{row: p.terms, eq: eq.p})
+	}
+	var redundant []Polynomial
+	var pivots []pivotRow
+	for {
+		k := 0 // drop constant rows
+		for _, r := range rows {
+			if c, off := (Polynomial{terms: r.row}).isOff(); !off {
+				rows[k] = r
+				k++
+			} else if arithm.Is0(c) {
+				redundant = append(redundant, r.eq)
+			} else {
+				return leq.equationError(ErrInconsistent, r.eq, c)
+			}
+		}
+		if rows = rows[:k]; len(rows) == 0 {
+			break
+		}
+		k, col := markowitzPivot(rows)
+		pr := pivotRow{col: col, row: rows[k].row}
+		rows = append(rows[:k], rows[k+1:]...)
+		for j := range rows {
+			rows[j].row = eliminate(rows[j].row, pr)
+		}
+		pivots = append(pivots, pr)
+	}
+	for k := len(pivots) - 1; k > 0; k-- { // reduce pivot rows to free variables
+		for j := 0; j < k; j++ {
+			pivots[j].row = eliminate(pivots[j].row, pivots[k])
+		}
+	}
+	D := copyMap(leq.dependents) // set up D' of dependents
+	it := D.Iterator()
+	for it.Next() { // substitute new dependents in every x.j=q(j)
+		q := it.Value().(Polynomial).CopyPolynomial()
+		for _, pr := range pivots {
+			if termContains(q, pr.col) {
+				q = Polynomial{terms: eliminate(q.terms, pr)}
+			}
+		}
+		D.Put(it.Key(), q)
+	}
+	for _, pr := range pivots { // 0 = a.col x.col + p  ⇒  x.col = -1/a p
+		D.Put(pr.col, leq.activateEquationTowards(pr.col, Polynomial{terms: pr.row.copy()}))
+	}
+	if err := leq.updateSolved(D); err != nil {
+		return err
+	}
+	leq.deferred, leq.queue = false, nil
+	leq.equations = append(leq.equations, queue...)
+	for _, p := range redundant {
+		leq.redundant(p)
+	}
+	leq.harvestCapsules()
+	if leq.showdependencies {
+		leq.Dump(leq.varresolver)
+	}
+	return nil
+}
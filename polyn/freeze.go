@@ -0,0 +1,50 @@
+package polyn
+
+// === Concurrency ===========================================================
+
+// LEQView is an immutable, read-only view of a LEQ, created by Freeze.
+// In contrast to LinEqSolver, a LEQView is safe for concurrent use by
+// multiple goroutines.
+type LEQView struct {
+	dependents map[int]Polynomial // dependent variable x.i = p(i)
+	solved     map[int]float64    // map x.i => numeric
+}
+
+// Freeze returns a read-only view of the current state of the LEQ.
+//
+// A LinEqSolver is not safe for concurrent use: adding equations alters its
+// dependencies and solutions in place. Clients which want to query a LEQ
+// from several goroutines, e.g. while continuing to add equations, should
+// freeze the LEQ and hand out the view. The view does not reflect later
+// changes to the LEQ.
+func (leq *LinEqSolver) Freeze() *LEQView {
+	v := &LEQView{
+		dependents: make(map[int]Polynomial, leq.dependents.Size()),
+		solved:     make(map[int]float64, leq.solved.Size()),
+	}
+	it := leq.dependents.Iterator()
+	for it.Next() {
+		v.dependents[it.Key().(int)] = it.Value().(Polynomial).CopyPolynomial()
+	}
+	it = leq.solved.Iterator()
+	for it.Next() {
+		v.solved[it.Key().(int)] = it.Value().(Polynomial).GetConstantValue()
+	}
+	return v
+}
+
+// Value returns the value of x.i, if x.i is solved.
+func (v *LEQView) Value(i int) (float64, bool) {
+	c, ok := v.solved[i]
+	return c, ok
+}
+
+// Dependency returns the dependency x.i = p(i), if x.i is a dependent
+// variable. The polynomial returned is a copy and may be altered by clients.
+func (v *LEQView) Dependency(i int) (Polynomial, bool) {
+	p, ok := v.dependents[i]
+	if !ok {
+		return Polynomial{}, false
+	}
+	return p.CopyPolynomial(), true
+}
@@ -1,7 +1,9 @@
 package polyn
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/npillmayer/arithm"
 
@@ -64,19 +66,62 @@ type VariableResolver interface {
 	IsCapsule(int) bool             // x.i has gone out of scope
 }
 
+// === Errors ================================================================
+
+// ErrInconsistent is reported for equations which contradict the equations
+// already known to a LEQ, i.e. which reduce to 0 = c with c ≠ 0.
+var ErrInconsistent = errors.New("inconsistent equation")
+
+// EquationError is returned by AddEq and AddEqs for equations which cannot be
+// added to a LEQ. Err is ErrInconsistent, Eq is the offending equation
+// 0 = Eq as given by the client. Redundant equations, i.e. equations which
+// reduce to 0 = 0, are not errors (see SetRedundancyHandler).
+type EquationError struct {
+	Err error      // ErrInconsistent
+	Eq  Polynomial // the offending equation 0 = Eq
+	Off float64    // the equation is off by this value
+}
+
+func (e *EquationError) Error() string {
+	return fmt.Sprintf("%v: 0 = %s is off by %g", e.Err, e.Eq, e.Off)
+}
+
+// Unwrap returns ErrInconsistent, making the error usable with errors.Is.
+func (e *EquationError) Unwrap() error {
+	return e.Err
+}
+
 // === System of linear equations =======================================
 
 // LinEqSolver is a container for linear equations. Used to incrementally solve
 // systems of linear equations.
 //
+// A LinEqSolver is not safe for concurrent use. Use Freeze to create a
+// read-only view for concurrent readers.
+//
 // Inspired by Donald E. Knuth's MetaFont, John Hobby's MetaPost and by
 // a Lua project by John D. Ramsdell: http://luaforge.net/projects/lineqpp/
 //
 type LinEqSolver struct {
-	dependents       *treemap.Map     // dependent variable at position i has dependencies[i]
-	solved           *treemap.Map     // map x.i => numeric
-	varresolver      VariableResolver // to resolve variable names from term positions
-	showdependencies bool             // continuously show dependent variables
+	dependents       *treemap.Map            // dependent variable at position i has dependencies[i]
+	solved           *treemap.Map            // map x.i => numeric
+	varresolver      VariableResolver        // to resolve variable names from term positions
+	showdependencies bool                    // continuously show dependent variables
+	capsules         map[int]bool            // anonymous variables and variables of closed groups
+	nextWhatever     int                     // next position for an anonymous variable
+	groups           [][]int                 // saved variables of open groups
+	equations        []loggedEq              // equations added by clients, for retraction
+	slack            float64                 // max. inconsistency fitted by least squares
+	lastHandle       EqHandle                // handle of the most recently added equation
+	txns             []*Txn                  // open transactions, innermost last
+	pending          []notification          // notifications held back by transactions
+	onredundant      func(Polynomial)        // called for redundant equations
+	bounds           map[int]bound           // bound constraints of variables
+	deferred         bool                    // queue new equations, see Defer
+	queue            []loggedEq              // equations queued in deferred mode
+	onsolved         []func(int, float64)    // listeners for solved variables
+	ondependency     []func(int, Polynomial) // listeners for changed dependencies
+	muted            bool                    // drop notifications while rebuilding
 }
 
 // CreateLinEqSolver creates a new sytem of linear equations.
@@ -85,6 +130,9 @@ func CreateLinEqSolver() *LinEqSolver {
 		dependents:       treemap.NewWithIntComparator(), // sorted map
 		solved:           treemap.NewWithIntComparator(), // sorted map
 		showdependencies: false,
+		capsules:         make(map[int]bool),
+		nextWhatever:     WhateverBase,
+		bounds:           make(map[int]bound),
 	}
 	return &leq
 }
@@ -97,6 +145,23 @@ func (leq *LinEqSolver) SetVariableResolver(resolver VariableResolver) {
 	leq.varresolver = resolver
 }
 
+// SetRedundancyHandler sets a function to be called for every equation which
+// is implied by the equations already known to the LEQ, i.e. which reduces
+// to 0 = 0. Redundant equations are accepted but do not change the LEQ.
+// The handler receives the equation as given by the client. Clients may use
+// it to warn about duplicate constraints. A nil handler removes it.
+func (leq *LinEqSolver) SetRedundancyHandler(h func(eq Polynomial)) {
+	leq.onredundant = h
+}
+
+// redundant reports a redundant equation to the redundancy handler, if any.
+func (leq *LinEqSolver) redundant(p Polynomial) {
+	T().P("op", "new equation").Infof("redundant equation: 0 = %s", leq.PolynString(p))
+	if leq.onredundant != nil {
+		leq.onredundant(p)
+	}
+}
+
 // Collect all currently solved variables from a system of linear equations.
 // Solved variables are returned as a map: i(var) -> numeric, where i(var) is an
 // integer representing the position of variable var.
@@ -109,88 +174,223 @@ func (leq *LinEqSolver) getSolvedVars() maps.Map {
 	return setOfSolved
 }
 
+// Unsolved returns the variables known to the LEQ which are not solved,
+// i.e. dependent variables and the free variables they depend on, in
+// ascending order. Anonymous whatever variables are not included.
+func (leq *LinEqSolver) Unsolved() []int {
+	vars := leq.freeVariables()
+	it := leq.dependents.Iterator()
+	for it.Next() {
+		vars[it.Key().(int)] = true
+	}
+	unsolved := make([]int, 0, len(vars))
+	for i := range vars {
+		if i < WhateverBase {
+			unsolved = append(unsolved, i)
+		}
+	}
+	sort.Ints(unsolved)
+	return unsolved
+}
+
+// DegreesOfFreedom returns the number of independent equations still needed
+// to solve all dependent variables. This is the number of free variables
+// the dependent variables depend on, including whatever variables.
+// Equations queued in deferred mode are not considered.
+func (leq *LinEqSolver) DegreesOfFreedom() int {
+	return len(leq.freeVariables())
+}
+
+// freeVariables collects the variables occuring on the RHS of dependencies.
+func (leq *LinEqSolver) freeVariables() map[int]bool {
+	free := make(map[int]bool)
+	it := leq.dependents.Iterator()
+	for it.Next() {
+		for _, i := range it.Value().(Polynomial).terms.Keys() {
+			if i != 0 {
+				free[i] = true
+			}
+		}
+	}
+	return free
+}
+
 // AddEq adds a
 // new equation 0 = p (p is Polynomial) to a system of linear equations.
 // Immediately starts to solve the -- possibly incomplete -- system, as
-// far as possible.
-func (leq *LinEqSolver) AddEq(p Polynomial) *LinEqSolver {
-	leq.addEq(p, false)
+// far as possible. AddEq returns a handle for the equation, which may be
+// used to retract it later.
+//
+// If the equation contradicts the system, AddEq returns an *EquationError
+// wrapping ErrInconsistent, and the system is left unchanged. The same holds
+// for equations which solve a variable out of its bounds (see AtLeast),
+// with a *BoundError being returned.
+//
+// If a slack has been set (see SetSlack), equations which are off by no
+// more than the slack are accepted and fitted in a least-squares sense.
+//
+// Equations implied by the system are accepted and reported to the
+// redundancy handler (see SetRedundancyHandler).
+func (leq *LinEqSolver) AddEq(p Polynomial) (EqHandle, error) {
+	if leq.deferred {
+		return leq.deferEq(p), nil
+	}
+	redundant, err := leq.addEq(p, false)
+	var h EqHandle
+	if leq.withinSlack(err) {
+		h, err = leq.addFitted(p, false)
+	} else if err == nil {
+		h = leq.logEq(p)
+		if redundant {
+			leq.redundant(p)
+		}
+	}
 	if leq.showdependencies {
 		leq.Dump(leq.varresolver)
 	}
-	return leq
+	return h, err
 }
 
 // AddEqs adds a set of linear equations to the LEQ system.
-// See AddEq.
-func (leq *LinEqSolver) AddEqs(plist []Polynomial) *LinEqSolver {
+// See AddEq. Adding equations stops at the first equation which cannot be
+// added, returning its error. Handles are returned for all equations which
+// have been added.
+func (leq *LinEqSolver) AddEqs(plist []Polynomial) ([]EqHandle, error) {
+	var err error
+	var handles []EqHandle
 	l := len(plist)
 	if l == 0 {
 		T().Errorf("given empty list of equations")
+	} else if leq.deferred {
+		for _, p := range plist {
+			handles = append(handles, leq.deferEq(p))
+		}
+		return handles, nil
 	} else {
 		for i, p := range plist {
 			T().Debugf("adding equation %d/%d: 0 = %s", i+1, l, p)
-			leq.addEq(p, i+1 < l)
+			var h EqHandle
+			var redundant bool
+			if redundant, err = leq.addEq(p, i+1 < l); leq.withinSlack(err) {
+				h, err = leq.addFitted(p, i+1 < l)
+			} else if err == nil {
+				h = leq.logEq(p)
+				if redundant {
+					leq.redundant(p)
+				}
+			}
+			if err != nil {
+				leq.harvestCapsules()
+				break
+			}
+			handles = append(handles, h)
 		}
 	}
 	if leq.showdependencies {
 		leq.Dump(leq.varresolver)
 	}
-	return leq
+	return handles, err
 }
 
 // If parameter cont is true, expect another equation immediately after this
 // one. This is necessary to suppress harvesting of capsules.
-func (leq *LinEqSolver) addEq(p Polynomial, cont bool) *LinEqSolver {
-	p = p.Zap()
+// Returns true if the equation is implied by the LEQ, i.e. reduces to 0 = 0.
+// Redundant equations leave the LEQ unchanged.
+func (leq *LinEqSolver) addEq(p Polynomial, cont bool) (bool, error) {
+	var redundant bool
+	orig := p.CopyPolynomial().Zap()
+	p = orig.CopyPolynomial() // do not alter the client's polynomial
 	T().P("op", "new equation").Infof("0 = %s", leq.PolynString(p))
 	// substitute solved in new equation
 	p = leq.substituteSolved(0, p, leq.solved)
-	if _, off := p.isOff(); !off { //  :-))  no pun intended
+	if c, off := p.isOff(); off { //  :-))  no pun intended
+		if !arithm.Is0(c) {
+			return false, leq.equationError(ErrInconsistent, orig, c)
+		}
+		redundant = true
+	} else if leq.implied(p) {
+		redundant = true
+	} else {
 		// select x.i=p(i)
-		i, _ := p.maxCoeff(leq.dependents)    // start with max (free) coefficient of p
+		i, _ := p.maxCoeff(leq.dependents) // start with max (free) coefficient of p
 		p = leq.activateEquationTowards(i, p) // now  x.i = -1/a * p(...).
 		// Phase 1: substitute P(i) in every x.j=P(j)
-		D := leq.updateDependentVariables(i, p)
-		// done, now split solved x from D' off to S'
-		S := treemap.NewWithIntComparator() // set up S' of solved
-		itD := D.Iterator()
-		for itD.Next() { // for every x.i=p(i) in D'
-			i, p = itD.Key().(int), itD.Value().(Polynomial)
-			if ok, rhs := solved(p); ok {
-				S.Put(i, rhs) // add x.i to S'
-				D.Remove(i)   // remove x.i from D'
-			}
+		D, off := leq.updateDependentVariables(i, p)
+		if !arithm.Is0(off) {
+			return false, leq.equationError(ErrInconsistent, orig, off)
 		}
-		// substitute solved: subst s in S' into d in D'
-		//T.Info("---------- subst solved -----------")
-		itD = D.Iterator()
-		for itD.Next() { // for every x.i=p(i) in D'
-			i, p = itD.Key().(int), itD.Value().(Polynomial)
-			p = leq.substituteSolved(i, p, S)
-			if ok, rhs := solved(p); ok {
-				S.Put(i, rhs) // add x.i to S'
-				D.Remove(i)   // remove x.i from D'
-			}
+		if err := leq.updateSolved(D); err != nil {
+			return false, err
 		}
-		//T.Info("-----------------------------------")
-		// done, update sets S and D
-		S.Each(func(key interface{}, value interface{}) { // S = S + S'
-			leq.setSolved(key.(int), value.(Polynomial))
-		})
-		leq.dependents = D // D = D'
 	}
 	if !cont { // if this equation is not part of an equation-pair
 		leq.harvestCapsules()
 	}
-	return leq
+	return redundant, nil
+}
+
+// updateSolved splits the solved variables off a new set D' of dependent
+// variables and substitutes them into the remaining dependencies. If all
+// solved variables are within their bounds, and all dependencies can be, the
+// LEQ is updated with the results. Otherwise a *BoundError is returned and
+// the LEQ is unchanged.
+func (leq *LinEqSolver) updateSolved(D *treemap.Map) error {
+	// done, now split solved x from D' off to S'
+	S := treemap.NewWithIntComparator() // set up S' of solved
+	itD := D.Iterator()
+	for itD.Next() { // for every x.i=p(i) in D'
+		i, p := itD.Key().(int), itD.Value().(Polynomial)
+		if ok, rhs := solved(p); ok {
+			S.Put(i, rhs) // add x.i to S'
+			D.Remove(i)   // remove x.i from D'
+		}
+	}
+	// substitute solved: subst s in S' into d in D'
+	//T.Info("---------- subst solved -----------")
+	itD = D.Iterator()
+	for itD.Next() { // for every x.i=p(i) in D'
+		i, p := itD.Key().(int), itD.Value().(Polynomial)
+		p = leq.substituteSolved(i, p, S)
+		if ok, rhs := solved(p); ok {
+			S.Put(i, rhs) // add x.i to S'
+			D.Remove(i)   // remove x.i from D'
+		}
+	}
+	//T.Info("-----------------------------------")
+	if err := leq.checkBounds(S); err != nil {
+		return err
+	}
+	if err := leq.checkDependentBounds(D); err != nil {
+		return err
+	}
+	// done, update sets S and D
+	S.Each(func(key interface{}, value interface{}) { // S = S + S'
+		leq.setSolved(key.(int), value.(Polynomial))
+	})
+	itD = D.Iterator()
+	for itD.Next() { // report changed dependencies
+		i, p := itD.Key().(int), itD.Value().(Polynomial)
+		if q, found := leq.dependents.Get(i); !found || !sameTerms(p, q.(Polynomial)) {
+			leq.notify(notification{i: i, p: p})
+		}
+	}
+	leq.dependents = D // D = D'
+	return nil
+}
+
+// equationError creates an error for an equation which cannot be added.
+func (leq *LinEqSolver) equationError(err error, p Polynomial, off float64) error {
+	T().P("op", "new equation").Errorf("%v: 0 = %s", err, leq.PolynString(p))
+	return &EquationError{Err: err, Eq: p, Off: off}
 }
 
 // 1st pass of the LEQ algorithm: with a new equation x.i=P(i) walk
 // through all dependent variables x.j=P(j) and substitute P(i) for x.i
 // in every RHS.
-// Return a new set D' of dependent variables.
-func (leq *LinEqSolver) updateDependentVariables(i int, p Polynomial) *treemap.Map {
+// Return a new set D' of dependent variables. If substitution results in
+// an equation 0 = c with c ≠ 0, the new equation is inconsistent with the
+// dependencies and c is returned as the second result.
+func (leq *LinEqSolver) updateDependentVariables(i int, p Polynomial) (*treemap.Map, float64) {
 	D := treemap.NewWithIntComparator() // set up D' of dependents
 	leq.updateDependency(i, p, D)
 	// D -> D'
@@ -201,7 +401,8 @@ func (leq *LinEqSolver) updateDependentVariables(i int, p Polynomial) *treemap.M
 		i = savei // restore i
 		tmp, _ := D.Get(i)
 		p = tmp.(Polynomial).CopyPolynomial() // get current version of p(i)
-		j, q := it.Key().(int), it.Value().(Polynomial)
+		// copy q(j), as dependents must stay intact if the new equation is off
+		j, q := it.Key().(int), it.Value().(Polynomial).CopyPolynomial()
 		T().P("op", "substitute").Debugf("(1) p(%s) in %s = %s",
 			leq.VarString(i), leq.VarString(j), leq.PolynString(q))
 		if j == i { // x.j = x.i, i.e. equations with identical LHS
@@ -225,9 +426,12 @@ func (leq *LinEqSolver) updateDependentVariables(i int, p Polynomial) *treemap.M
 			j, q = subst(i, p, j, q) // substitute new equation in x.j=q(j)
 			T().P("op", "substitute").Debugf("result: %s = %s", leq.VarString(j), leq.PolynString(q))
 			if j != 0 {
-				leq.updateDependency(j, q, D) // insert substitution result
+				D.Put(j, q) // replace by substitution result
 			} else { // j has been eliminated from q
-				if _, off := q.isOff(); !off {
+				if c, off := q.isOff(); off && !arithm.Is0(c) {
+					T().Debugf("-----------------------------------")
+					return D, c
+				} else if !off {
 					k, _ := q.maxCoeff(D) // find max (free) coefficient of q(k)
 					q = leq.activateEquationTowards(k, q)
 					leq.updateDependency(k, q, D) // insert new equation
@@ -236,7 +440,7 @@ func (leq *LinEqSolver) updateDependentVariables(i int, p Polynomial) *treemap.M
 		}
 	}
 	T().Debugf("-----------------------------------")
-	return D
+	return D, 0
 }
 
 // Check if a polynomial is constant, i.e. solves an equation.
@@ -285,19 +489,19 @@ func (leq *LinEqSolver) updateDependency(i int, p Polynomial, m *treemap.Map) {
 func subst(i int, p Polynomial, j int, q Polynomial) (int, Polynomial) {
 	ai := q.GetCoeffForTerm(i) // a.i in q
 	if !arithm.Is0(ai) {       // if variable x.i exists in q
-		q.Terms.Remove(i)                               // remove a.i*x.i in q (to be replaced)
+		q.terms.Remove(i)                               // remove a.i*x.i in q (to be replaced)
 		p = p.Multiply(NewConstantPolynomial(ai), true) // scale p(i) by a.i of q
 		q = q.Add(p, false).Zap()                       // now insert p(i) into q(j)
 		aj := q.GetCoeffForTerm(j)                      // results in a.j*x.j in q(j) ?
 		if arithm.Is0(aj) {                             // no => we're done
 			// do nothing
 		} else if arithm.Is1(aj) { // x.j = c + x.j + ...  => eliminate x.j and activate for free x.k
-			q.Terms.Remove(j) // remove x.j from RHS q
+			q.terms.Remove(j) // remove x.j from RHS q
 			j = 0             // set LHS to 'impossible' variable x.0
 		} else { // x.j = c + a.j*x.j + ...  => scale RHS by -1(a.j-1)
 			a := -1.0 / (aj - 1.0)         // a = -1/(a.j-1)
 			c := NewConstantPolynomial(a)  //
-			q.Terms.Remove(j)              // now remove a.j*x.j from RHS q
+			q.terms.Remove(j)              // now remove a.j*x.j from RHS q
 			q = q.Multiply(c, false).Zap() // and multiply RHS by -1/(a.j-1)
 		}
 	}
@@ -307,7 +511,38 @@ func subst(i int, p Polynomial, j int, q Polynomial) (int, Polynomial) {
 
 // Helper: number of variables in RHS of an equation.
 func termlength(p Polynomial) int {
-	return p.Terms.Size()
+	return p.terms.Size()
+}
+
+// In a new equation, substitute all dependent variables by their
+// dependencies. Dependencies may refer to other dependent variables, thus
+// substitution is repeated, at most once per dependent variable. Afterwards
+// the equation usually contains free variables only, and reduces to 0 = c
+// if it is implied by or inconsistent with the LEQ.
+func (leq *LinEqSolver) substituteDependents(p Polynomial) Polynomial {
+	for pass := 0; pass <= leq.dependents.Size(); pass++ {
+		found := false
+		it := leq.dependents.Iterator()
+		for it.Next() { // iterate over all dependent x.j = q(j)
+			j, q := it.Key().(int), it.Value().(Polynomial)
+			if termContains(p, j) {
+				p, found = p.substitute(j, q), true
+				T().P("op", "subst-dep").Debugf("%s = %s  =>  RHS = %s",
+					leq.VarString(j), leq.PolynString(q), leq.PolynString(p))
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return p
+}
+
+// implied checks if an equation is implied by the LEQ, i.e. reduces to 0 = 0
+// when substituting dependent variables. The equation is not changed.
+func (leq *LinEqSolver) implied(p Polynomial) bool {
+	c, off := leq.substituteDependents(p.CopyPolynomial()).isOff()
+	return off && arithm.Is0(c)
 }
 
 // In an equation, substitute all variables which are already known.
@@ -323,7 +558,7 @@ func (leq *LinEqSolver) substituteSolved(j int, p Polynomial, solved *treemap.Ma
 			coeff = coeff * c
 			pc := p.GetConstantValue()
 			p.SetTerm(0, pc+coeff)
-			p.Terms.Remove(i)
+			p.terms.Remove(i)
 			T().P("op", "subst-solved").Debugf("%s = %g  =>  RHS = %s",
 				leq.VarString(i), c, leq.PolynString(p))
 			if j > 0 {
@@ -343,7 +578,7 @@ func (leq *LinEqSolver) substituteSolved(j int, p Polynomial, solved *treemap.Ma
 //
 func (leq *LinEqSolver) activateEquationTowards(i int, p Polynomial) Polynomial {
 	coeff := p.GetCoeffForTerm(i)
-	p.Terms.Remove(i) // remove term x.i from RHS(p)
+	p.terms.Remove(i) // remove term x.i from RHS(p)
 	pp := NewConstantPolynomial(-1.0 / coeff)
 	p = p.Multiply(pp, true).Zap()
 	//T.P("op", "activate").Infof("## %s = %s", leq.VarString(i), leq.PolynString(p))
@@ -358,14 +593,15 @@ func (leq *LinEqSolver) setSolved(i int, p Polynomial) {
 	varname := leq.VarString(i)
 	T().P("var", varname).Infof("#### %s = %g", varname, c)
 	leq.solved.Put(i, p) // move x.i to set of solved variables
-	if leq.varresolver != nil {
-		leq.varresolver.SetVariableSolved(i, c) // notify variable solver
-	}
+	leq.notify(notification{i: i, c: c})
 }
 
 // VarString returns a readable variable name for an internal variable.
 // Uses a VariableResolver, if present.
 func (leq *LinEqSolver) VarString(i int) string {
+	if i >= WhateverBase && leq.capsules[i] {
+		return fmt.Sprintf("whatever.%d", i-WhateverBase)
+	}
 	if leq.varresolver == nil {
 		return fmt.Sprintf("x.%d", i)
 	}
@@ -375,11 +611,22 @@ func (leq *LinEqSolver) VarString(i int) string {
 // PolynString outputs a polynomial as string. Uses VariableResolver, if present.
 func (leq *LinEqSolver) PolynString(p Polynomial) string {
 	if leq.varresolver != nil {
-		return p.TraceString(leq.varresolver)
+		return p.TraceString(whateverResolver{leq.varresolver, leq})
 	}
 	return p.String()
 }
 
+// whateverResolver resolves names of anonymous variables, delegating all
+// other variables to the client's resolver.
+type whateverResolver struct {
+	VariableResolver
+	leq *LinEqSolver
+}
+
+func (r whateverResolver) GetVariableName(i int) string {
+	return r.leq.VarString(i)
+}
+
 // === Capsules ==============================================================
 
 /* 'Capsule' is a MetaFont terminus for variables in the LEQ, which have
@@ -395,6 +642,67 @@ func (leq *LinEqSolver) PolynString(p Polynomial) string {
  * equations for z0 (the above command produces 2 equations).
  */
 
+// WhateverBase is the position of the first anonymous variable created by
+// Whatever. Clients must not use positions ≥ WhateverBase for their own
+// variables.
+const WhateverBase = 1 << 30
+
+// Whatever creates a fresh anonymous variable and returns its position
+// (MetaFont: "whatever"). An anonymous variable is a capsule, i.e., it will be
+// removed from the LEQ as soon as it is no longer relevant. This allows for
+// the classic idiom
+//
+//	z = whatever[z1,z2]
+//
+// (z is somewhere on the straight line through z1 and z2) without the
+// client having to track the variable. Anonymous variables are not reported
+// to the VariableResolver.
+func (leq *LinEqSolver) Whatever() int {
+	i := leq.nextWhatever
+	leq.nextWhatever++
+	leq.capsules[i] = true
+	return i
+}
+
+// ErrNoGroup is returned when ending a group or saving a variable without
+// an open group.
+var ErrNoGroup = errors.New("no open group")
+
+// BeginGroup opens a group (MetaFont: "begingroup"). Groups may be nested.
+// Variables saved within a group become capsules at the end of the group.
+func (leq *LinEqSolver) BeginGroup() {
+	leq.groups = append(leq.groups, nil)
+}
+
+// Save declares variable x.i as local to the innermost open group
+// (MetaFont: "save"). Clients are expected to use a fresh variable position
+// for a saved variable, and to restore the name of the variable to the
+// previous position at the end of the group.
+func (leq *LinEqSolver) Save(i int) error {
+	if len(leq.groups) == 0 {
+		return ErrNoGroup
+	}
+	top := len(leq.groups) - 1
+	leq.groups[top] = append(leq.groups[top], i)
+	return nil
+}
+
+// EndGroup closes the innermost open group (MetaFont: "endgroup"). Variables
+// saved within the group become capsules and are removed from the LEQ, as
+// far as they are no longer relevant for solving it.
+func (leq *LinEqSolver) EndGroup() error {
+	if len(leq.groups) == 0 {
+		return ErrNoGroup
+	}
+	top := len(leq.groups) - 1
+	for _, i := range leq.groups[top] {
+		leq.capsules[i] = true
+	}
+	leq.groups = leq.groups[:top]
+	leq.harvestCapsules()
+	return nil
+}
+
 // Remove all equations which are dependent on a capsule, but only if the
 // capsule is a loner. If a capsule occurs in at least 2 equations, it
 // is still relevant for solving the LEQ.
@@ -405,10 +713,10 @@ func (leq *LinEqSolver) harvestCapsules() {
 		w := it.Key().(int)
 		pw := it.Value().(Polynomial)
 		leq.checkAndCountCapsule(w, counts) // check LHS variable
-		pit := pw.Terms.Iterator()          // for all terms in polynomial
+		pit := pw.terms.Iterator()          // for all terms in polynomial
 		for pit.Next() {
-			i := pit.Key().(int) // get every term.i
-			if i > 0 {           // omit constant term
+			i := pit.Key() // get every term.i
+			if i > 0 {     // omit constant term
 				leq.checkAndCountCapsule(i, counts)
 			}
 		}
@@ -428,7 +736,7 @@ func (leq *LinEqSolver) harvestCapsules() {
 
 // Helper for counting capsule references. Updates the count for a capsule.
 func (leq *LinEqSolver) checkAndCountCapsule(i int, counts map[int]int) {
-	if leq.varresolver != nil && leq.varresolver.IsCapsule(i) {
+	if leq.capsules[i] || (leq.varresolver != nil && leq.varresolver.IsCapsule(i)) {
 		counts[i]++
 		//T.P("capsule", i).Debugf("capsule counted, #=%d", counts[i])
 	}
@@ -1,8 +1,12 @@
 package polyn
 
 import (
+	"errors"
+	"math/big"
+	"sync"
 	"testing"
 
+	"github.com/npillmayer/arithm"
 	"github.com/npillmayer/schuko/tracing/gotestingadapter"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,13 +32,18 @@ func (r res) IsCapsule(int) bool { // x.i has gone out of scope
 	return false // no capsules
 }
 
+// errOf returns the error of a call to add equations, ignoring the handles.
+func errOf(_ interface{}, err error) error {
+	return err
+}
+
 // --- Tests -----------------------------------------------------------------
 
 func TestPolynSimple1(t *testing.T) {
 	teardown := gotestingadapter.RedirectTracing(t)
 	defer teardown()
 	p := NewConstantPolynomial(1.0)
-	if p.Terms.Size() != 1 {
+	if p.TermCount() != 1 {
 		t.Fail()
 	}
 }
@@ -44,7 +53,7 @@ func TestPolynSimple2(t *testing.T) {
 	defer teardown()
 	p := NewConstantPolynomial(0.5)
 	p.SetTerm(1, 3)
-	if p.Terms.Size() != 2 {
+	if p.TermCount() != 2 {
 		t.Fail()
 	}
 }
@@ -285,8 +294,14 @@ func TestLEQ3(t *testing.T) {
 	p1, _ := New(100, X{1, -1}) // a = 100
 	p2, _ := New(99, X{1, -2})  // 2a = 99
 	leq.AddEq(p1)
-	//leq.AddEq(p2)
-	assert.Panics(t, func() { leq.AddEq(p2) }, "equation should be off by -101")
+	_, err := leq.AddEq(p2)
+	assert.True(t, errors.Is(err, ErrInconsistent), "equation should be off by -101")
+	var eqerr *EquationError
+	if assert.True(t, errors.As(err, &eqerr)) {
+		assert.Equal(t, -101.0, eqerr.Off)
+		assert.Equal(t, -2.0, eqerr.Eq.GetCoeffForTerm(1))
+	}
+	assert.Equal(t, 100.0, r[1], "a should remain solved")
 }
 
 func TestLEQ4(t *testing.T) {
@@ -333,6 +348,17 @@ func TestLEQ5(t *testing.T) {
 	}
 }
 
+func TestSubstitutionReplacesDependency(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	p1, _ := New(10, X{1, -1}, X{2, -1}, X{3, -1}) // a+b+c=10
+	p2, _ := New(0, X{4, -1}, X{2, 1})             // d=b
+	leq.AddEqs([]Polynomial{p1, p2})
+	a, _ := leq.dependents.Get(1) // a=10-c-d
+	assert.False(t, termContains(a.(Polynomial), 2), "a should no longer depend on b, is %s", a)
+}
+
 // Example for solving linear equations. We use a variable resolver, which
 // maps a numeric value of 0..<n> to lowercase letters 'a'..'z'.
 func TestExampleLinEqSolver_usage(t *testing.T) {
@@ -347,3 +373,539 @@ func TestExampleLinEqSolver_usage(t *testing.T) {
 	q, _ := New(2, X{1, 3}, X{2, -1})
 	leq.AddEq(q)
 }
+
+func TestLEQInconsistentDependency(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(20, X{1, -1}, X{2, -1}) // a+b=20
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	_, err := leq.AddEq(p2)
+	assert.True(t, errors.Is(err, ErrInconsistent), "a+b=20 should contradict a+b=10")
+	p3, _ := New(4, X{1, -1}) // a=4
+	assert.NoError(t, errOf(leq.AddEq(p3)))
+	assert.Equal(t, 6.0, r[2], "b should be solved from a+b=10")
+}
+
+func TestPairEquation(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	// z1 = (a,b), z2 = (c,d); z1 = z2 + (3,4) and z2 = (1,1)
+	pp := NewConstantPairPolynomial(arithm.P(3, 4)).SetPairTerm(3, 4, 1).SetPairTerm(1, 2, -1)
+	assert.NoError(t, errOf(leq.AddPairEq(pp)))
+	z2 := NewConstantPairPolynomial(arithm.P(1, 1)).SetPairTerm(3, 4, -1)
+	assert.NoError(t, errOf(leq.AddPairEq(z2)))
+	assert.Equal(t, 4.0, r[1], "xpart z1")
+	assert.Equal(t, 5.0, r[2], "ypart z1")
+	// z3 = (e,f) = (7,9) and f = 3e, the y part contradicts
+	bad := NewConstantPairPolynomial(arithm.P(7, 9)).SetPairTerm(5, 6, -1)
+	f, _ := New(0, X{5, 3}, X{6, -1})
+	assert.NoError(t, errOf(leq.AddEq(f)))
+	handles, err := leq.AddPairEq(bad)
+	assert.True(t, errors.Is(err, ErrInconsistent), "ypart z3 should contradict f=3e")
+	assert.Nil(t, handles)
+	_, found := r[5]
+	assert.False(t, found, "xpart z3 should have been rolled back")
+	_, found = leq.solved.Get(5)
+	assert.False(t, found, "xpart z3 should not be solved")
+	assert.Equal(t, 0, len(leq.txns), "transaction should have been closed")
+	diff := pp.Subtract(pp.Scaled(2))
+	assert.Equal(t, -3.0, diff.X.GetConstantValue())
+	assert.Equal(t, 1.0, diff.Y.GetCoeffForTerm(2))
+}
+
+func TestWhatever(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	// z = (a,b) = whatever[(0,0),(2,2)]
+	w := leq.Whatever()
+	pp := NewConstantPairPolynomial(arithm.Origin).SetScalarTerm(w, arithm.P(2, 2)).SetPairTerm(1, 2, -1)
+	assert.NoError(t, errOf(leq.AddPairEq(pp)))
+	assert.Equal(t, 0, leq.solved.Size(), "nothing should be solved yet")
+	a, _ := New(1, X{1, -1}) // a = 1
+	assert.NoError(t, errOf(leq.AddEq(a)))
+	assert.Equal(t, 1.0, r[2], "b should be solved via whatever")
+	_, found := leq.solved.Get(w)
+	assert.False(t, found, "whatever should have been harvested")
+	_, found = r[w]
+	assert.False(t, found, "whatever should not be reported to resolver")
+	assert.NotEqual(t, w, leq.Whatever())
+}
+
+func TestGroups(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	assert.Equal(t, ErrNoGroup, leq.EndGroup())
+	leq.BeginGroup()
+	assert.NoError(t, leq.Save(5))
+	p1, _ := New(1, X{5, 1}, X{1, -1}) // a = e + 1
+	p2, _ := New(2, X{5, -1})          // e = 2
+	assert.NoError(t, errOf(leq.AddEqs([]Polynomial{p1, p2})))
+	_, found := leq.solved.Get(5)
+	assert.True(t, found, "e should be solved within group")
+	assert.NoError(t, leq.EndGroup())
+	_, found = leq.solved.Get(5)
+	assert.False(t, found, "e should be removed at end of group")
+	_, found = leq.solved.Get(1)
+	assert.True(t, found, "a should remain solved")
+	assert.Equal(t, ErrNoGroup, leq.Save(5))
+}
+
+func TestTransactions(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	txn := leq.Begin()
+	p2, _ := New(3, X{1, -1}) // a=3
+	assert.NoError(t, errOf(leq.AddEq(p2)))
+	assert.Equal(t, 0, len(r), "notifications should be held back")
+	assert.NoError(t, txn.Rollback())
+	assert.Equal(t, 0, leq.solved.Size(), "rollback should unsolve a and b")
+	txn = leq.Begin()
+	inner := leq.Begin()
+	p3, _ := New(4, X{1, -1}) // a=4
+	assert.NoError(t, errOf(leq.AddEq(p3)))
+	assert.Equal(t, ErrTxnOrder, txn.Commit())
+	assert.NoError(t, inner.Commit())
+	assert.Equal(t, 0, len(r), "notifications should be held back until outermost commit")
+	assert.NoError(t, txn.Commit())
+	assert.Equal(t, 6.0, r[2])
+	assert.Equal(t, ErrTxnOrder, txn.Rollback())
+}
+
+type unsolvingRes struct {
+	res
+	unsolved []int
+}
+
+func (r *unsolvingRes) SetVariableUnsolved(n int) {
+	delete(r.res, n)
+	r.unsolved = append(r.unsolved, n)
+}
+
+func TestRetract(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := &unsolvingRes{res: newResolver()}
+	leq.SetVariableResolver(r)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3
+	_, err := leq.AddEq(p1)
+	assert.NoError(t, err)
+	h, err := leq.AddEq(p2)
+	assert.NoError(t, err)
+	assert.Equal(t, 7.0, r.res[2])
+	assert.NoError(t, leq.Retract(h))
+	assert.ElementsMatch(t, []int{1, 2}, r.unsolved, "a and b should be unsolved")
+	assert.Equal(t, ErrUnknownEquation, leq.Retract(h))
+	p3, _ := New(4, X{1, -1}) // a=4
+	assert.NoError(t, errOf(leq.AddEq(p3)))
+	assert.Equal(t, 6.0, r.res[2], "b should be solved from remaining a+b=10")
+}
+
+func TestRetractConnected(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := &unsolvingRes{res: newResolver()}
+	leq.SetVariableResolver(r)
+	solved := make(map[int]int)
+	leq.OnSolved(func(i int, v float64) { solved[i]++ })
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3
+	p3, _ := New(5, X{3, -1})            // c=5, not connected to a and b
+	p4, _ := New(0, X{3, 1}, X{4, -1})   // d=c
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	h, _ := leq.AddEq(p2)
+	assert.NoError(t, errOf(leq.AddEqs([]Polynomial{p3, p4})))
+	assert.NoError(t, leq.Retract(h))
+	assert.ElementsMatch(t, []int{1, 2}, r.unsolved, "a and b should be unsolved")
+	assert.Equal(t, map[int]int{1: 1, 2: 1, 3: 1, 4: 1}, solved, "c and d should not be reported again")
+	assert.Equal(t, 5.0, r.res[4])
+	// slack 0.06: a=1 and a=1.05 are fitted to a=1.025, a=1.08 is fitted
+	// to a=1.0433, but a=1 and a=1.08 cannot be fitted
+	leq = CreateLinEqSolver()
+	leq.SetVariableResolver(r)
+	leq.SetSlack(0.06)
+	q1, _ := New(1, X{1, -1})
+	q2, _ := New(1.05, X{1, -1})
+	q3, _ := New(1.08, X{1, -1})
+	assert.NoError(t, errOf(leq.AddEq(q1)))
+	h, _ = leq.AddEq(q2)
+	assert.NoError(t, errOf(leq.AddEq(q3)))
+	assert.InDelta(t, 1.0433, r.res[1], 1e-4)
+	err := leq.Retract(h)
+	assert.True(t, errors.Is(err, ErrInconsistent), "a=1 and a=1.08 should not be fitted")
+	assert.InDelta(t, 1.0433, r.res[1], 1e-4, "LEQ should be unchanged")
+	assert.Equal(t, 3, len(leq.equations), "LEQ should be unchanged")
+	assert.Equal(t, 0, len(leq.txns), "transaction should have been closed")
+}
+
+func TestLeastSquares(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	leq.SetSlack(0.1)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3
+	p3, _ := New(7.03, X{2, -1})         // b=7.03, off by 0.03
+	p4, _ := New(5, X{3, -1})            // c=5, not connected
+	assert.NoError(t, errOf(leq.AddEq(p4)))
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	assert.NoError(t, errOf(leq.AddEq(p2)))
+	assert.NoError(t, errOf(leq.AddEq(p3)))
+	assert.InDelta(t, 2.99, r[1], 1e-9, "a should be fitted")
+	assert.InDelta(t, 7.02, r[2], 1e-9, "b should be fitted")
+	assert.Equal(t, 5.0, r[3])
+	p5, _ := New(8, X{2, -1}) // b=8, off by ~1
+	_, err := leq.AddEq(p5)
+	assert.True(t, errors.Is(err, ErrInconsistent), "b=8 exceeds slack")
+	assert.InDelta(t, 7.02, r[2], 1e-9, "b should keep its fitted value")
+}
+
+func TestRedundant(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	var redundant []Polynomial
+	leq.SetRedundancyHandler(func(eq Polynomial) {
+		redundant = append(redundant, eq)
+	})
+	p1, _ := New(0, X{1, 1}, X{2, -1})           // a=b
+	p2, _ := New(0, X{1, 2}, X{2, -2})           // 2a=2b, implied by a=b
+	p3, _ := New(10, X{1, 1}, X{2, 1}, X{3, -1}) // c=10+a+b
+	p4, _ := New(20, X{1, 2}, X{2, 2}, X{3, -2}) // implied by a=b and c=10+a+b
+	p5, _ := New(4, X{1, -1})                    // a=4
+	p6, _ := New(18, X{3, -1})                   // c=18, implied by a=4
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	assert.NoError(t, errOf(leq.AddEq(p2)))
+	assert.Equal(t, 1, len(redundant), "2a=2b should be reported as redundant")
+	assert.Equal(t, 1, leq.dependents.Size(), "redundant equation should not change dependents")
+	assert.NoError(t, errOf(leq.AddEqs([]Polynomial{p3, p4, p5, p6})))
+	if assert.Equal(t, 3, len(redundant)) {
+		assert.Equal(t, -2.0, redundant[1].GetCoeffForTerm(3))
+		assert.Equal(t, -1.0, redundant[2].GetCoeffForTerm(3))
+	}
+	assert.Equal(t, 4.0, r[2])
+	assert.Equal(t, 18.0, r[3])
+}
+
+func TestBounds(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	assert.NoError(t, leq.AtLeast(2, 0)) // b ≥ 0
+	assert.NoError(t, leq.AtMost(2, 5))  // b ≤ 5
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3  ⇒  b=7
+	p3, _ := New(6, X{1, -1})            // a=6  ⇒  b=4
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	_, err := leq.AddEq(p2)
+	assert.True(t, errors.Is(err, ErrBoundViolated), "b=7 should violate b ≤ 5")
+	var berr *BoundError
+	if assert.True(t, errors.As(err, &berr)) {
+		assert.Equal(t, 2, berr.Var)
+		assert.Equal(t, 7.0, berr.Value)
+	}
+	_, found := r[1]
+	assert.False(t, found, "a should not be solved")
+	assert.NoError(t, errOf(leq.AddEq(p3)))
+	assert.Equal(t, 4.0, r[2])
+	assert.True(t, errors.Is(leq.AtLeast(2, 4.5), ErrBoundViolated))
+	lo, hi := leq.Bounds(2)
+	assert.Equal(t, 0.0, lo)
+	assert.Equal(t, 5.0, hi)
+}
+
+func TestBoundsOfDependents(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	for i := 1; i <= 2; i++ { // 0 ≤ a,b ≤ 5
+		assert.NoError(t, leq.AtLeast(i, 0))
+		assert.NoError(t, leq.AtMost(i, 5))
+	}
+	assert.NoError(t, leq.AtMost(3, 10))         // c ≤ 10
+	p1, _ := New(20, X{1, 1}, X{2, 1}, X{3, -1}) // c=a+b+20 ≥ 20
+	p2, _ := New(1, X{1, 1}, X{2, 1}, X{3, -1})  // c=a+b+1
+	_, err := leq.AddEq(p1)                      // solving for a: a=c-b-20 ≤ -10
+	assert.True(t, errors.Is(err, ErrBoundViolated), "c=a+b+20 should violate c ≤ 10")
+	assert.Equal(t, 0, leq.dependents.Size(), "LEQ should be unchanged")
+	assert.NoError(t, errOf(leq.AddEq(p2)))
+	p3, _ := New(-1, X{4, 1}, X{3, -1}) // c=d-1
+	_, err = leq.AddEq(p3)
+	assert.NoError(t, err, "d is unbounded")
+}
+
+func TestRatLEQ(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateRatLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	third := big.NewRat(1, 3)
+	one := big.NewRat(1, 1)
+	p1 := NewConstantRatPolynomial(big.NewRat(-1, 1)).SetTerm(1, big.NewRat(3, 1)) // 3a=1
+	p2 := NewConstantRatPolynomial(new(big.Rat)).
+		SetTerm(1, one).SetTerm(2, third).SetTerm(3, big.NewRat(-1, 1)) // c=a+b/3
+	p3 := NewConstantRatPolynomial(big.NewRat(-2, 3)).SetTerm(2, one) // b=2/3
+	assert.NoError(t, leq.AddEqs([]RatPolynomial{p2, p1, p3}))
+	a, ok := leq.Value(1)
+	if assert.True(t, ok) {
+		assert.Equal(t, "1/3", a.RatString())
+	}
+	c, ok := leq.Value(3)
+	if assert.True(t, ok) {
+		assert.Equal(t, "5/9", c.RatString())
+	}
+	assert.InDelta(t, 5.0/9.0, r[3], 1e-15)
+	p4 := NewConstantRatPolynomial(one).SetTerm(3, big.NewRat(-9, 5)) // c=5/9
+	assert.NoError(t, leq.AddEq(p4))
+	p5 := NewConstantRatPolynomial(one).SetTerm(3, big.NewRat(-1, 1)) // c=1
+	assert.True(t, errors.Is(leq.AddEq(p5), ErrInconsistent))
+}
+
+func TestBigLEQ(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateBigLinEqSolver(200)
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	p1, _ := New(-1, X{1, 3})                     // 3a=1
+	p2, _ := New(0, X{1, 1}, X{2, 0.5}, X{3, -1}) // c=a+b/2
+	p3, _ := New(-2, X{2, 3})                     // 3b=2
+	assert.NoError(t, leq.AddEqs([]Polynomial{p2, p1, p3}))
+	c, ok := leq.Value(3)
+	if assert.True(t, ok) {
+		_, isbig := c.(arithm.BigNumber)
+		assert.True(t, isbig, "expected value in arbitrary precision")
+		d := c.Mul(arithm.Float(3)).Sub(arithm.Float(2)) // c=2/3
+		assert.InDelta(t, 0, d.Float64(), 1e-59, "expected c=2/3 at 200 bits")
+	}
+	assert.InDelta(t, 2.0/3.0, r[3], 1e-15)
+	p4, _ := New(1, X{3, -1}) // c=1
+	assert.True(t, errors.Is(leq.AddEq(p4), ErrInconsistent))
+}
+
+func TestTermList(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	p, _ := New(1, X{3, 3}, X{1, 1}, X{2, 2})
+	assert.Equal(t, []int{0, 1, 2, 3}, p.Positions(), "terms should be sorted by position")
+	p.terms.Remove(2)
+	p.SetTerm(1, 5)
+	assert.Equal(t, []int{0, 1, 3}, p.terms.Keys())
+	q := p.CopyPolynomial()
+	q.SetTerm(3, 7)
+	assert.Equal(t, 3.0, p.GetCoeffForTerm(3), "copy should not share terms")
+	var sum float64
+	it := q.terms.Iterator()
+	for it.Next() {
+		sum += float64(it.Key()) * it.Value()
+	}
+	assert.Equal(t, 26.0, sum)
+}
+
+func TestSparseLEQ(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateSparseLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	leq.AddEq(p1)
+	assert.NoError(t, leq.Solve())
+	assert.Equal(t, 0, len(r), "nothing should be solved")
+	p2, _ := New(3, X{1, -1})                   // a=3
+	p3, _ := New(0, X{2, 2}, X{3, -1})          // c=2b
+	p4, _ := New(0, X{1, 2}, X{2, 2}, X{4, -1}) // d=2a+2b
+	p5, _ := New(-20, X{1, 1}, X{2, 1})         // a+b=20
+	leq.AddEqs([]Polynomial{p3, p4, p2, p5})
+	err := leq.Solve()
+	assert.True(t, errors.Is(err, ErrInconsistent), "a+b=20 should be off")
+	assert.Equal(t, 3.0, r[1])
+	assert.Equal(t, 7.0, r[2])
+	assert.Equal(t, 14.0, r[3])
+	assert.Equal(t, 20.0, r[4])
+	// a chain x.1 = 1, x.i+1 = x.i + 1, given in reverse order
+	leq = CreateSparseLinEqSolver()
+	n := 500
+	for i := n - 1; i > 0; i-- {
+		p, _ := New(1, X{i, 1}, X{i + 1, -1})
+		leq.AddEq(p)
+	}
+	p, _ := New(1, X{1, -1})
+	leq.AddEq(p)
+	assert.NoError(t, leq.Solve())
+	v, ok := leq.Value(n)
+	assert.True(t, ok)
+	assert.Equal(t, float64(n), v)
+}
+
+func TestDeferred(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	var redundant int
+	leq.SetRedundancyHandler(func(Polynomial) { redundant++ })
+	p1, _ := New(10, X{1, -1}, X{2, -1})        // a+b=10
+	p2, _ := New(0, X{2, 2}, X{3, -1})          // c=2b
+	p3, _ := New(0, X{1, 1}, X{2, 1}, X{4, -1}) // d=a+b
+	p4, _ := New(20, X{1, -2}, X{2, -2})        // 2a+2b=20, redundant
+	p5, _ := New(3, X{1, -1})                   // a=3
+	p6, _ := New(-11, X{4, 1})                  // d=11, off
+	assert.NoError(t, errOf(leq.AddEq(p1)))
+	leq.Defer()
+	handles, err := leq.AddEqs([]Polynomial{p2, p3, p4})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(handles))
+	assert.Equal(t, 0, len(r), "deferred equations should not be solved")
+	assert.NoError(t, leq.Flush())
+	assert.Equal(t, 1, redundant)
+	assert.Equal(t, 10.0, r[4], "d should be solved")
+	leq.Defer()
+	assert.NoError(t, errOf(leq.AddEq(p5)))
+	h, _ := leq.AddEq(p6)
+	assert.True(t, errors.Is(leq.Flush(), ErrInconsistent))
+	_, found := r[1]
+	assert.False(t, found, "LEQ should be unchanged")
+	assert.True(t, errors.Is(leq.Flush(), ErrInconsistent), "equations should remain queued")
+	assert.NoError(t, leq.Retract(h))
+	assert.NoError(t, leq.Flush())
+	assert.Equal(t, 7.0, r[2])
+	assert.Equal(t, 14.0, r[3])
+}
+
+func TestFreeze(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3
+	leq.AddEq(p1)
+	view := leq.Freeze()
+	var wg sync.WaitGroup
+	for k := 0; k < 4; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				if dep, ok := view.Dependency(1); ok {
+					dep.SetTerm(2, 42) // must not alter the view
+				}
+				view.Value(2)
+			}
+		}()
+	}
+	for n := 3; n < 100; n++ { // continue to use the LEQ meanwhile
+		p, _ := New(float64(n), X{n, -1})
+		leq.AddEq(p)
+	}
+	wg.Wait()
+	leq.AddEq(p2)
+	_, ok := view.Value(1)
+	assert.False(t, ok, "view should not reflect later changes")
+	dep, ok := view.Dependency(1)
+	if assert.True(t, ok) {
+		assert.Equal(t, -1.0, dep.GetCoeffForTerm(2))
+	}
+	assert.Equal(t, 7.0, leq.Freeze().solved[2])
+}
+
+func TestClone(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	r := newResolver()
+	leq.SetVariableResolver(r)
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(3, X{1, -1})            // a=3
+	p3, _ := New(4, X{1, -1})            // a=4
+	leq.AddEq(p1)
+	branch := leq.Clone()
+	rb := newResolver()
+	branch.SetVariableResolver(rb)
+	assert.NoError(t, errOf(branch.AddEq(p2)))
+	assert.NoError(t, errOf(leq.AddEq(p3)), "original should not see a=3")
+	assert.Equal(t, 7.0, rb[2])
+	assert.Equal(t, 6.0, r[2])
+	h, err := branch.AddEq(p1)
+	assert.NoError(t, err)
+	assert.NoError(t, branch.Retract(h))
+	assert.Equal(t, 2, len(leq.equations), "retracting in the clone should not affect the original")
+}
+
+func TestDegreesOfFreedom(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	p1, _ := New(10, X{1, -1}, X{2, -1}, X{3, -1}) // a+b+c=10
+	p2, _ := New(0, X{4, -1}, X{2, 1})             // d=b
+	p3, _ := New(3, X{2, -1})                      // b=3
+	leq.AddEqs([]Polynomial{p1, p2})
+	assert.Equal(t, []int{1, 2, 3, 4}, leq.Unsolved())
+	assert.Equal(t, 2, leq.DegreesOfFreedom())
+	w := leq.Whatever()
+	p4, _ := New(0, X{5, -1}, X{w, 1}) // e=whatever
+	p5, _ := New(0, X{6, -1}, X{w, 2}) // f=2whatever
+	leq.AddEqs([]Polynomial{p4, p5})
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, leq.Unsolved(), "whatever should not be reported")
+	assert.Equal(t, 3, leq.DegreesOfFreedom())
+	leq.AddEq(p3)
+	assert.Equal(t, []int{1, 3, 5, 6}, leq.Unsolved())
+	assert.Equal(t, 2, leq.DegreesOfFreedom())
+}
+
+func TestListeners(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	leq := CreateLinEqSolver()
+	solved := make(map[int]float64)
+	var order []int
+	deps := make(map[int]Polynomial)
+	leq.OnSolved(func(i int, v float64) { solved[i] = v })
+	leq.OnSolved(func(i int, v float64) { order = append(order, i) })
+	leq.OnDependencyChanged(func(i int, p Polynomial) { deps[i] = p })
+	p1, _ := New(10, X{1, -1}, X{2, -1}) // a+b=10
+	p2, _ := New(0, X{3, -1}, X{4, 2})   // c=2d
+	p3, _ := New(3, X{2, -1})            // b=3
+	leq.AddEq(p1)
+	if assert.Contains(t, deps, 1) {
+		assert.Equal(t, -1.0, deps[1].GetCoeffForTerm(2), "a = 10 - b")
+	}
+	deps = make(map[int]Polynomial)
+	leq.AddEq(p2)
+	assert.Contains(t, deps, 4, "d = c/2")
+	assert.NotContains(t, deps, 1, "a = 10 - b did not change")
+	txn := leq.Begin()
+	leq.AddEq(p3)
+	assert.Equal(t, 0, len(solved), "notifications should be held back")
+	txn.Commit()
+	assert.Equal(t, map[int]float64{1: 7, 2: 3}, solved)
+	assert.Equal(t, 2, len(order), "second listener should be called as well")
+}
@@ -0,0 +1,218 @@
+package polyn
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/npillmayer/arithm"
+)
+
+// === Least Squares =========================================================
+
+// SetSlack sets the maximum inconsistency of equations which will be fitted
+// in a least-squares sense. By default, every equation contradicting the
+// LEQ is rejected with ErrInconsistent. With a slack > 0, an equation which
+// is off by no more than the slack is accepted. The variables of all
+// equations connected to it (by sharing variables, transitively) are then
+// set to the values minimizing the sum of squared errors of these
+// equations. This is intended for fitting noisy data into constraints.
+//
+// Fitting requires the connected equations to determine all of their
+// variables; otherwise the equation is rejected as inconsistent.
+func (leq *LinEqSolver) SetSlack(slack float64) {
+	leq.slack = slack
+}
+
+// withinSlack is a predicate: is err an inconsistency which should be
+// fitted?
+func (leq *LinEqSolver) withinSlack(err error) bool {
+	var eqerr *EquationError
+	return leq.slack > 0 && errors.As(err, &eqerr) && eqerr.Err == ErrInconsistent &&
+		math.Abs(eqerr.Off) <= leq.slack
+}
+
+// addFitted adds an equation which is slightly off and re-calculates the
+// LEQ with least-squares fitting. If fitting is not possible, the LEQ is
+// left unchanged and an error is returned.
+func (leq *LinEqSolver) addFitted(p Polynomial, cont bool) (EqHandle, error) {
+	txn := leq.Begin()
+	h := leq.logEq(p)
+	vars := make(map[int]bool)
+	addVars(p, vars)
+	if err := leq.rebuild(vars, cont); err != nil {
+		txn.Rollback()
+		return 0, err
+	}
+	return h, txn.Commit()
+}
+
+// reset removes the dependencies and solutions of the variables vars.
+func (leq *LinEqSolver) reset(vars map[int]bool) {
+	for i := range vars {
+		leq.dependents.Remove(i)
+		leq.solved.Remove(i)
+	}
+}
+
+// replay re-calculates dependencies and solutions from a list of equations,
+// which contain the variables vars only. Equations off by no more than the
+// slack are fitted by least squares, replacing the equations connected to
+// them by the fitted values of their variables. Returns the first error of
+// re-adding an equation.
+func (leq *LinEqSolver) replay(eqs []loggedEq, vars map[int]bool) error {
+	var firstErr error
+	fitted := make(map[int]float64)
+	skip := make(map[EqHandle]bool)   // fitted or failed equations
+	failed := make(map[EqHandle]bool) // equations which could not be added
+	leq.reset(vars)
+	for k, eq := range eqs {
+		_, err := leq.addEq(eq.p, true)
+		if leq.withinSlack(err) {
+			comp := component(eqs[:k+1], failed)
+			if values, ok := leastSquares(comp); ok {
+				T().P("op", "fit").Infof("fitting %d equations by least squares", len(comp))
+				for _, c := range comp {
+					skip[c.h] = true
+				}
+				for i, v := range values {
+					fitted[i] = v
+				}
+				leq.replayFitted(eqs[:k+1], vars, skip, fitted)
+				continue
+			}
+		}
+		if err != nil {
+			skip[eq.h], failed[eq.h] = true, true
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// replayFitted re-calculates dependencies and solutions from equations which
+// have not been fitted, and from the fitted values of variables.
+func (leq *LinEqSolver) replayFitted(eqs []loggedEq, vars map[int]bool, skip map[EqHandle]bool, fitted map[int]float64) {
+	leq.reset(vars)
+	for _, eq := range eqs {
+		if !skip[eq.h] {
+			leq.addEq(eq.p, true)
+		}
+	}
+	positions := make([]int, 0, len(fitted))
+	for i := range fitted {
+		positions = append(positions, i)
+	}
+	sort.Ints(positions)
+	for _, i := range positions { // x.i = v  ⇒  0 = v - x.i
+		leq.addEq(NewConstantPolynomial(fitted[i]).SetTerm(i, -1), true)
+	}
+}
+
+// component collects the last equation of eqs and all equations connected
+// to it by sharing variables, transitively, except failed equations.
+// Equations which have been fitted before are collected as well, superseding
+// their previous fit.
+func component(eqs []loggedEq, failed map[EqHandle]bool) []loggedEq {
+	vars := make(map[int]bool)
+	addVars(eqs[len(eqs)-1].p, vars)
+	return connected(eqs, vars, failed)
+}
+
+// connected collects the equations of eqs which share variables with vars,
+// transitively, except failed equations, in the order of eqs. The variables
+// of collected equations are added to vars.
+func connected(eqs []loggedEq, vars map[int]bool, failed map[EqHandle]bool) []loggedEq {
+	in := make([]bool, len(eqs))
+	for changed := true; changed; {
+		changed = false
+		for k, eq := range eqs {
+			if !in[k] && !failed[eq.h] && sharesVars(eq.p, vars) {
+				in[k], changed = true, true
+				addVars(eq.p, vars)
+			}
+		}
+	}
+	var comp []loggedEq
+	for k, eq := range eqs {
+		if in[k] {
+			comp = append(comp, eq)
+		}
+	}
+	return comp
+}
+
+func addVars(p Polynomial, vars map[int]bool) {
+	for _, i := range p.terms.Keys() {
+		if i != 0 {
+			vars[i] = true
+		}
+	}
+}
+
+func sharesVars(p Polynomial, vars map[int]bool) bool {
+	for _, i := range p.terms.Keys() {
+		if i != 0 && vars[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// leastSquares finds the values of the variables of a set of equations
+// 0 = c + a.1 x.1 + … + a.n x.n which minimize the sum of squared errors,
+// using the normal equations. Returns false if the equations do not
+// determine all of their variables.
+func leastSquares(eqs []loggedEq) (map[int]float64, bool) {
+	vars := make(map[int]bool)
+	for _, eq := range eqs {
+		addVars(eq.p, vars)
+	}
+	pos := make(map[int]int, len(vars)) // variable ⟼ column
+	cols := make([]int, 0, len(vars))
+	for i := range vars {
+		cols = append(cols, i)
+	}
+	sort.Ints(cols)
+	for k, i := range cols {
+		pos[i] = k
+	}
+	n := len(cols)
+	if len(eqs) < n {
+		return nil, false
+	}
+	ata := make([][]float64, n) // AᵀA
+	atb := make([]float64, n)   // Aᵀb
+	for k := range ata {
+		ata[k] = make([]float64, n)
+	}
+	for _, eq := range eqs {
+		b := -eq.p.GetConstantValue()
+		it := eq.p.terms.Iterator()
+		for it.Next() {
+			i := it.Key()
+			if i == 0 {
+				continue
+			}
+			ai := it.Value()
+			atb[pos[i]] += ai * b
+			it2 := eq.p.terms.Iterator()
+			for it2.Next() {
+				if j := it2.Key(); j != 0 {
+					ata[pos[i]][pos[j]] += ai * it2.Value()
+				}
+			}
+		}
+	}
+	x, ok := arithm.SolveLinear(ata, atb)
+	if !ok {
+		return nil, false
+	}
+	values := make(map[int]float64, n)
+	for k, i := range cols {
+		values[i] = x[k]
+	}
+	return values, true
+}
@@ -0,0 +1,82 @@
+package polyn
+
+// === Listeners =============================================================
+
+// notification is a message to a VariableResolver and to listeners, either
+// of a solved variable x.i = c, of a new dependency x.i = p(i), if p is
+// valid, or of x.i being no longer solved, if unsolved is set.
+type notification struct {
+	i        int
+	c        float64
+	p        Polynomial
+	unsolved bool
+}
+
+// OnSolved registers a listener, which will be called for every variable
+// x.i being solved, with its value. Listeners are called in the order of
+// registration, after the VariableResolver. Like the VariableResolver,
+// listeners are not notified of anonymous variables, and notifications are
+// held back by transactions.
+//
+// OnSolved decouples notifications from resolving variable names, which
+// both are the concern of a VariableResolver.
+func (leq *LinEqSolver) OnSolved(f func(i int, v float64)) {
+	leq.onsolved = append(leq.onsolved, f)
+}
+
+// OnDependencyChanged registers a listener, which will be called whenever a
+// variable x.i becomes dependent or its dependency x.i = p(i) changes.
+// Listeners receive a copy of p(i). Variables being solved are reported to
+// OnSolved listeners instead. See OnSolved.
+func (leq *LinEqSolver) OnDependencyChanged(f func(i int, p Polynomial)) {
+	leq.ondependency = append(leq.ondependency, f)
+}
+
+// notify delivers a notification, or holds it back until the outermost
+// transaction is committed. Notifications are dropped while the LEQ is
+// being rebuilt, as changes are reported after rebuilding.
+func (leq *LinEqSolver) notify(msg notification) {
+	if msg.i >= WhateverBase || leq.muted {
+		return
+	}
+	if len(leq.txns) > 0 {
+		leq.pending = append(leq.pending, msg)
+		return
+	}
+	leq.deliver(msg)
+}
+
+// deliver sends a notification to the VariableResolver and to listeners.
+func (leq *LinEqSolver) deliver(msg notification) {
+	if msg.unsolved {
+		if unsolver, ok := leq.varresolver.(VariableUnsolver); ok {
+			unsolver.SetVariableUnsolved(msg.i)
+		}
+		return
+	}
+	if msg.p.IsValid() {
+		for _, f := range leq.ondependency {
+			f(msg.i, msg.p.CopyPolynomial())
+		}
+		return
+	}
+	if leq.varresolver != nil {
+		leq.varresolver.SetVariableSolved(msg.i, msg.c) // notify variable solver
+	}
+	for _, f := range leq.onsolved {
+		f(msg.i, msg.c)
+	}
+}
+
+// sameTerms is a predicate: do p and q have identical terms?
+func sameTerms(p, q Polynomial) bool {
+	if p.terms.Size() != q.terms.Size() {
+		return false
+	}
+	for k, t := range p.terms.terms {
+		if q.terms.terms[k] != t {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package polyn
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/npillmayer/arithm"
+)
+
+// === LEQ in arithm.Numbers =================================================
+
+// numPolynomial is a linear polynomial with coefficients of type
+// arithm.Number. Terms with coefficient 0 are not stored. It is the
+// representation of equations for solvers running at a precision other
+// than float64: exact rationals (RatLinEqSolver) or arbitrary precision
+// floats (BigLinEqSolver).
+type numPolynomial map[int]arithm.Number
+
+func (p numPolynomial) setTerm(i int, a arithm.Number) numPolynomial {
+	if a.Sign() == 0 {
+		delete(p, i)
+	} else {
+		p[i] = a
+	}
+	return p
+}
+
+func (p numPolynomial) copy() numPolynomial {
+	r := make(numPolynomial, len(p))
+	for i, a := range p {
+		r[i] = a
+	}
+	return r
+}
+
+// constant returns the constant term of p and a flag telling if p is a
+// constant. A missing constant term is returned as nil.
+func (p numPolynomial) constant() (arithm.Number, bool) {
+	for i := range p {
+		if i != 0 {
+			return nil, false
+		}
+	}
+	return p[0], true
+}
+
+// positions returns the positions of all terms of p, sorted.
+func (p numPolynomial) positions() []int {
+	pos := make([]int, 0, len(p))
+	for i := range p {
+		pos = append(pos, i)
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// substitute replaces x.i within p by p2. Returns a new numPolynomial.
+func (p numPolynomial) substitute(i int, p2 numPolynomial) numPolynomial {
+	a, ok := p[i]
+	if !ok {
+		return p
+	}
+	r := p.copy()
+	delete(r, i)
+	for j, b := range p2 {
+		if c, ok := r[j]; ok {
+			r.setTerm(j, c.Add(a.Mul(b)))
+		} else {
+			r.setTerm(j, a.Mul(b))
+		}
+	}
+	return r
+}
+
+// maxCoeff finds the variable with the coefficient of maximum absolute value.
+// If p does not contain any variable, position 0 is returned.
+func (p numPolynomial) maxCoeff() int {
+	var maxp int
+	var maxc arithm.Number
+	for _, i := range p.positions() {
+		a := p[i]
+		if a.Sign() < 0 {
+			a = a.Neg()
+		}
+		if i > 0 && (maxc == nil || a.Sub(maxc).Sign() > 0) {
+			maxp, maxc = i, a
+		}
+	}
+	return maxp
+}
+
+// float converts p to a Polynomial, rounding coefficients to float64.
+func (p numPolynomial) float() Polynomial {
+	q := NewConstantPolynomial(0)
+	for _, i := range p.positions() {
+		q.SetTerm(i, p[i].Float64())
+	}
+	return q
+}
+
+func (p numPolynomial) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("{ ")
+	if c, ok := p[0]; ok {
+		buffer.WriteString(c.String())
+	} else {
+		buffer.WriteString("0")
+	}
+	for _, i := range p.positions() {
+		if i == 0 {
+			continue
+		}
+		if a := p[i]; a.Sign() < 0 {
+			buffer.WriteString(fmt.Sprintf(" - %s x.%d", a.Neg(), i))
+		} else {
+			buffer.WriteString(fmt.Sprintf(" + %s x.%d", a, i))
+		}
+	}
+	buffer.WriteString(" }")
+	return buffer.String()
+}
+
+// numSolver solves systems of linear equations in arithm.Numbers, in the
+// same way as LinEqSolver. It is the common implementation of
+// RatLinEqSolver and BigLinEqSolver.
+type numSolver struct {
+	dependents  map[int]numPolynomial // dependent variable x.i = p(i), with p(i) in free variables
+	solved      map[int]arithm.Number // map x.i => value
+	varresolver VariableResolver      // to resolve variable names from term positions
+}
+
+func newNumSolver() numSolver {
+	return numSolver{
+		dependents: make(map[int]numPolynomial),
+		solved:     make(map[int]arithm.Number),
+	}
+}
+
+// addEq adds a new equation 0 = p and solves the system as far as possible.
+// If the equation contradicts the system, addEq returns an *EquationError
+// wrapping ErrInconsistent, and the system is left unchanged.
+func (leq *numSolver) addEq(p numPolynomial) error {
+	T().P("op", "new equation").Infof("0 = %s", p)
+	q := p.copy()
+	for _, i := range q.positions() { // substitute solved and dependents
+		if c, ok := leq.solved[i]; ok {
+			q = q.substitute(i, numPolynomial{0: c})
+		} else if d, ok := leq.dependents[i]; ok {
+			q = q.substitute(i, d)
+		}
+	}
+	if c, isconst := q.constant(); isconst {
+		if c != nil && c.Sign() != 0 {
+			T().P("op", "new equation").Errorf("%v: 0 = %s", ErrInconsistent, p)
+			return &EquationError{Err: ErrInconsistent, Eq: p.float(), Off: c.Float64()}
+		}
+		T().P("op", "new equation").Infof("redundant equation: 0 = %s", p)
+		return nil
+	}
+	k := q.maxCoeff() // 0 = a.k x.k + q'  ⇒  x.k = -1/a.k q'
+	a := q[k].Neg()
+	delete(q, k)
+	for j, b := range q {
+		q[j] = b.Quo(a)
+	}
+	for j, d := range leq.dependents { // substitute x.k in every x.j = d(j)
+		leq.dependents[j] = d.substitute(k, q)
+	}
+	leq.dependents[k] = q
+	deps := make([]int, 0, len(leq.dependents))
+	for j := range leq.dependents {
+		deps = append(deps, j)
+	}
+	sort.Ints(deps)
+	for _, j := range deps { // move constant dependents to solved
+		if c, isconst := leq.dependents[j].constant(); isconst {
+			delete(leq.dependents, j)
+			leq.setSolved(j, c)
+		}
+	}
+	return nil
+}
+
+// setSolved moves x.i to the set of solved variables and notifies the
+// variable resolver. A nil value stands for 0.
+func (leq *numSolver) setSolved(i int, c arithm.Number) {
+	if c == nil {
+		c = arithm.Float(0)
+	}
+	leq.solved[i] = c
+	T().P("var", fmt.Sprintf("x.%d", i)).Infof("#### x.%d = %s", i, c)
+	if leq.varresolver != nil {
+		leq.varresolver.SetVariableSolved(i, c.Float64())
+	}
+}
+
+// --- High Precision LEQ ----------------------------------------------------
+
+// BigLinEqSolver is a container for linear equations, which are solved in
+// arbitrary precision arithmetic (see arithm.BigNumber). It solves systems
+// of linear equations incrementally, in the same way as LinEqSolver.
+// Coefficients are given as float64, but elimination and substitution,
+// where rounding errors accumulate, are done at the precision of the solver.
+//
+// BigLinEqSolver is intended for producing reference values when
+// investigating the rounding behaviour of LinEqSolver. Like
+// RatLinEqSolver, it does not support capsules, groups, transactions or any
+// of the other extensions of LinEqSolver.
+type BigLinEqSolver struct {
+	numSolver
+	prec uint
+}
+
+// CreateBigLinEqSolver creates a new system of linear equations, solved in
+// arithmetic with prec bits of mantissa.
+func CreateBigLinEqSolver(prec uint) *BigLinEqSolver {
+	return &BigLinEqSolver{numSolver: newNumSolver(), prec: prec}
+}
+
+// SetVariableResolver sets a variable resolver. Solved variables are reported
+// to the resolver rounded to float64; use Value to get the precise value.
+func (leq *BigLinEqSolver) SetVariableResolver(resolver VariableResolver) {
+	leq.varresolver = resolver
+}
+
+// Value returns the value of x.i, if x.i is solved.
+func (leq *BigLinEqSolver) Value(i int) (arithm.Number, bool) {
+	c, ok := leq.solved[i]
+	return c, ok
+}
+
+// AddEq adds a new equation 0 = p to a system of linear equations, and
+// solves the system as far as possible.
+//
+// If the equation contradicts the system, AddEq returns an *EquationError
+// wrapping ErrInconsistent, and the system is left unchanged. Equations
+// implied by the system are accepted without changing it.
+func (leq *BigLinEqSolver) AddEq(p Polynomial) error {
+	q := make(numPolynomial)
+	p.checkTerms()
+	it := p.terms.Iterator()
+	for it.Next() {
+		q.setTerm(it.Key(), arithm.NewBigNumber(it.Value(), leq.prec))
+	}
+	return leq.addEq(q)
+}
+
+// AddEqs adds a set of linear equations to the LEQ system. See AddEq.
+// Adding equations stops at the first equation which cannot be added,
+// returning its error.
+func (leq *BigLinEqSolver) AddEqs(plist []Polynomial) error {
+	for _, p := range plist {
+		if err := leq.AddEq(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
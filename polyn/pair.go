@@ -0,0 +1,99 @@
+package polyn
+
+import (
+	"fmt"
+
+	"github.com/npillmayer/arithm"
+)
+
+// === Pair Polynomials ======================================================
+
+// PairPolynomial is a linear polynomial with pair values, as used by
+// MetaFont's equations between points, e.g. z1 = z2 + (3,4). It consists of
+// a polynomial for the x-part and one for the y-part. A pair variable z is
+// represented by two numeric variables (xpart z, ypart z), which have
+// distinct positions in the LEQ.
+//
+// Scalar variables may occur in pair polynomials as well, scaled by a pair,
+// e.g. t⋅(z2-z1) for known z1 and z2.
+type PairPolynomial struct {
+	X Polynomial // x-part
+	Y Polynomial // y-part
+}
+
+// NewConstantPairPolynomial creates a PairPolynomial consisting of just a
+// constant pair.
+func NewConstantPairPolynomial(c arithm.Pair) PairPolynomial {
+	return PairPolynomial{
+		X: NewConstantPolynomial(c.X()),
+		Y: NewConstantPolynomial(c.Y()),
+	}
+}
+
+// SetPairTerm sets the coefficient for a pair variable a⋅z, where z is
+// represented by variables x.ix (xpart z) and x.iy (ypart z).
+func (pp PairPolynomial) SetPairTerm(ix, iy int, a float64) PairPolynomial {
+	pp.X = pp.X.SetTerm(ix, a)
+	pp.Y = pp.Y.SetTerm(iy, a)
+	return pp
+}
+
+// SetScalarTerm sets the coefficient for a scalar variable x.i, scaled by
+// pair c, i.e. the term c⋅x.i.
+func (pp PairPolynomial) SetScalarTerm(i int, c arithm.Pair) PairPolynomial {
+	pp.X = pp.X.SetTerm(i, c.X())
+	pp.Y = pp.Y.SetTerm(i, c.Y())
+	return pp
+}
+
+// Add adds two PairPolynomials. Returns a new PairPolynomial.
+func (pp PairPolynomial) Add(pp2 PairPolynomial) PairPolynomial {
+	return PairPolynomial{
+		X: pp.X.Add(pp2.X, false).Zap(),
+		Y: pp.Y.Add(pp2.Y, false).Zap(),
+	}
+}
+
+// Subtract subtracts two PairPolynomials. Returns a new PairPolynomial.
+func (pp PairPolynomial) Subtract(pp2 PairPolynomial) PairPolynomial {
+	return PairPolynomial{
+		X: pp.X.Subtract(pp2.X, false).Zap(),
+		Y: pp.Y.Subtract(pp2.Y, false).Zap(),
+	}
+}
+
+// Scaled multiplies a PairPolynomial by a numeric. Returns a new
+// PairPolynomial.
+func (pp PairPolynomial) Scaled(a float64) PairPolynomial {
+	return PairPolynomial{
+		X: pp.X.Multiply(NewConstantPolynomial(a), false),
+		Y: pp.Y.Multiply(NewConstantPolynomial(a), false),
+	}
+}
+
+// String creates a readable string representation for a PairPolynomial.
+func (pp PairPolynomial) String() string {
+	return fmt.Sprintf("( %s, %s )", pp.X, pp.Y)
+}
+
+// AddPairEq adds a pair equation 0 = pp to a system of linear equations,
+// i.e. the two equations 0 = xpart pp and 0 = ypart pp. See AddEq.
+//
+// Example: z1 = z2 + (3,4), with z1 = (x.1,x.2) and z2 = (x.3,x.4), is
+// added as
+//
+//	pp := NewConstantPairPolynomial(arithm.P(3, 4))
+//	pp = pp.SetPairTerm(3, 4, 1).SetPairTerm(1, 2, -1)
+//	leq.AddPairEq(pp)
+//
+// AddPairEq is atomic: if one of the two equations cannot be added, the LEQ
+// is left unchanged (see Txn), and no handles are returned.
+func (leq *LinEqSolver) AddPairEq(pp PairPolynomial) ([]EqHandle, error) {
+	txn := leq.Begin()
+	handles, err := leq.AddEqs([]Polynomial{pp.X, pp.Y})
+	if err != nil {
+		txn.Rollback()
+		return nil, err
+	}
+	return handles, txn.Commit()
+}
@@ -39,7 +39,6 @@ import (
 	"math"
 
 	"github.com/emirpasic/gods/maps"
-	"github.com/emirpasic/gods/maps/treemap"
 	"github.com/npillmayer/arithm"
 	"github.com/npillmayer/schuko/gtrace"
 	"github.com/npillmayer/schuko/tracing"
@@ -89,10 +88,11 @@ func New(c float64, tms ...X) (Polynomial, error) { // construct a polynomial
 //     c + a.1 x.1 + a.2 x.2 + ... a.n x.n .
 //
 // We store the coefficients only. Index 0 is the constant term.
-// We store the scales/coeff in a TreeMap (sorted map). Coefficients are of
-// type float64.
+// We store the scales/coeff in a termList (sorted by position). Coefficients
+// are of type float64. Use TermCount, Positions and GetCoeffForTerm to
+// inspect the terms of a polynomial.
 type Polynomial struct {
-	Terms *treemap.Map
+	terms *termList
 }
 
 // NewConstantPolynomial creates a Polynomial consisting of just a constant term.
@@ -101,13 +101,13 @@ func NewConstantPolynomial(c float64) Polynomial {
 	//p := Polynomial{m}
 	p := Polynomial{}
 	p.checkTerms()
-	p.Terms.Put(0, c) // initialize with constant term (at position 0)
+	p.terms.Put(0, c) // initialize with constant term (at position 0)
 	return p.Zap()
 }
 
 func (p *Polynomial) checkTerms() {
-	if p.Terms == nil {
-		p.Terms = treemap.NewWithIntComparator()
+	if p.terms == nil {
+		p.terms = newTermList()
 	}
 }
 
@@ -115,19 +115,15 @@ func (p *Polynomial) checkTerms() {
 // For i=0, sets the constant term.
 func (p Polynomial) SetTerm(i int, scale float64) Polynomial {
 	p.checkTerms()
-	p.Terms.Put(i, scale)
+	p.terms.Put(i, scale)
 	return p
 }
 
-// Helper: for an equation [ 0 = p ] check if p is constant and != 0.
-//
-// Panics if true (for easier debugging).
+// Helper: for an equation [ 0 = p ] check if p is constant, i.e. does not
+// contain any variables. Returns the constant and true if so. If the constant
+// is != 0, the equation is off by this value.
 func (p Polynomial) isOff() (float64, bool) {
 	if coeff, isconst := p.IsConstant(); isconst {
-		//coeff := p.getCoeffForTerm(0)
-		if !arithm.Is0(coeff) {
-			panic(fmt.Sprintf("equation off by %g", coeff))
-		}
 		return coeff, true
 	}
 	return 0.0, false
@@ -138,15 +134,16 @@ func (p Polynomial) isOff() (float64, bool) {
 // x.i not in dependents (i.e., we're looking for free variables only:
 // find free variable x.i in p, with abs(a.i) is max in p).
 // If no free variable can be found, find max(dependent(a.j)).
+// If p does not contain any variable, position 0 is returned.
 //
 func (p Polynomial) maxCoeff(dependents maps.Map) (int, float64) {
 	p.checkTerms()
-	it := p.Terms.Iterator()
+	it := p.terms.Iterator()
 	var maxp int      // variable position of max coeff
 	var maxc = 0.0    // max coeff
 	var coeff float64 // result coeff
 	for it.Next() {
-		i := it.Key().(int)
+		i := it.Key()
 		var isdep = false
 		if dependents != nil {
 			_, isdep = dependents.Get(i) // could be better de-coupled by providing predicate func
@@ -162,9 +159,6 @@ func (p Polynomial) maxCoeff(dependents maps.Map) (int, float64) {
 	if maxp == 0 && dependents != nil { // no free variable found
 		maxp, coeff = p.maxCoeff(nil) //
 	}
-	if maxp == 0 {
-		panic("I think this is an impossible error: seeing equation 0 = c")
-	}
 	return maxp, coeff
 }
 
@@ -181,7 +175,7 @@ func (p Polynomial) substitute(i int, p2 Polynomial) Polynomial {
 	scale_i = p.GetCoeffForTerm(i)
 	if !arithm.Is0(scale_i) { // variable i exists in p
 		//log.Printf("# found x.%d scaled %s\n", i, scale_i.String())
-		p.Terms.Remove(i)
+		p.terms.Remove(i)
 		//log.Printf("# p/%d = %s\n", i, p)
 		pp := p2.Multiply(NewConstantPolynomial(scale_i), true)
 		//log.Printf("# p2 * %s = %s\n", scale_i, pp)
@@ -193,13 +187,10 @@ func (p Polynomial) substitute(i int, p2 Polynomial) Polynomial {
 
 // CopyPolynomial makes a copy of a numeric Polynomial.
 func (p Polynomial) CopyPolynomial() Polynomial {
-	p1 := NewConstantPolynomial(0.0) // will become our return value
 	p.checkTerms()
-	it := p.Terms.Iterator()
-	for it.Next() { // copy all terms of p into p1
-		pos := it.Key().(int)
-		scale := it.Value().(float64)
-		p1.SetTerm(pos, scale)
+	p1 := Polynomial{terms: p.terms.copy()} // will become our return value
+	if _, ok := p1.terms.Get(0); !ok {
+		p1.terms.Put(0, 0.0) // copies always have a constant term
 	}
 	return p1
 }
@@ -211,10 +202,10 @@ func (p Polynomial) CopyPolynomial() Polynomial {
 func (p Polynomial) addOrSub(p2 Polynomial, doAdd bool, destructive bool) Polynomial {
 	p.checkTerms()
 	p1 := p.CopyPolynomial() // will become our return value
-	it2 := p2.Terms.Iterator()
+	it2 := p2.terms.Iterator()
 	for it2.Next() { // inspect all terms of p2
-		pos2 := it2.Key().(int)
-		scale2 := it2.Value().(float64)
+		pos2 := it2.Key()
+		scale2 := it2.Value()
 		if !arithm.Is0(scale2) {
 			scale1 := p1.GetCoeffForTerm(pos2)
 			if doAdd {
@@ -226,7 +217,7 @@ func (p Polynomial) addOrSub(p2 Polynomial, doAdd bool, destructive bool) Polyno
 		}
 	}
 	if destructive {
-		p.Terms = p1.Terms
+		p.terms = p1.terms
 	}
 	return p1
 }
@@ -277,14 +268,14 @@ func (p Polynomial) Multiply(p2 Polynomial, destructive bool) Polynomial {
 		}
 		p1 = p2 // swap to operate on p2
 	}
-	it := p1.Terms.Iterator()
+	it := p1.terms.Iterator()
 	for it.Next() { // multiply all coefficients by c
-		pos := it.Key().(int)
-		scale := it.Value().(float64)
+		pos := it.Key()
+		scale := it.Value()
 		p1.SetTerm(pos, arithm.Zap(scale*c))
 	}
 	if destructive {
-		p.Terms = p1.Terms
+		p.terms = p1.terms
 	}
 	p1 = p1.Zap()
 	return p1
@@ -298,8 +289,8 @@ func (p Polynomial) Divide(p2 Polynomial, destructive bool) Polynomial {
 	if !isconst || arithm.Is0(c) {
 		panic(fmt.Sprintf("illegal divisor: %s", p2.String()))
 	} else {
-		p2.Terms.Remove(0)
-		p2.Terms.Put(0, 1.0/c) // now p2 = 1/c
+		p2.terms.Remove(0)
+		p2.terms.Put(0, 1.0/c) // now p2 = 1/c
 	}
 	return p.Multiply(p2, destructive)
 }
@@ -307,16 +298,16 @@ func (p Polynomial) Divide(p2 Polynomial, destructive bool) Polynomial {
 // Zap eliminates all terms with coefficient=0 from a polynomial.
 func (p Polynomial) Zap() Polynomial {
 	p.checkTerms()
-	positions := p.Terms.Keys()     // all non-Zero terms of p
+	positions := p.terms.Keys()     // all non-Zero terms of p
 	for _, pos := range positions { // inspect terms
 		//if !(p.ispair && pos == 0) {
-		if scale, _ := p.Terms.Get(pos); arithm.Is0(scale.(float64)) {
-			p.Terms.Remove(pos) // may lose constant term c
+		if scale, _ := p.terms.Get(pos); arithm.Is0(scale) {
+			p.terms.Remove(pos) // may lose constant term c
 		}
 		//}
 	}
-	if _, ok := p.Terms.Get(0); !ok {
-		p.Terms.Put(0, 0.0) // set p = 0: re-introduce c
+	if _, ok := p.terms.Get(0); !ok {
+		p.terms.Put(0, 0.0) // set p = 0: re-introduce c
 	}
 	//T.Debugf("# Zapped: %s", p.String())
 	return p
@@ -327,12 +318,12 @@ func (p Polynomial) Zap() Polynomial {
 func (p Polynomial) IsConstant() (float64, bool) {
 	/*
 		if p.ispair {
-			return p.GetConstantPair().x, p.Terms.Size() == 1
+			return p.GetConstantPair().x, p.terms.Size() == 1
 		} else {
-			return p.GetCoeffForTerm(0), p.Terms.Size() == 1
+			return p.GetCoeffForTerm(0), p.terms.Size() == 1
 		}
 	*/
-	return p.GetCoeffForTerm(0), p.Terms.Size() == 1
+	return p.GetCoeffForTerm(0), p.terms.Size() == 1
 }
 
 // IsVariable checks wether
@@ -340,10 +331,10 @@ func (p Polynomial) IsConstant() (float64, bool) {
 // Returns the position of the term and a flag.
 func (p Polynomial) IsVariable() (int, bool) {
 	p.checkTerms()
-	if p.Terms.Size() == 2 { // ok: p = a*x.i + c
+	if p.terms.Size() == 2 { // ok: p = a*x.i + c
 		if arithm.Is0(p.GetCoeffForTerm(0)) { // if c == 0
-			positions := p.Terms.Keys() // all non-Zero Terms of p, ordered
-			pos := positions[1].(int)
+			positions := p.terms.Keys() // all non-Zero Terms of p, ordered
+			pos := positions[1]
 			a := p.GetCoeffForTerm(pos)
 			if arithm.Is1(a) { // if a.i = 0
 				return pos, true
@@ -355,7 +346,7 @@ func (p Polynomial) IsVariable() (int, bool) {
 
 // IsValid checks if this a correctly initialized polynomial.
 func (p Polynomial) IsValid() bool {
-	return (p.Terms != nil)
+	return (p.terms != nil)
 }
 
 // GetConstantValue returns the constant term of a polynomial.
@@ -371,16 +362,27 @@ func (p Polynomial) GetConstantValue() float64 {
 //    coeff(2) = 3
 //
 func (p Polynomial) GetCoeffForTerm(i int) float64 {
-	var sc interface{}
-	var found bool
 	p.checkTerms()
-	sc, found = p.Terms.Get(i)
-	if found {
-		return sc.(float64)
+	if sc, found := p.terms.Get(i); found {
+		return sc
 	}
 	return 0.0
 }
 
+// TermCount returns the number of terms of a polynomial, including the
+// constant term.
+func (p Polynomial) TermCount() int {
+	p.checkTerms()
+	return p.terms.Size()
+}
+
+// Positions returns the positions of all terms of a polynomial, in
+// ascending order. Position 0 is the constant term.
+func (p Polynomial) Positions() []int {
+	p.checkTerms()
+	return p.terms.Keys()
+}
+
 // ArityComparator is a
 // Comparator for polynomials. Polynomials are "smaller" if their arity
 // is smaller, i.e. they have less unknown variables.
@@ -388,16 +390,16 @@ func (p Polynomial) GetCoeffForTerm(i int) float64 {
 func ArityComparator(polyn1, polyn2 interface{}) int {
 	p1, _ := polyn1.(Polynomial)
 	p2, _ := polyn2.(Polynomial)
-	if p1.Terms == nil {
-		if p1.Terms == nil {
+	if p1.terms == nil {
+		if p1.terms == nil {
 			return 0
 		}
 		return -1
-	} else if p2.Terms == nil {
+	} else if p2.terms == nil {
 		return 1
 	}
-	T().Debugf("|p1| = %d, |p2| = %d", p1.Terms.Size(), p2.Terms.Size())
-	return p1.Terms.Size() - p2.Terms.Size()
+	T().Debugf("|p1| = %d, |p2| = %d", p1.terms.Size(), p2.terms.Size())
+	return p1.terms.Size() - p2.terms.Size()
 }
 
 // String creates a readable string representation for a Polynomial.
@@ -414,19 +416,19 @@ func (p Polynomial) String() string {
 func (p Polynomial) TraceString(resolv VariableResolver) string {
 	var buffer bytes.Buffer
 	p.checkTerms()
-	it := p.Terms.Iterator()
+	it := p.terms.Iterator()
 	var indent = false // no space before first term (usually constant)
 	for it.Next() {
-		pos := it.Key().(int)
+		pos := it.Key()
 		if pos == 0 { // constant term
 			/*
 				if p.ispair {
 					pc := it.Value().(Pair)
 				} else {
-					pc := it.Value().(float64).Round(3)
+					pc := it.Value().Round(3)
 				}
 			*/
-			pc := it.Value().(float64)
+			pc := it.Value()
 			if resolv == nil {
 				buffer.WriteString(fmt.Sprintf("{ %g } ", arithm.Round(pc)))
 			} else {
@@ -436,7 +438,7 @@ func (p Polynomial) TraceString(resolv VariableResolver) string {
 				}
 			}
 		} else { // variable term
-			scale := it.Value().(float64)
+			scale := it.Value()
 			if resolv == nil {
 				buffer.WriteString(fmt.Sprintf("{ %g x.%d } ",
 					arithm.Round(scale), pos))
@@ -0,0 +1,265 @@
+package polyn
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/npillmayer/arithm"
+)
+
+// === Exact Rational Arithmetic =============================================
+
+// RatPolynomial is a linear polynomial with exact rational coefficients
+//
+//	c + a.1 x.1 + a.2 x.2 + ... a.n x.n .
+//
+// Index 0 is the constant term. Terms with coefficient 0 are not stored.
+// RatPolynomials are used with a RatLinEqSolver, which solves systems of
+// linear equations without floating-point drift. This is useful for
+// verifying results of the floating-point LinEqSolver.
+type RatPolynomial struct {
+	Terms map[int]*big.Rat
+}
+
+// NewConstantRatPolynomial creates a RatPolynomial consisting of just a
+// constant term.
+func NewConstantRatPolynomial(c *big.Rat) RatPolynomial {
+	p := RatPolynomial{Terms: make(map[int]*big.Rat)}
+	return p.SetTerm(0, c)
+}
+
+// RatFromPolynomial converts a Polynomial to a RatPolynomial. Coefficients
+// are converted exactly, i.e. 0.1 will become 3602879701896397/36028797018963968.
+// Use NewConstantRatPolynomial and SetTerm to construct polynomials from
+// decimal fractions.
+func RatFromPolynomial(p Polynomial) RatPolynomial {
+	r := NewConstantRatPolynomial(new(big.Rat))
+	p.checkTerms()
+	it := p.terms.Iterator()
+	for it.Next() {
+		r.SetTerm(it.Key(), new(big.Rat).SetFloat64(it.Value()))
+	}
+	return r
+}
+
+// SetTerm sets the coefficient for a term a.i within a RatPolynomial.
+// For i=0, sets the constant term. The coefficient is copied.
+func (p RatPolynomial) SetTerm(i int, a *big.Rat) RatPolynomial {
+	if a.Sign() == 0 {
+		delete(p.Terms, i)
+	} else {
+		p.Terms[i] = new(big.Rat).Set(a)
+	}
+	return p
+}
+
+// GetCoeffForTerm gets the coefficient for term # i. The coefficient must
+// not be altered by clients.
+func (p RatPolynomial) GetCoeffForTerm(i int) *big.Rat {
+	if a, ok := p.Terms[i]; ok {
+		return a
+	}
+	return new(big.Rat)
+}
+
+// GetConstantValue returns the constant term of a RatPolynomial.
+func (p RatPolynomial) GetConstantValue() *big.Rat {
+	return p.GetCoeffForTerm(0)
+}
+
+// IsConstant checks wether a RatPolynomial is a constant, i.e. p = { c }?
+// Returns the constant and a flag.
+func (p RatPolynomial) IsConstant() (*big.Rat, bool) {
+	for i := range p.Terms {
+		if i != 0 {
+			return nil, false
+		}
+	}
+	return p.GetConstantValue(), true
+}
+
+// Copy makes a copy of a RatPolynomial.
+func (p RatPolynomial) Copy() RatPolynomial {
+	return p.Scaled(big.NewRat(1, 1))
+}
+
+// Add adds two RatPolynomials. Returns a new RatPolynomial.
+func (p RatPolynomial) Add(p2 RatPolynomial) RatPolynomial {
+	r := p.Copy()
+	for i, a := range p2.Terms {
+		r.SetTerm(i, new(big.Rat).Add(r.GetCoeffForTerm(i), a))
+	}
+	return r
+}
+
+// Subtract subtracts two RatPolynomials. Returns a new RatPolynomial.
+func (p RatPolynomial) Subtract(p2 RatPolynomial) RatPolynomial {
+	return p.Add(p2.Scaled(big.NewRat(-1, 1)))
+}
+
+// Scaled multiplies a RatPolynomial by a constant. Returns a new
+// RatPolynomial.
+func (p RatPolynomial) Scaled(a *big.Rat) RatPolynomial {
+	r := NewConstantRatPolynomial(new(big.Rat))
+	for i, b := range p.Terms {
+		r.SetTerm(i, new(big.Rat).Mul(a, b))
+	}
+	return r
+}
+
+// Float converts a RatPolynomial to a Polynomial, rounding coefficients to
+// the nearest float64.
+func (p RatPolynomial) Float() Polynomial {
+	q := NewConstantPolynomial(0)
+	for _, i := range p.positions() {
+		a, _ := p.Terms[i].Float64()
+		q.SetTerm(i, a)
+	}
+	return q
+}
+
+// String creates a readable string representation for a RatPolynomial.
+// Variables are printed as x.<n>.
+func (p RatPolynomial) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("{ ")
+	buffer.WriteString(p.GetConstantValue().RatString())
+	for _, i := range p.positions() {
+		if i == 0 {
+			continue
+		}
+		a := p.Terms[i]
+		if a.Sign() < 0 {
+			buffer.WriteString(fmt.Sprintf(" - %s x.%d", new(big.Rat).Neg(a).RatString(), i))
+		} else {
+			buffer.WriteString(fmt.Sprintf(" + %s x.%d", a.RatString(), i))
+		}
+	}
+	buffer.WriteString(" }")
+	return buffer.String()
+}
+
+// positions returns the positions of all terms of p, sorted.
+func (p RatPolynomial) positions() []int {
+	pos := make([]int, 0, len(p.Terms))
+	for i := range p.Terms {
+		pos = append(pos, i)
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// number converts p to a polynomial of ratNumbers.
+func (p RatPolynomial) number() numPolynomial {
+	q := make(numPolynomial, len(p.Terms))
+	for i, a := range p.Terms {
+		q.setTerm(i, ratNumber{new(big.Rat).Set(a)})
+	}
+	return q
+}
+
+// ratNumber is an exact rational arithm.Number, used for solving
+// RatPolynomials. Operands which are not ratNumbers are converted from
+// float64.
+type ratNumber struct {
+	r *big.Rat
+}
+
+var _ arithm.Number = ratNumber{}
+
+func rat(y arithm.Number) *big.Rat {
+	if q, ok := y.(ratNumber); ok {
+		return q.r
+	}
+	return new(big.Rat).SetFloat64(y.Float64())
+}
+
+func (x ratNumber) Add(y arithm.Number) arithm.Number {
+	return ratNumber{new(big.Rat).Add(x.r, rat(y))}
+}
+
+func (x ratNumber) Sub(y arithm.Number) arithm.Number {
+	return ratNumber{new(big.Rat).Sub(x.r, rat(y))}
+}
+
+func (x ratNumber) Mul(y arithm.Number) arithm.Number {
+	return ratNumber{new(big.Rat).Mul(x.r, rat(y))}
+}
+
+func (x ratNumber) Quo(y arithm.Number) arithm.Number {
+	return ratNumber{new(big.Rat).Quo(x.r, rat(y))}
+}
+
+func (x ratNumber) Neg() arithm.Number {
+	return ratNumber{new(big.Rat).Neg(x.r)}
+}
+
+func (x ratNumber) Sign() int {
+	return x.r.Sign()
+}
+
+func (x ratNumber) Float64() float64 {
+	f, _ := x.r.Float64()
+	return f
+}
+
+func (x ratNumber) String() string {
+	return x.r.RatString()
+}
+
+// --- Rational LEQ ----------------------------------------------------------
+
+// RatLinEqSolver is a container for linear equations with exact rational
+// coefficients. It solves systems of linear equations incrementally, in the
+// same way as LinEqSolver, but without floating-point drift.
+//
+// RatLinEqSolver does not support capsules, groups, transactions or any of
+// the other extensions of LinEqSolver.
+type RatLinEqSolver struct {
+	numSolver
+}
+
+// CreateRatLinEqSolver creates a new system of linear equations with exact
+// rational coefficients.
+func CreateRatLinEqSolver() *RatLinEqSolver {
+	return &RatLinEqSolver{numSolver: newNumSolver()}
+}
+
+// SetVariableResolver sets a variable resolver. Solved variables are reported
+// to the resolver as float64; use Value to get the exact value.
+func (leq *RatLinEqSolver) SetVariableResolver(resolver VariableResolver) {
+	leq.varresolver = resolver
+}
+
+// Value returns the exact value of x.i, if x.i is solved.
+func (leq *RatLinEqSolver) Value(i int) (*big.Rat, bool) {
+	c, ok := leq.solved[i]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Set(rat(c)), true
+}
+
+// AddEq adds a new equation 0 = p to a system of linear equations, and
+// solves the system as far as possible.
+//
+// If the equation contradicts the system, AddEq returns an *EquationError
+// wrapping ErrInconsistent, and the system is left unchanged. Equations
+// implied by the system are accepted without changing it.
+func (leq *RatLinEqSolver) AddEq(p RatPolynomial) error {
+	return leq.addEq(p.number())
+}
+
+// AddEqs adds a set of linear equations to the LEQ system. See AddEq.
+// Adding equations stops at the first equation which cannot be added,
+// returning its error.
+func (leq *RatLinEqSolver) AddEqs(plist []RatPolynomial) error {
+	for _, p := range plist {
+		if err := leq.AddEq(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package polyn
+
+import (
+	"errors"
+	"sort"
+)
+
+// === Retracting Equations ==================================================
+
+// EqHandle identifies an equation added to a LEQ. Handles are > 0.
+type EqHandle int
+
+// loggedEq is an equation as given by a client, together with its handle.
+type loggedEq struct {
+	h EqHandle
+	p Polynomial
+}
+
+// VariableUnsolver may be implemented by a VariableResolver, to be notified
+// of variables which are no longer solved after retracting an equation.
+type VariableUnsolver interface {
+	SetVariableUnsolved(int) // message: x.i is no longer solved
+}
+
+// ErrUnknownEquation is returned when retracting an equation which is not
+// part of the LEQ.
+var ErrUnknownEquation = errors.New("unknown equation")
+
+// logEq remembers an equation added by a client and returns its handle.
+func (leq *LinEqSolver) logEq(p Polynomial) EqHandle {
+	leq.lastHandle++
+	leq.equations = append(leq.equations, loggedEq{leq.lastHandle, p.CopyPolynomial()})
+	return leq.lastHandle
+}
+
+// Retract removes an equation from the LEQ. Dependencies and solutions of
+// the variables connected to the equation (by sharing variables with it,
+// transitively) are re-calculated from the remaining equations, in the order
+// they have been added. Variables whose state changed are reported: newly
+// solved variables and changed solutions to the VariableResolver, variables
+// which are no longer solved to the VariableResolver, if it implements
+// VariableUnsolver, and changed dependencies to OnDependencyChanged
+// listeners.
+//
+// Equations which involve capsules may have been removed from the LEQ
+// already. They are re-added nevertheless, with capsules being removed once
+// all remaining equations have been added.
+//
+// If re-calculating fails, which may happen for equations fitted by least
+// squares (see SetSlack), the error is returned and the LEQ is left
+// unchanged.
+func (leq *LinEqSolver) Retract(h EqHandle) error {
+	k := -1
+	for i, eq := range leq.equations {
+		if eq.h == h {
+			k = i
+			break
+		}
+	}
+	if k < 0 {
+		return leq.retractQueued(h)
+	}
+	p := leq.equations[k].p
+	T().P("op", "retract").Infof("0 = %s", leq.PolynString(p))
+	txn := leq.Begin()
+	eqs := make([]loggedEq, 0, len(leq.equations)-1)
+	eqs = append(eqs, leq.equations[:k]...)
+	leq.equations = append(eqs, leq.equations[k+1:]...)
+	vars := make(map[int]bool)
+	addVars(p, vars)
+	if err := leq.rebuild(vars, false); err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+// retractQueued removes an equation queued in deferred mode.
+func (leq *LinEqSolver) retractQueued(h EqHandle) error {
+	for k, eq := range leq.queue {
+		if eq.h == h {
+			queue := make([]loggedEq, 0, len(leq.queue)-1)
+			queue = append(queue, leq.queue[:k]...)
+			leq.queue = append(queue, leq.queue[k+1:]...)
+			return nil
+		}
+	}
+	return ErrUnknownEquation
+}
+
+// rebuild re-calculates dependencies and solutions of the variables
+// connected to vars, from the equations added by clients. Variables of other
+// equations are not affected. If parameter cont is true, another equation is
+// expected immediately after and capsules are not harvested. Variables whose
+// state changed are reported (see Retract). Returns the first error of
+// re-adding an equation.
+func (leq *LinEqSolver) rebuild(vars map[int]bool, cont bool) error {
+	eqs := connected(leq.equations, vars, nil)
+	was := make(map[int]varState, len(vars))
+	for i := range vars {
+		was[i] = leq.varState(i)
+	}
+	leq.muted = true
+	err := leq.replay(eqs, vars)
+	if !cont {
+		leq.harvestCapsules()
+	}
+	leq.muted = false
+	if err != nil {
+		return err
+	}
+	positions := make([]int, 0, len(vars))
+	for i := range vars {
+		positions = append(positions, i)
+	}
+	sort.Ints(positions)
+	for _, i := range positions {
+		leq.notifyChange(i, was[i], leq.varState(i))
+	}
+	return nil
+}
+
+// varState is the state of a variable of a LEQ: solved with x.i = p,
+// dependent with x.i = p(i), or free, with p invalid.
+type varState struct {
+	solved bool
+	p      Polynomial
+}
+
+func (leq *LinEqSolver) varState(i int) varState {
+	if p, found := leq.solved.Get(i); found {
+		return varState{solved: true, p: p.(Polynomial)}
+	}
+	if p, found := leq.dependents.Get(i); found {
+		return varState{p: p.(Polynomial)}
+	}
+	return varState{}
+}
+
+// notifyChange reports a change of the state of x.i, if any.
+func (leq *LinEqSolver) notifyChange(i int, was, is varState) {
+	if was.solved == is.solved && was.p.IsValid() == is.p.IsValid() &&
+		(!is.p.IsValid() || sameTerms(was.p, is.p)) {
+		return // unchanged
+	}
+	if is.solved {
+		leq.notify(notification{i: i, c: is.p.GetConstantValue()})
+		return
+	}
+	if was.solved {
+		leq.notify(notification{i: i, unsolved: true})
+	}
+	if is.p.IsValid() {
+		leq.notify(notification{i: i, p: is.p})
+	}
+}
@@ -0,0 +1,256 @@
+package polyn
+
+import (
+	"math"
+
+	"github.com/npillmayer/arithm"
+)
+
+// === Sparse Elimination ====================================================
+
+// SparseLinEqSolver solves systems of linear equations by sparse Gaussian
+// elimination with Markowitz pivoting. It is an alternative to LinEqSolver
+// for large systems: equations are queued by AddEq and eliminated together
+// by Solve, instead of substituting every new equation into all
+// dependencies, one at a time.
+//
+// Solve may be called repeatedly. Equations added in between are reduced by
+// the pivot rows of earlier calls, thus the elimination stays incremental.
+//
+// SparseLinEqSolver does not support capsules, groups, transactions or any
+// of the other extensions of LinEqSolver.
+type SparseLinEqSolver struct {
+	pivots      []pivotRow       // eliminated equations, in pivot order
+	pending     []Polynomial     // equations not yet eliminated
+	solved      map[int]float64  // map x.i => numeric
+	varresolver VariableResolver // to resolve variable names from term positions
+}
+
+// pivotRow is an equation 0 = row, which has been solved for x.col.
+// A pivot row does not contain the pivot variables of preceding rows.
+type pivotRow struct {
+	col int
+	row *termList
+}
+
+// activeRow is an equation 0 = row during elimination, together with the
+// equation as given by the client.
+type activeRow struct {
+	row *termList
+	eq  Polynomial
+}
+
+// markowitzThreshold is the minimum size of a pivot, relative to the
+// largest coefficient of its row. Smaller coefficients are not considered
+// for pivoting, for numerical stability.
+const markowitzThreshold = 0.1
+
+// CreateSparseLinEqSolver creates a new system of linear equations, to be
+// solved by sparse elimination.
+func CreateSparseLinEqSolver() *SparseLinEqSolver {
+	return &SparseLinEqSolver{
+		solved: make(map[int]float64),
+	}
+}
+
+// SetVariableResolver sets a variable resolver. See LinEqSolver.
+func (s *SparseLinEqSolver) SetVariableResolver(resolver VariableResolver) {
+	s.varresolver = resolver
+}
+
+// AddEq queues a new equation 0 = p. It will be solved by the next call
+// to Solve.
+func (s *SparseLinEqSolver) AddEq(p Polynomial) {
+	s.pending = append(s.pending, p.CopyPolynomial())
+}
+
+// AddEqs queues a set of linear equations. See AddEq.
+func (s *SparseLinEqSolver) AddEqs(plist []Polynomial) {
+	for _, p := range plist {
+		s.AddEq(p)
+	}
+}
+
+// Value returns the value of x.i, if x.i is solved.
+func (s *SparseLinEqSolver) Value(i int) (float64, bool) {
+	c, ok := s.solved[i]
+	return c, ok
+}
+
+// Solve eliminates all queued equations and reports newly solved variables
+// to the VariableResolver.
+//
+// Equations which contradict the system are dropped. Solve continues with
+// the remaining equations and returns an *EquationError wrapping
+// ErrInconsistent for the first of them.
+func (s *SparseLinEqSolver) Solve() error {
+	rows := make([]activeRow, 0, len(s.pending))
+	for _, p := range s.pending { // reduce new equations by existing pivots
+		r := p.terms.copy()
+		for _, pr := range s.pivots {
+			r = eliminate(r, pr)
+		}
+		rows = append(rows, activeRow{row: r, eq: p})
+	}
+	s.pending = nil
+	var err error
+	for {
+		var e error
+		if rows, e = s.dropConstant(rows); e != nil && err == nil {
+			err = e
+		}
+		if len(rows) == 0 {
+			break
+		}
+		k, col := markowitzPivot(rows)
+		pr := pivotRow{col: col, row: rows[k].row}
+		T().P("op", "pivot").Debugf("pivot on %s", TraceStringVar(col, s.varresolver))
+		rows = append(rows[:k], rows[k+1:]...)
+		for j := range rows {
+			rows[j].row = eliminate(rows[j].row, pr)
+		}
+		s.pivots = append(s.pivots, pr)
+	}
+	s.backSubstitute()
+	return err
+}
+
+// dropConstant removes all equations without variables from a set of
+// active rows. If one of them is off, an error is returned for the first
+// one.
+func (s *SparseLinEqSolver) dropConstant(rows []activeRow) ([]activeRow, error) {
+	var err error
+	k := 0
+	for _, r := range rows {
+		if r.row.Size() > 1 || (r.row.Size() == 1 && r.row.terms[0].i != 0) {
+			rows[k] = r
+			k++
+			continue
+		}
+		c, _ := r.row.Get(0)
+		if arithm.Is0(c) {
+			T().P("op", "new equation").Infof("redundant equation: 0 = %s", r.eq.TraceString(s.varresolver))
+		} else if err == nil {
+			T().P("op", "new equation").Errorf("%v: 0 = %s", ErrInconsistent, r.eq.TraceString(s.varresolver))
+			err = &EquationError{Err: ErrInconsistent, Eq: r.eq, Off: c}
+		}
+	}
+	return rows[:k], err
+}
+
+// markowitzPivot selects a pivot from a set of active rows. The pivot is
+// the coefficient a.c of a row r with minimum cost (|r|-1)⋅(|c|-1), where
+// |r| is the number of variables in r and |c| is the number of rows
+// containing x.c. Coefficients below the Markowitz threshold are not
+// considered. Returns the index of the row and the pivot variable.
+func markowitzPivot(rows []activeRow) (int, int) {
+	colcount := make(map[int]int)
+	for _, r := range rows {
+		for _, t := range r.row.terms {
+			if t.i != 0 {
+				colcount[t.i]++
+			}
+		}
+	}
+	pivrow, pivcol := -1, 0
+	var mincost int
+	var maxa float64
+	for k, r := range rows {
+		nvars, rowmax := 0, 0.0
+		for _, t := range r.row.terms {
+			if t.i != 0 {
+				nvars++
+				rowmax = math.Max(rowmax, math.Abs(t.a))
+			}
+		}
+		for _, t := range r.row.terms {
+			a := math.Abs(t.a)
+			if t.i == 0 || a < markowitzThreshold*rowmax {
+				continue
+			}
+			cost := (nvars - 1) * (colcount[t.i] - 1)
+			if pivrow < 0 || cost < mincost || (cost == mincost && a > maxa) {
+				pivrow, pivcol, mincost, maxa = k, t.i, cost, a
+			}
+		}
+	}
+	return pivrow, pivcol
+}
+
+// eliminate removes the pivot variable of a pivot row from row r, by adding
+// a multiple of the pivot row. Returns the resulting row.
+func eliminate(r *termList, pr pivotRow) *termList {
+	a, found := r.Get(pr.col)
+	if !found {
+		return r
+	}
+	apiv, _ := pr.row.Get(pr.col)
+	r = combine(r, -a/apiv, pr.row)
+	r.Remove(pr.col) // avoid rounding residues
+	return r
+}
+
+// combine calculates x + f⋅y for two rows x and y. Coefficients of variables
+// which become 0 are dropped, the constant term is kept.
+func combine(x *termList, f float64, y *termList) *termList {
+	r := &termList{terms: make([]term, 0, len(x.terms)+len(y.terms))}
+	k, l := 0, 0
+	for k < len(x.terms) || l < len(y.terms) {
+		var t term
+		switch {
+		case l == len(y.terms) || (k < len(x.terms) && x.terms[k].i < y.terms[l].i):
+			t = x.terms[k]
+			k++
+		case k == len(x.terms) || y.terms[l].i < x.terms[k].i:
+			t = term{y.terms[l].i, f * y.terms[l].a}
+			l++
+		default:
+			t = term{x.terms[k].i, x.terms[k].a + f*y.terms[l].a}
+			k++
+			l++
+		}
+		if t.i == 0 || !arithm.Is0(t.a) {
+			r.terms = append(r.terms, t)
+		}
+	}
+	return r
+}
+
+// backSubstitute calculates the values of pivot variables, which depend on
+// solved variables only. Pivot rows are processed in reverse order, as
+// every pivot row contains only pivot variables of subsequent rows.
+func (s *SparseLinEqSolver) backSubstitute() {
+	for k := len(s.pivots) - 1; k >= 0; k-- {
+		pr := s.pivots[k]
+		if _, ok := s.solved[pr.col]; ok {
+			continue
+		}
+		var sum, apiv float64
+		determined := true
+		for _, t := range pr.row.terms {
+			if t.i == 0 {
+				sum += t.a
+			} else if t.i == pr.col {
+				apiv = t.a
+			} else if v, ok := s.solved[t.i]; ok {
+				sum += t.a * v
+			} else {
+				determined = false
+				break
+			}
+		}
+		if determined { // 0 = a.col x.col + sum
+			s.setSolved(pr.col, arithm.Round(-sum/apiv))
+		}
+	}
+}
+
+// setSolved stores the value of x.i and notifies the variable resolver.
+func (s *SparseLinEqSolver) setSolved(i int, c float64) {
+	T().P("var", TraceStringVar(i, s.varresolver)).Infof("#### %s = %g",
+		TraceStringVar(i, s.varresolver), c)
+	s.solved[i] = c
+	if s.varresolver != nil {
+		s.varresolver.SetVariableSolved(i, c)
+	}
+}
@@ -0,0 +1,119 @@
+package polyn
+
+import "sort"
+
+// === Terms of Polynomials ==================================================
+
+// term is a single term a.i x.i of a polynomial. For i=0, a is the constant.
+type term struct {
+	i int     // position of variable x.i
+	a float64 // coefficient a.i
+}
+
+// termList holds the terms of a Polynomial, sorted by position. Positions
+// are unique.
+//
+// termList replaces a general purpose sorted map, which boxed coefficients
+// into interfaces and allocated iterators. Terms are held in a slice, found
+// by binary search and iterated without allocation.
+type termList struct {
+	terms []term
+}
+
+func newTermList() *termList {
+	return &termList{terms: make([]term, 0, 4)}
+}
+
+// Size returns the number of terms.
+func (tl *termList) Size() int {
+	return len(tl.terms)
+}
+
+// find returns the index of term x.i, or the index to insert x.i at, if
+// not present.
+func (tl *termList) find(i int) (int, bool) {
+	k := sort.Search(len(tl.terms), func(k int) bool { return tl.terms[k].i >= i })
+	return k, k < len(tl.terms) && tl.terms[k].i == i
+}
+
+// Get returns the coefficient of term x.i and true, or 0 and false if
+// there is no such term.
+func (tl *termList) Get(i int) (float64, bool) {
+	if k, found := tl.find(i); found {
+		return tl.terms[k].a, true
+	}
+	return 0, false
+}
+
+// Put inserts term a.i x.i or replaces its coefficient.
+func (tl *termList) Put(i int, a float64) {
+	k, found := tl.find(i)
+	if found {
+		tl.terms[k].a = a
+		return
+	}
+	tl.terms = append(tl.terms, term{})
+	copy(tl.terms[k+1:], tl.terms[k:])
+	tl.terms[k] = term{i, a}
+}
+
+// Remove removes term x.i, if present.
+func (tl *termList) Remove(i int) {
+	if k, found := tl.find(i); found {
+		tl.terms = append(tl.terms[:k], tl.terms[k+1:]...)
+	}
+}
+
+// Keys returns the positions of all terms, in ascending order.
+func (tl *termList) Keys() []int {
+	keys := make([]int, len(tl.terms))
+	for k, t := range tl.terms {
+		keys[k] = t.i
+	}
+	return keys
+}
+
+// copy creates a copy of a termList.
+func (tl *termList) copy() *termList {
+	c := &termList{terms: make([]term, len(tl.terms), len(tl.terms)+1)}
+	copy(c.terms, tl.terms)
+	return c
+}
+
+// Iterator returns an iterator over all terms, in ascending order of
+// positions. The coefficients of terms may be altered while iterating, but
+// terms must not be inserted or removed.
+//
+// Use it as
+//
+//	it := p.terms.Iterator()
+//	for it.Next() {
+//	    i, a := it.Key(), it.Value()
+//	    ...
+//	}
+func (tl *termList) Iterator() termIterator {
+	return termIterator{tl: tl, k: -1}
+}
+
+// termIterator iterates over the terms of a termList.
+type termIterator struct {
+	tl *termList
+	k  int
+}
+
+// Next moves the iterator to the next term and returns true, if there is
+// one.
+func (it *termIterator) Next() bool {
+	it.k++
+	return it.k < len(it.tl.terms)
+}
+
+// Key returns the position of the current term.
+func (it *termIterator) Key() int {
+	return it.tl.terms[it.k].i
+}
+
+// Value returns the coefficient of the current term.
+func (it *termIterator) Value() float64 {
+	return it.tl.terms[it.k].a
+}
@@ -0,0 +1,157 @@
+package polyn
+
+import (
+	"errors"
+
+	"github.com/emirpasic/gods/maps/treemap"
+)
+
+// === Transactions ==========================================================
+
+// Txn is a transaction on a LEQ. Equations added after Begin may be
+// discarded by Rollback, restoring the LEQ to its state at the start of the
+// transaction. This allows to try equations speculatively, e.g. while
+// parsing a figure, and to drop them if a later equation conflicts.
+//
+// While a transaction is open, notifications of solved variables are held
+// back and delivered to the VariableResolver on Commit of the outermost
+// transaction. Rollback discards them.
+type Txn struct {
+	leq          *LinEqSolver
+	dependents   *treemap.Map
+	solved       *treemap.Map
+	capsules     map[int]bool
+	groups       [][]int
+	nextWhatever int
+	equations    []loggedEq
+	bounds       map[int]bound
+	deferred     bool
+	queue        []loggedEq
+	pending      int  // number of held back notifications at start
+	done         bool // committed or rolled back
+}
+
+// ErrTxnOrder is returned when committing or rolling back a transaction
+// which is not the innermost open transaction.
+var ErrTxnOrder = errors.New("transaction is not the innermost open transaction")
+
+// Begin starts a transaction. Transactions may be nested.
+//
+// Equations are never altered after having been stored in the LEQ, thus
+// Begin remembers the current equations without copying them.
+func (leq *LinEqSolver) Begin() *Txn {
+	txn := &Txn{
+		leq:          leq,
+		dependents:   copyMap(leq.dependents),
+		solved:       copyMap(leq.solved),
+		capsules:     make(map[int]bool, len(leq.capsules)),
+		groups:       make([][]int, len(leq.groups)),
+		nextWhatever: leq.nextWhatever,
+		equations:    leq.equations,
+		bounds:       make(map[int]bound, len(leq.bounds)),
+		deferred:     leq.deferred,
+		queue:        leq.queue[:len(leq.queue):len(leq.queue)],
+		pending:      len(leq.pending),
+	}
+	for i := range leq.capsules {
+		txn.capsules[i] = true
+	}
+	for i, b := range leq.bounds {
+		txn.bounds[i] = b
+	}
+	for k, g := range leq.groups {
+		txn.groups[k] = append([]int(nil), g...)
+	}
+	leq.txns = append(leq.txns, txn)
+	return txn
+}
+
+// Commit ends a transaction and keeps all equations added within it. On
+// Commit of the outermost transaction, held back notifications are delivered
+// to the VariableResolver.
+func (txn *Txn) Commit() error {
+	leq := txn.leq
+	if err := txn.end(); err != nil {
+		return err
+	}
+	if len(leq.txns) == 0 {
+		pending := leq.pending
+		leq.pending = nil
+		for _, msg := range pending {
+			leq.deliver(msg)
+		}
+	}
+	return nil
+}
+
+// Rollback ends a transaction and restores the LEQ to its state at the
+// start of the transaction.
+func (txn *Txn) Rollback() error {
+	leq := txn.leq
+	if err := txn.end(); err != nil {
+		return err
+	}
+	leq.dependents, leq.solved = txn.dependents, txn.solved
+	leq.capsules, leq.groups = txn.capsules, txn.groups
+	leq.nextWhatever = txn.nextWhatever
+	leq.equations = txn.equations
+	leq.bounds = txn.bounds
+	leq.deferred, leq.queue = txn.deferred, txn.queue
+	leq.pending = leq.pending[:txn.pending]
+	return nil
+}
+
+// end checks that txn is the innermost open transaction and closes it.
+func (txn *Txn) end() error {
+	leq := txn.leq
+	if txn.done || len(leq.txns) == 0 || leq.txns[len(leq.txns)-1] != txn {
+		return ErrTxnOrder
+	}
+	txn.done = true
+	leq.txns = leq.txns[:len(leq.txns)-1]
+	return nil
+}
+
+// Clone creates a copy of a LEQ, which may be altered independently of
+// the original. This allows search-style clients to branch on alternative
+// sets of equations.
+//
+// Equations are never altered after having been stored in the LEQ, thus the
+// clone shares them with the original. The clone shares the
+// VariableResolver and redundancy handler as well; use SetVariableResolver
+// to separate notifications of solved variables. Open transactions of the
+// original are not cloned, and notifications held back by them will be
+// delivered by the original only.
+func (leq *LinEqSolver) Clone() *LinEqSolver {
+	c := *leq
+	c.dependents, c.solved = copyMap(leq.dependents), copyMap(leq.solved)
+	c.capsules = make(map[int]bool, len(leq.capsules))
+	for i := range leq.capsules {
+		c.capsules[i] = true
+	}
+	c.groups = make([][]int, len(leq.groups))
+	for k, g := range leq.groups {
+		c.groups[k] = append([]int(nil), g...)
+	}
+	c.bounds = make(map[int]bound, len(leq.bounds))
+	for i, b := range leq.bounds {
+		c.bounds[i] = b
+	}
+	// appending to the clone must not overwrite entries of the original
+	c.equations = leq.equations[:len(leq.equations):len(leq.equations)]
+	c.queue = leq.queue[:len(leq.queue):len(leq.queue)]
+	c.onsolved = leq.onsolved[:len(leq.onsolved):len(leq.onsolved)]
+	c.ondependency = leq.ondependency[:len(leq.ondependency):len(leq.ondependency)]
+	c.txns, c.pending = nil, nil
+	return &c
+}
+
+// copyMap creates a shallow copy of a map of equations.
+func copyMap(m *treemap.Map) *treemap.Map {
+	c := treemap.NewWithIntComparator()
+	it := m.Iterator()
+	for it.Next() {
+		c.Put(it.Key(), it.Value())
+	}
+	return c
+}
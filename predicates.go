@@ -0,0 +1,107 @@
+package arithm
+
+import (
+	"math"
+	"math/big"
+)
+
+// === Geometric Predicates ==================================================
+
+// Error bounds for the floating point filters, from J.R. Shewchuk: "Adaptive
+// Precision Floating-Point Arithmetic and Fast Robust Geometric Predicates".
+const (
+	machEps       = 1.0 / (1 << 53)
+	ccwErrBound   = (3 + 16*machEps) * machEps
+	iccErrBound   = (10 + 96*machEps) * machEps
+	predicateTiny = math.SmallestNonzeroFloat64
+)
+
+// Orient2D tells the orientation of the points a, b and c. It returns a
+// positive value if the points are arranged counter-clockwise (with the
+// y-axis pointing upwards), a negative value if they are arranged clockwise,
+// and 0 if they are collinear. The value approximates twice the signed area
+// of the triangle abc.
+//
+// Contrary to tests using Epsilon, the sign of the result is always correct.
+// The result is calculated with floating point arithmetic first; only if the
+// rounding error could affect the sign, it is re-calculated with exact
+// arithmetic (as in Shewchuk's adaptive predicates, with a single exact
+// stage).
+func Orient2D(a, b, c Pair) float64 {
+	detleft := (a.X() - c.X()) * (b.Y() - c.Y())
+	detright := (a.Y() - c.Y()) * (b.X() - c.X())
+	det := detleft - detright
+	detsum := math.Abs(detleft) + math.Abs(detright)
+	if math.Abs(det) > ccwErrBound*detsum || (detsum == 0 && det == 0) {
+		return det
+	}
+	ax, ay, bx, by, cx, cy := rats(a, b, c)
+	l := mul(sub(ax, cx), sub(by, cy))
+	r := mul(sub(ay, cy), sub(bx, cx))
+	return approx(sub(l, r), det)
+}
+
+// InCircle tells the position of point d relative to the circle through a,
+// b and c, where a, b and c have to be arranged counter-clockwise. It returns
+// a positive value if d lies inside the circle, a negative value if d lies
+// outside, and 0 if the four points are cocircular. For a, b and c arranged
+// clockwise, the sign is reversed.
+//
+// As with Orient2D, the sign of the result is always correct.
+func InCircle(a, b, c, d Pair) float64 {
+	adx, ady := a.X()-d.X(), a.Y()-d.Y()
+	bdx, bdy := b.X()-d.X(), b.Y()-d.Y()
+	cdx, cdy := c.X()-d.X(), c.Y()-d.Y()
+	alift, blift, clift := adx*adx+ady*ady, bdx*bdx+bdy*bdy, cdx*cdx+cdy*cdy
+	det := alift*(bdx*cdy-cdx*bdy) + blift*(cdx*ady-adx*cdy) + clift*(adx*bdy-bdx*ady)
+	permanent := (math.Abs(bdx*cdy)+math.Abs(cdx*bdy))*alift +
+		(math.Abs(cdx*ady)+math.Abs(adx*cdy))*blift +
+		(math.Abs(adx*bdy)+math.Abs(bdx*ady))*clift
+	if math.Abs(det) > iccErrBound*permanent || (permanent == 0 && det == 0) {
+		return det
+	}
+	ax, ay, bx, by, cx, cy := rats(a, b, c)
+	dx, dy := rat(d.X()), rat(d.Y())
+	eax, eay := sub(ax, dx), sub(ay, dy)
+	ebx, eby := sub(bx, dx), sub(by, dy)
+	ecx, ecy := sub(cx, dx), sub(cy, dy)
+	lift := func(x, y *big.Rat) *big.Rat { return add(mul(x, x), mul(y, y)) }
+	exact := add(add(
+		mul(lift(eax, eay), sub(mul(ebx, ecy), mul(ecx, eby))),
+		mul(lift(ebx, eby), sub(mul(ecx, eay), mul(eax, ecy)))),
+		mul(lift(ecx, ecy), sub(mul(eax, eby), mul(ebx, eay))))
+	return approx(exact, det)
+}
+
+// --- Exact arithmetic ------------------------------------------------------
+
+func rat(x float64) *big.Rat {
+	return new(big.Rat).SetFloat64(x)
+}
+
+func rats(a, b, c Pair) (ax, ay, bx, by, cx, cy *big.Rat) {
+	return rat(a.X()), rat(a.Y()), rat(b.X()), rat(b.Y()), rat(c.X()), rat(c.Y())
+}
+
+func add(x, y *big.Rat) *big.Rat { return new(big.Rat).Add(x, y) }
+func sub(x, y *big.Rat) *big.Rat { return new(big.Rat).Sub(x, y) }
+func mul(x, y *big.Rat) *big.Rat { return new(big.Rat).Mul(x, y) }
+
+// approx converts an exact result to a float with the correct sign. If the
+// exact value is too small to be represented, the smallest float of the
+// correct sign is returned. The floating point estimate is used if it has the
+// correct sign.
+func approx(exact *big.Rat, estimate float64) float64 {
+	sign := exact.Sign()
+	if sign == 0 {
+		return 0
+	}
+	if (estimate > 0 && sign > 0) || (estimate < 0 && sign < 0) {
+		return estimate
+	}
+	f, _ := exact.Float64()
+	if f == 0 {
+		f = math.Copysign(predicateTiny, float64(sign))
+	}
+	return f
+}
@@ -0,0 +1,68 @@
+package arithm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestOrient2D(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	if o := Orient2D(P(0, 0), P(1, 0), P(0, 1)); o != 1 {
+		t.Errorf("Expected counter-clockwise orientation 1, is %g", o)
+	}
+	if o := Orient2D(P(0, 0), P(0, 1), P(1, 0)); o >= 0 {
+		t.Errorf("Expected clockwise orientation, is %g", o)
+	}
+	if o := Orient2D(P(1, 1), P(2, 2), P(3, 3)); o != 0 {
+		t.Errorf("Expected collinear points to have orientation 0, is %g", o)
+	}
+	// nearly collinear points, for which naive floating point evaluation fails
+	// (cf. Kettner et al.: "Classroom Examples of Robustness Problems")
+	b, c := P(12, 12), P(24, 24)
+	failures := 0
+	for i := 0; i < 256; i++ {
+		a := P(0.5+float64(i%16)*math.Pow(2, -53), 0.5+float64(i/16)*math.Pow(2, -53))
+		ax, ay, bx, by, cx, cy := rats(a, b, c)
+		exact := sub(mul(sub(ax, cx), sub(by, cy)), mul(sub(ay, cy), sub(bx, cx))).Sign()
+		naive := (a.X()-c.X())*(b.Y()-c.Y()) - (a.Y()-c.Y())*(b.X()-c.X())
+		if sign(naive) != exact {
+			failures++
+		}
+		if s := sign(Orient2D(a, b, c)); s != exact {
+			t.Errorf("Expected orientation sign %d for %v, is %d", exact, a, s)
+		}
+	}
+	if failures == 0 {
+		t.Errorf("Expected naive evaluation to fail for some of the test points")
+	}
+}
+
+func TestInCircle(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	a, b, c := P(1, 0), P(0, 1), P(-1, 0)
+	if InCircle(a, b, c, P(0, 0)) <= 0 || InCircle(a, b, c, P(2, 0)) >= 0 {
+		t.Errorf("Expected origin inside and (2,0) outside of unit circle")
+	}
+	if ic := InCircle(a, b, c, P(0, -1)); ic != 0 {
+		t.Errorf("Expected cocircular points to result in 0, is %g", ic)
+	}
+	if InCircle(c, b, a, P(0, 0)) >= 0 {
+		t.Errorf("Expected reversed sign for clockwise points")
+	}
+	if ic := InCircle(a, b, c, P(0, math.Nextafter(-1, 0))); ic <= 0 {
+		t.Errorf("Expected point just inside of unit circle to be inside, is %g", ic)
+	}
+}
+
+func sign(x float64) int {
+	if x > 0 {
+		return 1
+	} else if x < 0 {
+		return -1
+	}
+	return 0
+}
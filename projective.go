@@ -0,0 +1,133 @@
+package arithm
+
+import (
+	"fmt"
+	"math"
+)
+
+// === Projective Transformations ============================================
+
+// PT is a projective (perspective) transform. As AT, it is a 3x3 matrix
+// flattened by rows, but its last row is not restricted to (0,0,1). Points
+// are transformed in homogeneous coordinates and divided by w afterwards.
+type PT []float64
+
+// Projective returns an affine transform as a projective one.
+func (m AT) Projective() PT {
+	p := make(PT, 9)
+	copy(p, m)
+	return p
+}
+
+// NewPT creates a projective transform from the rows of its matrix, i.e.
+// a point (x,y) will be mapped to
+//
+//	((a⋅x + b⋅y + c)/w, (d⋅x + e⋅y + f)/w),  w = g⋅x + h⋅y + i
+func NewPT(a, b, c, d, e, f, g, h, i float64) PT {
+	return PT{a, b, c, d, e, f, g, h, i}
+}
+
+// Perspective returns the projective transform which maps the corners of
+// quadrilateral src onto the corners of quadrilateral dst, e.g. for mapping
+// a figure onto a plane seen in perspective. If three corners of either
+// quadrilateral are collinear, Perspective returns ErrSingular.
+func Perspective(src, dst [4]Pair) (PT, error) {
+	s, err := squareToQuad(src)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	d, err := squareToQuad(dst)
+	if err != nil {
+		return nil, err
+	}
+	return inv.Combine(d), nil
+}
+
+// squareToQuad returns the projective transform mapping the unit square
+// (0,0), (1,0), (1,1), (0,1) onto a quadrilateral (Heckbert: "Fundamentals
+// of Texture Mapping and Image Warping", 1989).
+func squareToQuad(q [4]Pair) (PT, error) {
+	x0, y0, x1, y1 := q[0].X(), q[0].Y(), q[1].X(), q[1].Y()
+	x2, y2, x3, y3 := q[2].X(), q[2].Y(), q[3].X(), q[3].Y()
+	var g, h float64
+	if sx, sy := x0-x1+x2-x3, y0-y1+y2-y3; !Is0(sx) || !Is0(sy) {
+		dx1, dy1, dx2, dy2 := x1-x2, y1-y2, x3-x2, y3-y2
+		den := dx1*dy2 - dx2*dy1
+		if Is0(den) {
+			return nil, ErrSingular
+		}
+		g = (sx*dy2 - dx2*sy) / den
+		h = (dx1*sy - sx*dy1) / den
+	} // else parallelogram, affine mapping
+	a, b, d, e := x1-x0+g*x1, x3-x0+h*x3, y1-y0+g*y1, y3-y0+h*y3
+	det := a*(e-y0*h) - b*(d-y0*g) + x0*(d*h-e*g)
+	m := NewPT(a, b, x0, d, e, y0, g, h, 1)
+	if m.singular(det) { // three corners are collinear
+		return nil, ErrSingular
+	}
+	return m, nil
+}
+
+// Debug Stringer for a projective transform.
+func (m PT) String() string {
+	return fmt.Sprintf("[%g,%g,%g|%g,%g,%g|%g,%g,%g]",
+		m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7], m[8])
+}
+
+// Transform a 2D-point. Points mapped to infinity (w = 0) result in
+// infinite coordinates.
+func (m PT) Transform(p Pair) Pair {
+	x, y := p.X(), p.Y()
+	w := m[6]*x + m[7]*y + m[8]
+	return P((m[0]*x+m[1]*y+m[2])/w, (m[3]*x+m[4]*y+m[5])/w)
+}
+
+// Combine 2 projective transforms to a new one, which applies m first and
+// then n, as AT.Combine does.
+func (m PT) Combine(n PT) PT {
+	o := make(PT, 9)
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			o[row*3+col] = n[row*3]*m[col] + n[row*3+1]*m[3+col] + n[row*3+2]*m[6+col]
+		}
+	}
+	return o
+}
+
+// Inverse returns the inverse of a projective transform. If m is singular,
+// Inverse returns ErrSingular. The result is normalized such that its
+// last element is 1, if possible.
+func (m PT) Inverse() (PT, error) {
+	a, b, c, d, e, f, g, h, i := m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7], m[8]
+	adj := PT{ // adjugate matrix
+		e*i - f*h, c*h - b*i, b*f - c*e,
+		f*g - d*i, a*i - c*g, c*d - a*f,
+		d*h - e*g, b*g - a*h, a*e - b*d,
+	}
+	det := a*adj[0] + b*adj[3] + c*adj[6]
+	if m.singular(det) {
+		return nil, ErrSingular
+	}
+	norm := det
+	if !Is0(adj[8] / det) {
+		norm = adj[8]
+	}
+	for k := range adj {
+		adj[k] /= norm
+	}
+	return adj, nil
+}
+
+// singular is a predicate: is the determinant det of m zero, relative to
+// the magnitude of m's elements?
+func (m PT) singular(det float64) bool {
+	scale := 0.0
+	for _, x := range m {
+		scale = math.Max(scale, math.Abs(x))
+	}
+	return math.IsNaN(det) || math.Abs(det) <= Epsilon*scale*scale*scale
+}
@@ -0,0 +1,44 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestPerspective(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	src := [4]Pair{P(0, 0), P(2, 0), P(2, 2), P(0, 2)}
+	dst := [4]Pair{P(1, 1), P(5, 0), P(4, 3), P(2, 4)}
+	m, err := Perspective(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range src {
+		if p := m.Transform(src[i]); !p.Equal(dst[i]) {
+			t.Errorf("Expected corner %v to map to %v, is %v", src[i], dst[i], p)
+		}
+	}
+	inv, err := m.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := inv.Transform(m.Transform(P(0.5, 1.5))); !p.Equal(P(0.5, 1.5)) {
+		t.Errorf("Expected inverse to map point back to (0.5,1.5), is %v", p)
+	}
+	if _, err := Perspective(src, [4]Pair{P(0, 0), P(1, 0), P(2, 0), P(0, 1)}); err != ErrSingular {
+		t.Errorf("Expected degenerate quadrilateral to be rejected")
+	}
+}
+
+func TestProjectiveAffine(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	a := RotationAround(P(1, 2), 0.7)
+	b := Scaling(2, 3)
+	m := a.Projective().Combine(b.Projective())
+	if p, q := m.Transform(P(3, -1)), a.Combine(b).Transform(P(3, -1)); !p.Equal(q) {
+		t.Errorf("Expected projective combination to agree with affine one, %v ≠ %v", p, q)
+	}
+}
@@ -0,0 +1,118 @@
+package arithm
+
+import (
+	"fmt"
+	"math"
+)
+
+// === Rectangles ============================================================
+
+// Rect is an axis-aligned rectangle, given by its lower left corner Min and
+// its upper right corner Max (with the y-axis pointing upwards). A rectangle
+// with Min.X() > Max.X() or Min.Y() > Max.Y() is empty.
+type Rect struct {
+	Min, Max Pair
+}
+
+// NewRect creates a rectangle from two opposite corners, in any order.
+func NewRect(p1, p2 Pair) Rect {
+	return Rect{
+		Min: P(math.Min(p1.X(), p2.X()), math.Min(p1.Y(), p2.Y())),
+		Max: P(math.Max(p1.X(), p2.X()), math.Max(p1.Y(), p2.Y())),
+	}
+}
+
+// EmptyRect returns an empty rectangle. It is the neutral element of Union.
+func EmptyRect() Rect {
+	inf := math.Inf(1)
+	return Rect{Min: P(inf, inf), Max: P(-inf, -inf)}
+}
+
+// EnclosingRect returns the smallest rectangle containing all the given
+// points. For no points, the empty rectangle is returned.
+func EnclosingRect(pts ...Pair) Rect {
+	r := EmptyRect()
+	for _, p := range pts {
+		r = r.Union(Rect{Min: p, Max: p})
+	}
+	return r
+}
+
+// Pretty Stringer for rectangles.
+func (r Rect) String() string {
+	if r.IsEmpty() {
+		return "[empty]"
+	}
+	return fmt.Sprintf("[%v,%v]", r.Min, r.Max)
+}
+
+// IsEmpty is a predicate: does the rectangle contain no points?
+func (r Rect) IsEmpty() bool {
+	return !(r.Min.X() <= r.Max.X() && r.Min.Y() <= r.Max.Y())
+}
+
+// Width returns the width of a rectangle, or 0 if it is empty.
+func (r Rect) Width() float64 {
+	if r.IsEmpty() {
+		return 0
+	}
+	return r.Max.X() - r.Min.X()
+}
+
+// Height returns the height of a rectangle, or 0 if it is empty.
+func (r Rect) Height() float64 {
+	if r.IsEmpty() {
+		return 0
+	}
+	return r.Max.Y() - r.Min.Y()
+}
+
+// Union returns the smallest rectangle containing both r and s.
+func (r Rect) Union(s Rect) Rect {
+	if r.IsEmpty() {
+		return s
+	} else if s.IsEmpty() {
+		return r
+	}
+	return Rect{
+		Min: P(math.Min(r.Min.X(), s.Min.X()), math.Min(r.Min.Y(), s.Min.Y())),
+		Max: P(math.Max(r.Max.X(), s.Max.X()), math.Max(r.Max.Y(), s.Max.Y())),
+	}
+}
+
+// Intersect returns the intersection of r and s, which may be empty.
+func (r Rect) Intersect(s Rect) Rect {
+	i := Rect{
+		Min: P(math.Max(r.Min.X(), s.Min.X()), math.Max(r.Min.Y(), s.Min.Y())),
+		Max: P(math.Min(r.Max.X(), s.Max.X()), math.Min(r.Max.Y(), s.Max.Y())),
+	}
+	if i.IsEmpty() {
+		return EmptyRect()
+	}
+	return i
+}
+
+// Contains is a predicate: is p inside of r or on its border?
+func (r Rect) Contains(p Pair) bool {
+	return r.Min.X() <= p.X() && p.X() <= r.Max.X() && r.Min.Y() <= p.Y() && p.Y() <= r.Max.Y()
+}
+
+// Inset returns a rectangle shrunk by d at every side. Negative values of d
+// enlarge the rectangle. If the rectangle shrinks to nothing, the empty
+// rectangle is returned.
+func (r Rect) Inset(d float64) Rect {
+	if r.IsEmpty() {
+		return r
+	}
+	i := Rect{Min: P(r.Min.X()+d, r.Min.Y()+d), Max: P(r.Max.X()-d, r.Max.Y()-d)}
+	if i.IsEmpty() {
+		return EmptyRect()
+	}
+	return i
+}
+
+// Corners returns the corners of a rectangle, counter-clockwise and starting
+// with the lower left corner.
+func (r Rect) Corners() [4]Pair {
+	return [4]Pair{r.Min, P(r.Max.X(), r.Min.Y()), r.Max, P(r.Min.X(), r.Max.Y())}
+}
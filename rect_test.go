@@ -0,0 +1,44 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestRect(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	r := NewRect(P(4, 0), P(0, 2))
+	if r.Min != P(0, 0) || r.Max != P(4, 2) || r.Width() != 4 || r.Height() != 2 {
+		t.Errorf("Expected rectangle [(0,0),(4,2)], is %v", r)
+	}
+	s := EnclosingRect(P(3, 1), P(5, -1), P(4, 3))
+	if s.Min != P(3, -1) || s.Max != P(5, 3) {
+		t.Errorf("Expected enclosing rectangle [(3,-1),(5,3)], is %v", s)
+	}
+	if u := r.Union(s); u != NewRect(P(0, -1), P(5, 3)) {
+		t.Errorf("Expected union [(0,-1),(5,3)], is %v", u)
+	}
+	if i := r.Intersect(s); i != NewRect(P(3, 0), P(4, 2)) {
+		t.Errorf("Expected intersection [(3,0),(4,2)], is %v", i)
+	}
+	if i := r.Intersect(NewRect(P(10, 10), P(11, 11))); !i.IsEmpty() {
+		t.Errorf("Expected empty intersection, is %v", i)
+	}
+	if !r.Contains(P(4, 1)) || r.Contains(P(4.1, 1)) {
+		t.Errorf("Expected border to be contained, outside point not")
+	}
+	if i := r.Inset(0.5); i != NewRect(P(0.5, 0.5), P(3.5, 1.5)) {
+		t.Errorf("Expected inset rectangle [(0.5,0.5),(3.5,1.5)], is %v", i)
+	}
+	if r.Inset(1).IsEmpty() || !r.Inset(1.5).IsEmpty() {
+		t.Errorf("Expected rectangle to shrink to nothing")
+	}
+	if c := r.Corners(); c[1] != P(4, 0) || c[3] != P(0, 2) {
+		t.Errorf("Expected corners counter-clockwise from lower left, are %v", c)
+	}
+	if e := EnclosingRect(); !e.IsEmpty() || e.Union(r) != r {
+		t.Errorf("Expected empty rectangle to be neutral for union")
+	}
+}
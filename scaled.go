@@ -0,0 +1,205 @@
+package arithm
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// === MetaFont Scaled Numbers ===============================================
+
+// Scaled is a fixed-point number with 16 bits of fraction, the numeric type
+// of MetaFont ("scaled" in mf.web). Arithmetic on Scaled follows the
+// rounding rules of MetaFont, thus it may be used to reproduce results of
+// MetaFont exactly, as far as they depend on arithmetic of scaled numbers.
+//
+// Results exceeding the range of Scaled are clamped to ±(2³¹-1), as
+// MetaFont does after reporting an arithmetic overflow.
+type Scaled int32
+
+// ScaledUnity is the Scaled value of 1.0.
+const ScaledUnity Scaled = 1 << 16
+
+// elGordo is the largest Scaled value, as in mf.web.
+const elGordo = math.MaxInt32
+
+const halfUnit = 1 << 15
+
+// ErrEnormous is returned when parsing decimal numbers ≥ 4096, which
+// MetaFont does not accept.
+var ErrEnormous = errors.New("enormous number")
+
+// NewScaled converts a float64 to the nearest Scaled. Halfway cases are
+// rounded away from zero.
+func NewScaled(x float64) Scaled {
+	return clampScaled(math.Round(x * float64(ScaledUnity)))
+}
+
+// ScaledFromInt converts an integer to Scaled.
+func ScaledFromInt(n int) Scaled {
+	return clampScaled(float64(n) * float64(ScaledUnity))
+}
+
+func clampScaled(x float64) Scaled {
+	if x >= elGordo {
+		return elGordo
+	} else if x <= -elGordo {
+		return -elGordo
+	}
+	return Scaled(x)
+}
+
+func clamp64(x int64) Scaled {
+	if x > elGordo {
+		return elGordo
+	} else if x < -elGordo {
+		return -elGordo
+	}
+	return Scaled(x)
+}
+
+// Float64 returns s as a float64. The conversion is exact.
+func (s Scaled) Float64() float64 {
+	return float64(s) / float64(ScaledUnity)
+}
+
+// Add returns s+t.
+func (s Scaled) Add(t Scaled) Scaled {
+	return clamp64(int64(s) + int64(t))
+}
+
+// Sub returns s-t.
+func (s Scaled) Sub(t Scaled) Scaled {
+	return clamp64(int64(s) - int64(t))
+}
+
+// Mul returns s⋅t, rounded to the nearest Scaled ("take_scaled" in mf.web).
+// Halfway cases are rounded away from zero.
+func (s Scaled) Mul(t Scaled) Scaled {
+	return clamp64(roundShift(int64(s)*int64(t), 16))
+}
+
+// Div returns s/t, rounded to the nearest Scaled ("make_scaled" in mf.web).
+// Halfway cases are rounded away from zero. Div panics if t is zero.
+func (s Scaled) Div(t Scaled) Scaled {
+	if t == 0 {
+		panic("division of scaled number by zero")
+	}
+	p, q := int64(s)<<16, int64(t)
+	neg := (p < 0) != (q < 0)
+	if p < 0 {
+		p = -p
+	}
+	if q < 0 {
+		q = -q
+	}
+	r := (2*p + q) / (2 * q)
+	if neg {
+		r = -r
+	}
+	return clamp64(r)
+}
+
+// roundShift returns x/2ⁿ, rounded to the nearest integer, with halfway
+// cases rounded away from zero.
+func roundShift(x int64, n uint) int64 {
+	if x < 0 {
+		return -((-x + 1<<(n-1)) >> n)
+	}
+	return (x + 1<<(n-1)) >> n
+}
+
+// Half returns s/2, rounding halfway cases up (as "half" in mf.web).
+func (s Scaled) Half() Scaled {
+	if s&1 != 0 {
+		return Scaled((int64(s) + 1) / 2)
+	}
+	return s / 2
+}
+
+// Round returns s rounded to the nearest integer, rounding halfway cases up
+// ("round_unscaled" in mf.web).
+func (s Scaled) Round() int {
+	return int((int64(s) + halfUnit) >> 16)
+}
+
+// Floor returns the largest integer ≤ s.
+func (s Scaled) Floor() int {
+	return int(int64(s) >> 16)
+}
+
+// String prints s with the shortest decimal representation which will be read
+// back as s ("print_scaled" in mf.web).
+func (s Scaled) String() string {
+	var b strings.Builder
+	x := int64(s)
+	if x < 0 {
+		b.WriteByte('-')
+		x = -x
+	}
+	unity := int64(ScaledUnity)
+	b.WriteString(strconv.FormatInt(x/unity, 10))
+	x = 10*(x%unity) + 5
+	if x != 5 {
+		delta := int64(10)
+		b.WriteByte('.')
+		for {
+			if delta > unity {
+				x = x + halfUnit - delta/2 // round the final digit
+			}
+			b.WriteByte(byte('0' + x/unity))
+			x = 10 * (x % unity)
+			delta *= 10
+			if x <= delta {
+				break
+			}
+		}
+	}
+	return b.String()
+}
+
+// ParseScaled reads a decimal number the way MetaFont's scanner does: the
+// fraction is rounded to the nearest Scaled from at most 17 digits
+// ("round_decimals" in mf.web), and numbers ≥ 4096 are rejected with
+// ErrEnormous. A leading sign is accepted.
+func ParseScaled(str string) (Scaled, error) {
+	neg := false
+	if strings.HasPrefix(str, "-") {
+		neg, str = true, str[1:]
+	} else if strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+	intpart, frac := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intpart, frac = str[:i], str[i+1:]
+	}
+	if intpart == "" && frac == "" {
+		return 0, errors.New("not a number: " + str)
+	}
+	var n int64
+	for _, c := range intpart {
+		if c < '0' || c > '9' {
+			return 0, errors.New("not a number: " + str)
+		}
+		if n = 10*n + int64(c-'0'); n >= 4096 {
+			return 0, ErrEnormous
+		}
+	}
+	if len(frac) > 17 {
+		frac = frac[:17]
+	}
+	var a int64
+	for k := len(frac) - 1; k >= 0; k-- {
+		c := frac[k]
+		if c < '0' || c > '9' {
+			return 0, errors.New("not a number: " + str)
+		}
+		a = (a + int64(c-'0')*(2<<16)) / 10
+	}
+	s := Scaled(n<<16 + (a+1)/2)
+	if neg {
+		s = -s
+	}
+	return s, nil
+}
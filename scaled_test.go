@@ -0,0 +1,62 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestScaledArithmetic(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	third := ScaledUnity.Div(ScaledFromInt(3))
+	if third != 21845 {
+		t.Errorf("Expected 1/3 = 21845, is %d", third)
+	}
+	if s := third.Mul(ScaledFromInt(3)); s != 65535 {
+		t.Errorf("Expected 1/3⋅3 = 65535, is %d", s)
+	}
+	if s := Scaled(3).Mul(Scaled(1 << 15)); s != 2 { // 1.5 rounds away from zero
+		t.Errorf("Expected 2, is %d", s)
+	}
+	if s := Scaled(-3).Mul(Scaled(1 << 15)); s != -2 {
+		t.Errorf("Expected -2, is %d", s)
+	}
+	if h := Scaled(-3).Half(); h != -1 {
+		t.Errorf("Expected half(-3) = -1, is %d", h)
+	}
+	if r := NewScaled(-2.5).Round(); r != -2 {
+		t.Errorf("Expected round(-2.5) = -2, is %d", r)
+	}
+	if f := NewScaled(-2.5).Floor(); f != -3 {
+		t.Errorf("Expected floor(-2.5) = -3, is %d", f)
+	}
+	if s := Scaled(elGordo).Add(ScaledUnity); s != elGordo {
+		t.Errorf("Expected overflow to be clamped, is %d", s)
+	}
+}
+
+func TestScaledString(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	for _, x := range []struct {
+		s   Scaled
+		str string
+	}{
+		{ScaledUnity, "1"},
+		{NewScaled(-2.5), "-2.5"},
+		{21845, "0.33333"},
+		{1, "0.00002"},
+		{NewScaled(0.1), "0.1"},
+	} {
+		if str := x.s.String(); str != x.str {
+			t.Errorf("Expected %d to print as %s, is %s", x.s, x.str, str)
+		}
+		if s, err := ParseScaled(x.str); err != nil || s != x.s {
+			t.Errorf("Expected %s to read back as %d, is %d (%v)", x.str, x.s, s, err)
+		}
+	}
+	if _, err := ParseScaled("4096"); err != ErrEnormous {
+		t.Errorf("Expected 4096 to be too large for MetaFont")
+	}
+}
@@ -0,0 +1,88 @@
+package arithm
+
+import "math"
+
+// === Line Segments =========================================================
+
+// SegmentsIntersect checks if the line segments a1–a2 and b1–b2 intersect and
+// returns an intersection point. Segments touching at an end point or at an
+// inner point intersect. For collinear segments which overlap, the point of
+// the overlap closest to a1 is returned. Degenerate segments (with both end
+// points identical) are treated as points.
+//
+// Points are considered collinear if they deviate from a line by no more
+// than Epsilon, relative to the lengths of the segments.
+func SegmentsIntersect(a1, a2, b1, b2 Pair) (Pair, bool) {
+	d1, d2 := orientation(b1, b2, a1), orientation(b1, b2, a2)
+	d3, d4 := orientation(a1, a2, b1), orientation(a1, a2, b2)
+	if d1 == 0 && d2 == 0 && d3 == 0 && d4 == 0 {
+		return collinearOverlap(a1, a2, b1, b2)
+	}
+	if d1*d2 > 0 || d3*d4 > 0 {
+		return Origin, false
+	}
+	switch { // touching at an end point
+	case d1 == 0:
+		return a1, true
+	case d2 == 0:
+		return a2, true
+	case d3 == 0:
+		return b1, true
+	case d4 == 0:
+		return b2, true
+	}
+	t := d1 / (d1 - d2)
+	return Lerp(t, a1, a2), true
+}
+
+// orientation returns the signed area of the parallelogram spanned by a→b
+// and a→c, or 0 if c is on the line through a and b (within Epsilon,
+// relative to the lengths involved).
+func orientation(a, b, c Pair) float64 {
+	o := (b - a).Cross(c - a)
+	scale := math.Max((b - a).Length(), (c - a).Length())
+	if math.Abs(o) <= Epsilon*math.Max(scale*scale, 1) {
+		return 0
+	}
+	return o
+}
+
+// collinearOverlap finds the point of the overlap of collinear segments
+// closest to a1, if they overlap.
+func collinearOverlap(a1, a2, b1, b2 Pair) (Pair, bool) {
+	dir := a2 - a1
+	if dir.IsOrigin() {
+		dir = b2 - b1
+	}
+	if dir.IsOrigin() { // both segments are points
+		return a1, (a1 - b1).Length() <= Epsilon
+	}
+	param := func(p Pair) float64 { return (p - a1).Dot(dir) / dir.LengthSquared() }
+	ta0, ta1 := math.Min(param(a1), param(a2)), math.Max(param(a1), param(a2))
+	tb0, tb1 := math.Min(param(b1), param(b2)), math.Max(param(b1), param(b2))
+	lo, hi := math.Max(ta0, tb0), math.Min(ta1, tb1)
+	if lo > hi+Epsilon {
+		return Origin, false
+	}
+	// a1 has parameter 0; pick the end of the overlap closer to it
+	switch {
+	case lo <= 0 && 0 <= hi:
+		return a1, true
+	case hi < 0:
+		return Lerp(hi, a1, a1+dir), true
+	}
+	return Lerp(lo, a1, a1+dir), true
+}
+
+// ProjectOnSegment returns the point q of the line segment a–b closest to p,
+// together with its parameter 0 ≤ t ≤ 1, i.e. q = a + t⋅(b-a). For
+// degenerate segments, q is a and t is 0.
+func ProjectOnSegment(p, a, b Pair) (q Pair, t float64) {
+	ab := b - a
+	l2 := ab.LengthSquared()
+	if l2 == 0 {
+		return a, 0
+	}
+	t = math.Max(0, math.Min(1, (p-a).Dot(ab)/l2))
+	return Lerp(t, a, b), t
+}
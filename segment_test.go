@@ -0,0 +1,55 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestSegmentsIntersect(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	for i, c := range []struct {
+		a1, a2, b1, b2 Pair
+		ok             bool
+		at             Pair
+	}{
+		{P(0, 0), P(2, 2), P(0, 2), P(2, 0), true, P(1, 1)},  // crossing
+		{P(0, 0), P(1, 1), P(0, 2), P(2, 0), true, P(1, 1)},  // touching at end point
+		{P(0, 0), P(2, 0), P(1, 0), P(1, 3), true, P(1, 0)},  // T-junction
+		{P(0, 0), P(1, 0), P(0, 1), P(1, 1), false, Origin},  // parallel
+		{P(0, 0), P(1, 1), P(2, 2), P(3, 3), false, Origin},  // collinear, disjoint
+		{P(0, 0), P(2, 0), P(3, 0), P(1, 0), true, P(1, 0)},  // collinear, overlapping
+		{P(2, 0), P(4, 0), P(0, 0), P(3, 0), true, P(2, 0)},  // collinear, a1 inside b
+		{P(0, 0), P(1, 0), P(1, 0), P(2, 0), true, P(1, 0)},  // collinear, touching
+		{P(1, 1), P(1, 1), P(0, 0), P(2, 2), true, P(1, 1)},  // point on segment
+		{P(1, 1), P(1, 1), P(1, 1), P(1, 1), true, P(1, 1)},  // identical points
+		{P(0, 0), P(1, 0), P(2, -1), P(2, 1), false, Origin}, // beyond end point
+	} {
+		p, ok := SegmentsIntersect(c.a1, c.a2, c.b1, c.b2)
+		if ok != c.ok || (ok && !p.Equal(c.at)) {
+			t.Errorf("Case #%d: expected (%v,%v), is (%v,%v)", i, c.at, c.ok, p, ok)
+		}
+	}
+}
+
+func TestProjectOnSegment(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	a, b := P(1, 1), P(5, 1)
+	for _, x := range []struct {
+		p, q Pair
+		t    float64
+	}{
+		{P(2, 3), P(2, 1), 0.25},
+		{P(-1, -1), a, 0},
+		{P(9, 2), b, 1},
+	} {
+		if q, tt := ProjectOnSegment(x.p, a, b); !q.Equal(x.q) || !Is0(tt-x.t) {
+			t.Errorf("Expected %v to project onto %v at t=%g, is %v at t=%g", x.p, x.q, x.t, q, tt)
+		}
+	}
+	if q, tt := ProjectOnSegment(P(3, 3), a, a); q != a || tt != 0 {
+		t.Errorf("Expected degenerate segment to project onto its start, is %v", q)
+	}
+}
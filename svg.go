@@ -0,0 +1,112 @@
+package arithm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// === SVG Transforms ========================================================
+
+// ParseTransform creates an affine transform from a transform list in the
+// syntax of SVG's transform attribute (and of CSS transforms on SVG
+// elements), e.g.
+//
+//	translate(3,4) rotate(30) scale(2)
+//
+// Supported transform functions are matrix, translate, scale, rotate (with
+// an optional center), skewX and skewY. Angles are in degrees. As in SVG,
+// the transforms of a list are applied right to left, i.e. the example above
+// scales a point first and translates it last.
+func ParseTransform(s string) (AT, error) {
+	t := Identity()
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		close := strings.IndexByte(rest, ')')
+		if open < 0 || close < open {
+			return nil, fmt.Errorf("malformed transform list: %q", s)
+		}
+		name := strings.TrimSpace(rest[:open])
+		args, err := parseTransformArgs(rest[open+1 : close])
+		if err != nil {
+			return nil, fmt.Errorf("malformed arguments of %s: %v", name, err)
+		}
+		m, err := svgTransform(name, args)
+		if err != nil {
+			return nil, err
+		}
+		t = m.Combine(t) // later transforms are applied before earlier ones
+		rest = strings.TrimLeft(rest[close+1:], " \t\r\n,")
+	}
+	return t, nil
+}
+
+// parseTransformArgs splits the arguments of a transform function, which
+// are separated by whitespace and/or a comma.
+func parseTransformArgs(s string) ([]float64, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})
+	args := make([]float64, len(fields))
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = x
+	}
+	return args, nil
+}
+
+// svgTransform creates the affine transform for a single SVG transform
+// function.
+func svgTransform(name string, args []float64) (AT, error) {
+	argc := func(counts ...int) error {
+		for _, n := range counts {
+			if len(args) == n {
+				return nil
+			}
+		}
+		return fmt.Errorf("wrong number of arguments for %s: %d", name, len(args))
+	}
+	switch name {
+	case "matrix":
+		if err := argc(6); err != nil {
+			return nil, err
+		}
+		var c [6]float64
+		copy(c[:], args)
+		return FromColumnMajor(c), nil
+	case "translate":
+		if err := argc(1, 2); err != nil {
+			return nil, err
+		}
+		args = append(args, 0)
+		return Translation(P(args[0], args[1])), nil
+	case "scale":
+		if err := argc(1, 2); err != nil {
+			return nil, err
+		}
+		args = append(args, args[0])
+		return Scaling(args[0], args[1]), nil
+	case "rotate":
+		if err := argc(1, 3); err != nil {
+			return nil, err
+		}
+		args = append(args, 0, 0)
+		return RotationAround(P(args[1], args[2]), args[0]*math.Pi/180), nil
+	case "skewX":
+		if err := argc(1); err != nil {
+			return nil, err
+		}
+		return Shear(math.Tan(args[0]*math.Pi/180), 0), nil
+	case "skewY":
+		if err := argc(1); err != nil {
+			return nil, err
+		}
+		return Shear(0, math.Tan(args[0]*math.Pi/180)), nil
+	}
+	return nil, fmt.Errorf("unknown transform function: %q", name)
+}
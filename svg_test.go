@@ -0,0 +1,44 @@
+package arithm
+
+import (
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestParseTransform(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	m, err := ParseTransform("translate(3,4) rotate(90) scale(2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.Transform(P(1, 0)); !p.Equal(P(3, 6)) {
+		t.Errorf("Expected (1,0) to transform to (3,6), is %v", p)
+	}
+	m, err = ParseTransform("rotate(180 1 1),skewX(45)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.Transform(P(0, 1)); !p.Equal(P(1, 1)) {
+		t.Errorf("Expected (0,1) to transform to (1,1), is %v", p)
+	}
+	if p := m.Transform(P(1, 0)); !p.Equal(P(1, 2)) {
+		t.Errorf("Expected (1,0) to transform to (1,2), is %v", p)
+	}
+	m, err = ParseTransform(" matrix(1 2 3 4 5 6) ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.Transform(P(1, 1)); !p.Equal(P(9, 12)) {
+		t.Errorf("Expected (1,1) to transform to (9,12), is %v", p)
+	}
+	if m, err = ParseTransform(""); err != nil || !m.Equal(Identity(), 0) {
+		t.Errorf("Expected empty transform list to be the identity")
+	}
+	for _, s := range []string{"scale()", "rotate(1,2)", "shrink(2)", "translate(1", "scale(x)"} {
+		if _, err := ParseTransform(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
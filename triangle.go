@@ -0,0 +1,40 @@
+package arithm
+
+import "math"
+
+// === Triangles =============================================================
+
+// Barycentric returns the barycentric coordinates (u,v,w) of p with respect
+// to the triangle (a,b,c), i.e. the weights with p = u⋅a + v⋅b + w⋅c and
+// u+v+w = 1. p is inside the triangle if all of u, v and w are
+// non-negative. For degenerate triangles all coordinates are NaN.
+//
+// A triangle is degenerate if its area is 0 within Epsilon, relative to the
+// square of its longest edge. The test thus does not depend on the units
+// of the coordinates, and it does not consider small triangles degenerate.
+func Barycentric(p, a, b, c Pair) (u, v, w float64) {
+	det := (b - a).Cross(c - a)
+	scale := math.Max((b - a).Length(), math.Max((c - a).Length(), (c - b).Length()))
+	if math.Abs(det) <= Epsilon*scale*scale || math.IsNaN(det) {
+		nan := math.NaN()
+		return nan, nan, nan
+	}
+	v = (p - a).Cross(c-a) / det
+	w = (b - a).Cross(p-a) / det
+	return 1 - v - w, v, w
+}
+
+// FromBarycentric returns the point with barycentric coordinates (u,v,w)
+// with respect to the triangle (a,b,c), i.e. u⋅a + v⋅b + w⋅c. It is the
+// inverse of Barycentric. Mapping barycentric coordinates of one triangle
+// onto another one warps the points inside the triangles.
+func FromBarycentric(u, v, w float64, a, b, c Pair) Pair {
+	return P(u*a.X()+v*b.X()+w*c.X(), u*a.Y()+v*b.Y()+w*c.Y())
+}
+
+// InTriangle is a predicate: is p inside of or on the border of the
+// triangle (a,b,c)? Degenerate triangles contain no points.
+func InTriangle(p, a, b, c Pair) bool {
+	u, v, w := Barycentric(p, a, b, c)
+	return u >= -Epsilon && v >= -Epsilon && w >= -Epsilon
+}
@@ -0,0 +1,35 @@
+package arithm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npillmayer/schuko/tracing/gotestingadapter"
+)
+
+func TestBarycentric(t *testing.T) {
+	teardown := gotestingadapter.RedirectTracing(t)
+	defer teardown()
+	a, b, c := P(0, 0), P(4, 0), P(0, 2)
+	u, v, w := Barycentric(P(1, 1), a, b, c)
+	if !Is0(u-0.25) || !Is0(v-0.25) || !Is0(w-0.5) {
+		t.Errorf("Expected (0.25,0.25,0.5), is (%g,%g,%g)", u, v, w)
+	}
+	if p := FromBarycentric(u, v, w, a, b, c); !p.Equal(P(1, 1)) {
+		t.Errorf("Expected round trip to (1,1), is %v", p)
+	}
+	if !InTriangle(P(1, 1), a, b, c) || !InTriangle(P(2, 0), a, b, c) || InTriangle(P(3, 1), a, b, c) {
+		t.Errorf("Unexpected result of point-in-triangle test")
+	}
+	if InTriangle(P(1, 0), a, b, P(8, 0)) {
+		t.Errorf("Expected degenerate triangle to contain no points")
+	}
+	small := func(p Pair) Pair { return p.Scaled(1e-5) }
+	if u, v, w := Barycentric(small(P(1, 1)), small(a), small(b), small(c)); !Is0(u-0.25) || !Is0(v-0.25) || !Is0(w-0.5) {
+		t.Errorf("Expected (0.25,0.25,0.5) for small triangle, is (%g,%g,%g)", u, v, w)
+	}
+	large := func(p Pair) Pair { return p.Scaled(1e5) }
+	if u, _, _ := Barycentric(large(P(1, 1)), large(a), large(b), large(P(8, 1e-9))); !math.IsNaN(u) {
+		t.Errorf("Expected large, flat triangle to be degenerate, is %g", u)
+	}
+}